@@ -0,0 +1,245 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// AddOnHandler AddOn endpoint handler
+type AddOnHandler struct {
+	handlerBase
+	Service *domain_services.AddOnService
+}
+
+// Register AddOnHandler
+// this method registers all routes,routeGroups and passes AddOnHandler's related dependencies
+func (handler *AddOnHandler) Register(config *dto.HandlerConfig, service *domain_services.AddOnService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags AddOn
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  AddOn body  models.AddOn true "AddOn"
+// @Success 200 {object} models.AddOn
+// @Router /add-ons [post]
+func (handler *AddOnHandler) create(c echo.Context) error {
+
+	addOn := &models.AddOn{}
+	user := currentUser(c)
+
+	if err := c.Bind(&addOn); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest,
+			commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+			})
+	}
+
+	if ok, err := addOn.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	addOn.SetAudit(user)
+	result, err := handler.Service.Create(tenantContext(c), addOn)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+		Data:         result,
+	})
+}
+
+// @Tags AddOn
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  AddOn body  models.AddOn true "AddOn"
+// @Success 200 {object} models.AddOn
+// @Router /add-ons/{id} [put]
+func (handler *AddOnHandler) update(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	addOn, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if addOn == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	if err := c.Bind(&addOn); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := addOn.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	addOn.SetUpdatedBy(currentUser(c))
+	if result, err := handler.Service.Update(tenantContext(c), addOn); err == nil {
+
+		return c.JSON(http.StatusOK, commons.ApiResponse{
+			Data:         result,
+			ResponseCode: http.StatusOK,
+			Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+		})
+	} else {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+}
+
+// @Tags AddOn
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.AddOn
+// @Router /add-ons/{id} [get]
+func (handler *AddOnHandler) find(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	addOn, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if addOn == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Data:         nil,
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         addOn,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags AddOn
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} models.AddOn
+// @Router /add-ons [get]
+func (handler *AddOnHandler) findAll(c echo.Context) error {
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	list, err := handler.Service.FindAll(tenantContext(c), paginationInput)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags AddOn
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.AddOn
+// @Router /add-ons/{id} [delete]
+func (handler *AddOnHandler) delete(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	err = handler.Service.Delete(tenantContext(c), id)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Deleted),
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *AddOnHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/add-ons")
+	routeGroup.POST("", handler.create)
+	routeGroup.PUT("/:id", handler.update)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
+}
@@ -0,0 +1,245 @@
+// Package handlers
+// handles all http requests
+// /**/
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// CancellationPolicyHandler CancellationPolicy endpoint handler
+type CancellationPolicyHandler struct {
+	handlerBase
+	Service *domain_services.CancellationPolicyService
+}
+
+// Register CancellationPolicyHandler
+// this method registers all routes,routeGroups and passes CancellationPolicyHandler's related dependencies
+func (handler *CancellationPolicyHandler) Register(config *dto.HandlerConfig, service *domain_services.CancellationPolicyService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags CancellationPolicy
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  CancellationPolicy body  models.CancellationPolicy true "CancellationPolicy"
+// @Success 200 {object} models.CancellationPolicy
+// @Router /cancellation-policies [post]
+func (handler *CancellationPolicyHandler) create(c echo.Context) error {
+
+	cancellationPolicy := &models.CancellationPolicy{}
+	user := currentUser(c)
+
+	if err := c.Bind(&cancellationPolicy); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest,
+			commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+			})
+	}
+
+	if ok, err := cancellationPolicy.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	cancellationPolicy.SetAudit(user)
+	result, err := handler.Service.Create(tenantContext(c), cancellationPolicy)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+		Data:         result,
+	})
+}
+
+// @Tags CancellationPolicy
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  CancellationPolicy body  models.CancellationPolicy true "CancellationPolicy"
+// @Success 200 {object} models.CancellationPolicy
+// @Router /cancellation-policies/{id} [put]
+func (handler *CancellationPolicyHandler) update(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	cancellationPolicy, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if cancellationPolicy == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	if err := c.Bind(&cancellationPolicy); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := cancellationPolicy.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	cancellationPolicy.SetUpdatedBy(currentUser(c))
+	if result, err := handler.Service.Update(tenantContext(c), cancellationPolicy); err == nil {
+
+		return c.JSON(http.StatusOK, commons.ApiResponse{
+			Data:         result,
+			ResponseCode: http.StatusOK,
+			Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+		})
+	} else {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+}
+
+// @Tags CancellationPolicy
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.CancellationPolicy
+// @Router /cancellation-policies/{id} [get]
+func (handler *CancellationPolicyHandler) find(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	cancellationPolicy, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if cancellationPolicy == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Data:         nil,
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         cancellationPolicy,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags CancellationPolicy
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} models.CancellationPolicy
+// @Router /cancellation-policies [get]
+func (handler *CancellationPolicyHandler) findAll(c echo.Context) error {
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	list, err := handler.Service.FindAll(tenantContext(c), paginationInput)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags CancellationPolicy
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.CancellationPolicy
+// @Router /cancellation-policies/{id} [delete]
+func (handler *CancellationPolicyHandler) delete(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	err = handler.Service.Delete(tenantContext(c), id)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Deleted),
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *CancellationPolicyHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/cancellation-policies")
+	routeGroup.POST("", handler.create)
+	routeGroup.PUT("/:id", handler.update)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
+}
@@ -0,0 +1,253 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// ChildAgeBandHandler ChildAgeBand endpoint handler
+type ChildAgeBandHandler struct {
+	handlerBase
+	Service *domain_services.ChildAgeBandService
+}
+
+// Register ChildAgeBandHandler
+// this method registers all routes,routeGroups and passes ChildAgeBandHandler's related dependencies
+func (handler *ChildAgeBandHandler) Register(config *dto.HandlerConfig, service *domain_services.ChildAgeBandService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags ChildAgeBand
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  ChildAgeBand body  models.ChildAgeBand true "ChildAgeBand"
+// @Success 200 {object} models.ChildAgeBand
+// @Router /child-age-bands [post]
+func (handler *ChildAgeBandHandler) create(c echo.Context) error {
+
+	childAgeBand := &models.ChildAgeBand{}
+	user := currentUser(c)
+
+	if err := c.Bind(&childAgeBand); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest,
+			commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+			})
+	}
+
+	if ok, err := childAgeBand.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      localizeError(c, err),
+		})
+	}
+
+	childAgeBand.SetAudit(user)
+	result, err := handler.Service.Create(tenantContext(c), childAgeBand)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      localizeError(c, err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+		Data:         result,
+	})
+}
+
+// @Tags ChildAgeBand
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  ChildAgeBand body  models.ChildAgeBand true "ChildAgeBand"
+// @Success 200 {object} models.ChildAgeBand
+// @Router /child-age-bands/{id} [put]
+func (handler *ChildAgeBandHandler) update(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	childAgeBand, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if childAgeBand == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	if err := c.Bind(&childAgeBand); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := childAgeBand.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      localizeError(c, err),
+		})
+	}
+
+	childAgeBand.SetUpdatedBy(currentUser(c))
+	if result, err := handler.Service.Update(tenantContext(c), childAgeBand); err == nil {
+
+		return c.JSON(http.StatusOK, commons.ApiResponse{
+			Data:         result,
+			ResponseCode: http.StatusOK,
+			Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+		})
+	} else if errors.Is(err, models.ChildAgeBandOverlapErr) {
+
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	} else {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+}
+
+// @Tags ChildAgeBand
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.ChildAgeBand
+// @Router /child-age-bands/{id} [get]
+func (handler *ChildAgeBandHandler) find(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	childAgeBand, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if childAgeBand == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Data:         nil,
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         childAgeBand,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags ChildAgeBand
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} models.ChildAgeBand
+// @Router /child-age-bands [get]
+func (handler *ChildAgeBandHandler) findAll(c echo.Context) error {
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	list, err := handler.Service.FindAll(tenantContext(c), paginationInput)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags ChildAgeBand
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.ChildAgeBand
+// @Router /child-age-bands/{id} [delete]
+func (handler *ChildAgeBandHandler) delete(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	err = handler.Service.Delete(tenantContext(c), id)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Deleted),
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *ChildAgeBandHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/child-age-bands")
+	routeGroup.POST("", handler.create)
+	routeGroup.PUT("/:id", handler.update)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
+}
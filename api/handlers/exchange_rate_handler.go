@@ -0,0 +1,294 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// ExchangeRateHandler ExchangeRate endpoint handler
+type ExchangeRateHandler struct {
+	handlerBase
+	Service *domain_services.ExchangeRateService
+}
+
+// Register ExchangeRateHandler
+// this method registers all routes,routeGroups and passes ExchangeRateHandler's related dependencies
+func (handler *ExchangeRateHandler) Register(config *dto.HandlerConfig, service *domain_services.ExchangeRateService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags ExchangeRate
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  ExchangeRate body  models.ExchangeRate true "ExchangeRate"
+// @Success 200 {object} models.ExchangeRate
+// @Router /exchange-rates [post]
+func (handler *ExchangeRateHandler) create(c echo.Context) error {
+
+	exchangeRate := &models.ExchangeRate{}
+	user := currentUser(c)
+
+	if err := c.Bind(&exchangeRate); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest,
+			commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+			})
+	}
+
+	if ok, err := exchangeRate.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	exchangeRate.SetAudit(user)
+	result, err := handler.Service.Create(tenantContext(c), exchangeRate)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+		Data:         result,
+	})
+}
+
+// @Tags ExchangeRate
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  ExchangeRate body  models.ExchangeRate true "ExchangeRate"
+// @Success 200 {object} models.ExchangeRate
+// @Router /exchange-rates/{id} [put]
+func (handler *ExchangeRateHandler) update(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	exchangeRate, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if exchangeRate == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	if err := c.Bind(&exchangeRate); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := exchangeRate.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	exchangeRate.SetUpdatedBy(currentUser(c))
+	if result, err := handler.Service.Update(tenantContext(c), exchangeRate); err == nil {
+
+		return c.JSON(http.StatusOK, commons.ApiResponse{
+			Data:         result,
+			ResponseCode: http.StatusOK,
+			Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+		})
+	} else {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+}
+
+// @Tags ExchangeRate
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.ExchangeRate
+// @Router /exchange-rates/{id} [get]
+func (handler *ExchangeRateHandler) find(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	exchangeRate, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if exchangeRate == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Data:         nil,
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         exchangeRate,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags ExchangeRate
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} models.ExchangeRate
+// @Router /exchange-rates [get]
+func (handler *ExchangeRateHandler) findAll(c echo.Context) error {
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	list, err := handler.Service.FindAll(tenantContext(c), paginationInput)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags ExchangeRate
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.ExchangeRate
+// @Router /exchange-rates/{id} [delete]
+func (handler *ExchangeRateHandler) delete(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	err = handler.Service.Delete(tenantContext(c), id)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Deleted),
+	})
+}
+
+// @Tags ExchangeRate
+// @Accept multipart/form-data
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param file formData file true "csv file with from,to,rate,effective_date columns"
+// @Produce json
+// @Success 200 {object} dto.ExchangeRateImportResultDto
+// @Router /exchange-rates/import [post]
+func (handler *ExchangeRateHandler) importRates(c echo.Context) error {
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+	defer file.Close()
+
+	result, err := handler.Service.Import(tenantContext(c), file, currentUser(c))
+	if err != nil {
+
+		if errors.Is(err, models.InvalidExchangeRatesFileErr) || errors.Is(err, models.DuplicateExchangeRateErr) ||
+			errors.Is(err, domain_services.UnknownCurrencyCodeErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *ExchangeRateHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/exchange-rates")
+	routeGroup.POST("", handler.create)
+	routeGroup.POST("/import", handler.importRates)
+	routeGroup.PUT("/:id", handler.update)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
+}
@@ -0,0 +1,310 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// FolioHandler reservation folios endpoint handler
+type FolioHandler struct {
+	handlerBase
+	Service *domain_services.FolioService
+}
+
+// Register FolioHandler
+// this method registers all routes,routeGroups and passes FolioHandler's related dependencies
+func (handler *FolioHandler) Register(config *dto.HandlerConfig, service *domain_services.FolioService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  Folio body  models.Folio true "Folio"
+// @Success 200 {object} models.Folio
+// @Router /folios [post]
+func (handler *FolioHandler) create(c echo.Context) error {
+
+	folio := &models.Folio{}
+	if err := c.Bind(&folio); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if folio.ReservationId == 0 {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	folio.SetAudit(currentUser(c))
+	result, err := handler.Service.Create(tenantContext(c), folio)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} models.Folio
+// @Router /folios/{id} [get]
+func (handler *FolioHandler) find(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	folio, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if folio == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         folio,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param reservation_id query int true "ReservationId"
+// @Produce json
+// @Success 200 {array} models.Folio
+// @Router /folios [get]
+func (handler *FolioHandler) findByReservation(c echo.Context) error {
+
+	reservationId, err := strconv.ParseUint(c.QueryParam("reservation_id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	result, err := handler.Service.FindByReservation(tenantContext(c), reservationId)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  FolioEntry body  models.FolioEntry true "FolioEntry"
+// @Success 200 {object} models.FolioEntry
+// @Router /folios/{id}/entries [post]
+func (handler *FolioHandler) post(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	entry := &models.FolioEntry{}
+	if err := c.Bind(&entry); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Post(tenantContext(c), id, entry, currentUser(c))
+	return handler.entryResult(c, result, err)
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} models.FolioEntry
+// @Router /folios/entries/{id}/reverse [post]
+func (handler *FolioHandler) reverse(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Reverse(tenantContext(c), id, currentUser(c))
+	return handler.entryResult(c, result, err)
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  FolioTransfer body  dto.FolioTransferDto true "FolioTransfer"
+// @Success 200 {array} models.FolioEntry
+// @Router /folios/entries/{id}/transfer [post]
+func (handler *FolioHandler) transfer(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	input := dto.FolioTransferDto{}
+	if err := c.Bind(&input); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Transfer(tenantContext(c), id, input.FolioId, currentUser(c))
+	if err != nil {
+
+		if models.IsFolioErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// @Tags Folio
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} models.Folio
+// @Router /folios/{id}/settle [post]
+func (handler *FolioHandler) settle(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Settle(tenantContext(c), id, currentUser(c))
+	if err != nil {
+
+		if models.IsFolioErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+	})
+}
+
+// entryResult returns response of posting a folio entry.
+func (handler *FolioHandler) entryResult(c echo.Context, result *models.FolioEntry, err error) error {
+
+	if err != nil {
+
+		if models.IsFolioErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *FolioHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/folios")
+	routeGroup.POST("", handler.create)
+	routeGroup.GET("", handler.findByReservation)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.POST("/:id/entries", handler.post)
+	routeGroup.POST("/:id/settle", handler.settle)
+	routeGroup.POST("/entries/:id/reverse", handler.reverse)
+	routeGroup.POST("/entries/:id/transfer", handler.transfer)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"reservation-api/internal/global_variables"
+	"reservation-api/pkg/translator"
 	"strings"
 	"time"
 )
@@ -58,6 +59,16 @@ func getOutputQueryParamVal(c echo.Context) string {
 	return strings.TrimSpace(c.QueryParam("output"))
 }
 
+// localizeError returns translated message of given error,
+// errors which are not a translation key are returned as is.
+func localizeError(c echo.Context, err error) string {
+
+	if message := translator.Localize(c.Request().Context(), err.Error()); message != "" {
+		return message
+	}
+	return err.Error()
+}
+
 // setCreatedByUpdatedBy fills CreatedBy and UpdatedBy fields.
 func setCreatedByUpdatedBy(entity interface{}, audit string) {
 	//val := reflect.Indirect(reflect.ValueOf(entity))
@@ -0,0 +1,222 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// GroupReservationHandler group and block bookings endpoint handler
+type GroupReservationHandler struct {
+	handlerBase
+	Service *domain_services.GroupReservationService
+}
+
+// Register GroupReservationHandler
+// this method registers all routes,routeGroups and passes GroupReservationHandler's related dependencies
+func (handler *GroupReservationHandler) Register(config *dto.HandlerConfig, service *domain_services.GroupReservationService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags GroupReservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  GroupReservation body  dto.GroupReservationCreateDto true "GroupReservation"
+// @Success 200 {object} models.Reservation
+// @Router /group-reservations [post]
+func (handler *GroupReservationHandler) create(c echo.Context) error {
+
+	input := dto.GroupReservationCreateDto{}
+	if err := c.Bind(&input); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	if ok, err := input.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	result, err := handler.Service.Create(tenantContext(c), &input, currentUser(c))
+	if err != nil {
+
+		if errors.Is(err, domain_services.InvalidGroupReservationErr) || models.IsOccupancyErr(err) ||
+			models.IsAddOnErr(err) || errors.Is(err, repositories.RateNotAvailableErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      localizeError(c, err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// @Tags GroupReservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} models.Reservation
+// @Router /group-reservations/{id} [get]
+func (handler *GroupReservationHandler) find(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags GroupReservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  RoomingList body  []dto.RoomingListItemDto true "RoomingList"
+// @Success 200 {object} models.Reservation
+// @Router /group-reservations/{id}/rooming-list [put]
+func (handler *GroupReservationHandler) roomingList(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	items := make([]*dto.RoomingListItemDto, 0)
+	if err := c.Bind(&items); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	master, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if master == nil {
+		return c.JSON(http.StatusNotFound, nil)
+	}
+
+	if err := handler.Service.ApplyRoomingList(tenantContext(c), id, items, currentUser(c)); err != nil {
+
+		if errors.Is(err, repositories.GroupRoomNotFoundErr) || errors.Is(err, repositories.RateNotAvailableErr) ||
+			errors.Is(err, repositories.EmptyRoomingListItemErr) || models.IsOccupancyErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	result, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+	})
+}
+
+// @Tags GroupReservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} dto.GroupFolioDto
+// @Router /group-reservations/{id}/folio [get]
+func (handler *GroupReservationHandler) folio(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	master, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if master == nil {
+		return c.JSON(http.StatusNotFound, nil)
+	}
+
+	result, err := handler.Service.GetFolio(tenantContext(c), master)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *GroupReservationHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/group-reservations")
+	routeGroup.POST("", handler.create)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.PUT("/:id/rooming-list", handler.roomingList)
+	routeGroup.GET("/:id/folio", handler.folio)
+}
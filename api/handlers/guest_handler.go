@@ -4,8 +4,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/global_variables"
@@ -184,10 +187,241 @@ func (handler *GuestHandler) findAll(c echo.Context) error {
 	})
 }
 
+// @Tags Guest
+// @Description returns pairs of guests which are probably the same person by their documents, contacts and names
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param min_score query number false "minimum score of duplicates from 0 to 100"
+// @Param guest_id query int false "returns duplicates of this guest"
+// @Param page query int false "page"
+// @Param page_size query int false "page size"
+// @Produce json
+// @Success 200 {object} commons.PaginatedResult
+// @Router /guests/duplicates [get]
+func (handler *GuestHandler) findDuplicates(c echo.Context) error {
+
+	filter := dto.GuestDuplicateFilter{}
+	if err := c.Bind(&filter); err != nil {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	result, err := handler.Service.FindDuplicates(tenantContext(c), &filter, paginationInput)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data: result,
+	})
+}
+
+// @Tags Guest
+// @Description merges a duplicate guest into the surviving guest, its reservations, sharers and payments are moved to
+// @Description the survivor and it is deleted
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param  GuestMergeDto body  dto.GuestMergeDto true "GuestMergeDto"
+// @Produce json
+// @Success 200 {object} models.GuestMergeLog
+// @Router /guests/merge [post]
+func (handler *GuestHandler) merge(c echo.Context) error {
+
+	input := &dto.GuestMergeDto{}
+	if err := c.Bind(input); err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := input.Validate(); !ok {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	result, err := handler.Service.Merge(tenantContext(c), input, currentUser(c))
+	if err != nil {
+		if errors.Is(err, models.SameGuestMergeErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      localizeError(c, err),
+			})
+		}
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags Guest
+// @Description exports everything which is held about the guest as a zip archive, guest.json has the profile,
+// @Description reservations, sharers, payments and emails of the guest and invoice documents are attached
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce application/zip
+// @Success 200 {file} file
+// @Router /guests/{id}/export [get]
+func (handler *GuestHandler) export(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	content, err := handler.Service.Export(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if content == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Message: translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=guest-%d.%s", id, ZIP))
+	return c.Blob(http.StatusOK, "application/zip", content)
+}
+
+// @Tags Guest
+// @Description irreversibly replaces personal data of the guest with a pseudonym, reservations and financial
+// @Description records of the guest are kept
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} models.Guest
+// @Router /guests/{id}/anonymize [post]
+func (handler *GuestHandler) anonymize(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	guest, err := handler.Service.Anonymize(tenantContext(c), id, currentUser(c))
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if guest == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Message: translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         guest,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+	})
+}
+
+// @Tags Guest
+// @Description returns how long guest profiles are kept before they are anonymized, zero days keeps them forever
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {object} models.RetentionPolicy
+// @Router /guests/retention-policy [get]
+func (handler *GuestHandler) retentionPolicy(c echo.Context) error {
+
+	policy, err := handler.Service.RetentionPolicy(tenantContext(c))
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data: policy,
+	})
+}
+
+// @Tags Guest
+// @Description sets how long guest profiles are kept, profiles of guests who have not stayed for guest_retention_days
+// @Description are anonymized every night
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param  RetentionPolicy body  models.RetentionPolicy true "RetentionPolicy"
+// @Produce json
+// @Success 200 {object} models.RetentionPolicy
+// @Router /guests/retention-policy [put]
+func (handler *GuestHandler) setRetentionPolicy(c echo.Context) error {
+
+	policy := &models.RetentionPolicy{}
+	if err := c.Bind(policy); err != nil {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	policy.SetAudit(currentUser(c))
+	result, err := handler.Service.SetRetentionPolicy(tenantContext(c), policy)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:    result,
+		Message: translator.Localize(c.Request().Context(), message_keys.Updated),
+	})
+}
+
+// @Tags Guest
+// @Description adds a new data key to the tenant which encrypts identity documents of guests, documents which are
+// @Description encrypted with old keys are re-encrypted in background
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {object} commons.ApiResponse
+// @Router /guests/encryption-key/rotate [post]
+func (handler *GuestHandler) rotateEncryptionKey(c echo.Context) error {
+
+	version, err := handler.Service.RotateEncryptionKey(tenantContext(c))
+	if err != nil {
+		if errors.Is(err, models.FieldEncryptionDisabledErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      localizeError(c, err),
+			})
+		}
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         map[string]uint32{"key_version": version},
+		ResponseCode: http.StatusOK,
+	})
+}
+
 // ============================= register routes ================================================== //
 func (handler *GuestHandler) registerRoutes() {
 	routeGroup := handler.Router.Group("/guests")
 	routeGroup.POST("", handler.create)
+	routeGroup.GET("/duplicates", handler.findDuplicates, middlewares2.PaginationMiddleware)
+	routeGroup.POST("/merge", handler.merge)
+	routeGroup.GET("/retention-policy", handler.retentionPolicy)
+	routeGroup.PUT("/retention-policy", handler.setRetentionPolicy)
+	routeGroup.POST("/encryption-key/rotate", handler.rotateEncryptionKey)
+	routeGroup.GET("/:id/export", handler.export)
+	routeGroup.POST("/:id/anonymize", handler.anonymize)
 	routeGroup.GET("/:id", handler.find)
 	routeGroup.GET("", handler.findAll)
 	routeGroup.PUT("/:id", handler.update)
@@ -0,0 +1,103 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// InvoiceHandler reservation invoices endpoint handler
+type InvoiceHandler struct {
+	handlerBase
+	Service *domain_services.InvoiceService
+}
+
+// Register InvoiceHandler
+// this method registers all routes,routeGroups and passes InvoiceHandler's related dependencies
+func (handler *InvoiceHandler) Register(config *dto.HandlerConfig, service *domain_services.InvoiceService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags Invoice
+// @Description returns the last issued invoice of the reservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce application/pdf
+// @Success 200 {file} file
+// @Router /reservation/{id}/invoice [get]
+func (handler *InvoiceHandler) invoice(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	invoice, content, err := handler.Service.Latest(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return handler.invoiceDocument(c, invoice, content)
+}
+
+// @Tags Invoice
+// @Description issues invoice of the reservation, the last invoice is reissued if no entries are posted after it
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce application/pdf
+// @Success 200 {file} file
+// @Router /reservation/{id}/invoice [post]
+func (handler *InvoiceHandler) issue(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	invoice, content, err := handler.Service.Issue(tenantContext(c), id, currentUser(c))
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return handler.invoiceDocument(c, invoice, content)
+}
+
+// invoiceDocument returns PDF document of the invoice, it returns not found if there is no invoice.
+func (handler *InvoiceHandler) invoiceDocument(c echo.Context, invoice *models.Invoice, content []byte) error {
+
+	if invoice == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%s.%s", invoice.InvoiceNo, PDF))
+	return c.Blob(http.StatusOK, "application/pdf", content)
+}
+
+// ============================= register routes ================================================== //
+func (handler *InvoiceHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/reservation")
+	routeGroup.GET("/:id/invoice", handler.invoice)
+	routeGroup.POST("/:id/invoice", handler.issue)
+}
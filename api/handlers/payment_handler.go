@@ -4,12 +4,15 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
 	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
 	"strconv"
 )
 
@@ -51,26 +54,209 @@ func (handler *PaymentHandler) create(c echo.Context) error {
 
 // @Tags Payment
 // @Accept json
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Param  PaymentRefund body  dto.PaymentRefundDto true "PaymentRefund"
+// @Success 200 {object} models.Payment
+// @Router /payment/{id}/refund [post]
+func (handler *PaymentHandler) refund(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	input := &dto.PaymentRefundDto{}
+	if err := c.Bind(input); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := input.Validate(); !ok {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	result, err := handler.PaymentService.Refund(tenantContext(c), id, input.Amount, input.Reason, currentUser(c))
+	if err != nil {
+		if errors.Is(err, models.RefundExceedsPaymentErr) || errors.Is(err, models.InvalidRefundErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      localizeError(c, err),
+			})
+		}
+		return handler.chargeError(c, err)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// @Tags Payment
+// @Accept json
+// @Produce json
 // @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param  Charge body  dto.ChargeDto true "Charge"
+// @Success 200 {object} models.Charge
+// @Router /payment/charges [post]
+func (handler *PaymentHandler) charge(c echo.Context) error {
+
+	input := &dto.ChargeDto{}
+	if err := c.Bind(input); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := input.Validate(); !ok {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	result, err := handler.PaymentService.Charge(tenantContext(c), input, currentUser(c))
+	if err != nil {
+		return handler.chargeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
+}
+
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Success 200 {object} models.Charge
+// @Router /payment/charges/{id} [get]
+func (handler *PaymentHandler) findCharge(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.PaymentService.FindCharge(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return handler.chargeResponse(c, result)
+}
+
+// @Tags Payment
+// @Accept json
 // @Produce json
-// @Success 200 {array} models.Payment
-// @Router /payments [delete]
-func (handler *PaymentHandler) delete(c echo.Context) error {
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Param  ChargeCapture body  dto.ChargeCaptureDto true "ChargeCapture"
+// @Success 200 {object} models.Charge
+// @Router /payment/charges/{id}/capture [post]
+func (handler *PaymentHandler) captureCharge(c echo.Context) error {
 
-	idStr := fmt.Sprintf("%s", c.Get("id"))
-	id, _ := strconv.ParseUint(idStr, 10, 64)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
 
-	if err := handler.PaymentService.Delete(tenantContext(c), id); err != nil {
+	input := &dto.ChargeCaptureDto{}
+	if err := c.Bind(input); err != nil {
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusBadRequest, nil)
 	}
-	return c.JSON(http.StatusOK, nil)
 
+	result, err := handler.PaymentService.CaptureCharge(tenantContext(c), id, input.Amount, currentUser(c))
+	if err != nil {
+		return handler.chargeError(c, err)
+	}
+
+	return handler.chargeResponse(c, result)
+}
+
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Success 200 {object} models.Charge
+// @Router /payment/charges/{id}/void [post]
+func (handler *PaymentHandler) voidCharge(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.PaymentService.VoidCharge(tenantContext(c), id, currentUser(c))
+	if err != nil {
+		return handler.chargeError(c, err)
+	}
+
+	return handler.chargeResponse(c, result)
+}
+
+// chargeResponse returns the charge or not found if it is nil.
+func (handler *PaymentHandler) chargeResponse(c echo.Context, charge *models.Charge) error {
+
+	if charge == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         charge,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// chargeError returns rejections of charges as bad request and declined cards as payment required,
+// gateway failures are returned as bad gateway.
+func (handler *PaymentHandler) chargeError(c echo.Context, err error) error {
+
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, models.InvalidChargeAmountErr), errors.Is(err, models.InvalidChargeStatusErr),
+		errors.Is(err, models.ChargeCurrencyErr):
+		status = http.StatusBadRequest
+	case errors.Is(err, models.ChargeDeclinedErr):
+		status = http.StatusPaymentRequired
+	default:
+		handler.Logger.LogError(err.Error())
+	}
+
+	return c.JSON(status, commons.ApiResponse{
+		ResponseCode: status,
+		Message:      localizeError(c, err),
+	})
 }
 
 // ============================= register routes ================================================== //
 func (handler *PaymentHandler) registerRoutes() {
 	routeGroup := handler.Router.Group("/payment")
 	routeGroup.POST("", handler.create)
-	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.POST("/:id/refund", handler.refund)
+	routeGroup.POST("/charges", handler.charge)
+	routeGroup.GET("/charges/:id", handler.findCharge)
+	routeGroup.POST("/charges/:id/capture", handler.captureCharge)
+	routeGroup.POST("/charges/:id/void", handler.voidCharge)
 }
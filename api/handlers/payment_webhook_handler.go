@@ -0,0 +1,60 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/pkg/payment_gateway"
+)
+
+// PaymentWebhookHandler receives notifications of the payment gateway, it is registered without tenant and
+// authentication middlewares because the gateway sends neither, requests are authenticated by their signature.
+type PaymentWebhookHandler struct {
+	handlerBase
+	PaymentService *domain_services.PaymentService
+}
+
+// Register PaymentWebhookHandler
+// this method registers all routes,routeGroups and passes PaymentWebhookHandler's related dependencies
+func (handler *PaymentWebhookHandler) Register(config *dto.HandlerConfig, service *domain_services.PaymentService) {
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.PaymentService = service
+	handler.registerRoutes()
+}
+
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param Stripe-Signature header string true "Stripe-Signature"
+// @Success 200
+// @Router /payment/webhook [post]
+func (handler *PaymentWebhookHandler) webhook(c echo.Context) error {
+
+	payload, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := handler.PaymentService.HandleWebhook(payload, c.Request().Header.Get("Stripe-Signature")); err != nil {
+		if errors.Is(err, payment_gateway.InvalidSignatureErr) {
+			return c.NoContent(http.StatusBadRequest)
+		}
+		// gateway retries webhooks which are not answered with success.
+		handler.Logger.LogError(err.Error())
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ============================= register routes ================================================== //
+func (handler *PaymentWebhookHandler) registerRoutes() {
+	handler.Router.POST("/payment/webhook", handler.webhook)
+}
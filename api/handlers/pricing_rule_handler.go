@@ -0,0 +1,283 @@
+// Package handlers
+// handles all http requests
+///**/
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
+	"strconv"
+)
+
+// PricingRuleHandler PricingRule endpoint handler
+type PricingRuleHandler struct {
+	handlerBase
+	Service *domain_services.PricingRuleService
+}
+
+// Register PricingRuleHandler
+// this method registers all routes,routeGroups and passes PricingRuleHandler's related dependencies
+func (handler *PricingRuleHandler) Register(config *dto.HandlerConfig, service *domain_services.PricingRuleService) {
+	handler.Service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
+	handler.registerRoutes()
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Param  PricingRule body  models.PricingRule true "PricingRule"
+// @Success 200 {object} models.PricingRule
+// @Router /pricing-rules [post]
+func (handler *PricingRuleHandler) create(c echo.Context) error {
+
+	pricingRule := &models.PricingRule{}
+	user := currentUser(c)
+
+	if err := c.Bind(&pricingRule); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest,
+			commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+			})
+	}
+
+	if ok, err := pricingRule.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	pricingRule.SetAudit(user)
+	result, err := handler.Service.Create(tenantContext(c), pricingRule)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+		Data:         result,
+	})
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  PricingRule body  models.PricingRule true "PricingRule"
+// @Success 200 {object} models.PricingRule
+// @Router /pricing-rules/{id} [put]
+func (handler *PricingRuleHandler) update(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	pricingRule, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if pricingRule == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	if err := c.Bind(&pricingRule); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	if ok, err := pricingRule.Validate(); err != nil && ok == false {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      err.Error(),
+		})
+	}
+
+	pricingRule.SetUpdatedBy(currentUser(c))
+	if result, err := handler.Service.Update(tenantContext(c), pricingRule); err == nil {
+
+		return c.JSON(http.StatusOK, commons.ApiResponse{
+			Data:         result,
+			ResponseCode: http.StatusOK,
+			Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+		})
+	} else {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.PricingRule
+// @Router /pricing-rules/{id} [get]
+func (handler *PricingRuleHandler) find(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	pricingRule, err := handler.Service.Find(tenantContext(c), id)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, commons.ApiResponse{
+			ResponseCode: http.StatusInternalServerError,
+			Message:      translator.Localize(c.Request().Context(), message_keys.InternalServerError),
+		})
+	}
+
+	if pricingRule == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			Data:         nil,
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         pricingRule,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} models.PricingRule
+// @Router /pricing-rules [get]
+func (handler *PricingRuleHandler) findAll(c echo.Context) error {
+
+	paginationInput := c.Get(paginationInput).(*dto.PaginationFilter)
+	list, err := handler.Service.FindAll(tenantContext(c), paginationInput)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.PricingRule
+// @Router /pricing-rules/{id} [delete]
+func (handler *PricingRuleHandler) delete(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	err = handler.Service.Delete(tenantContext(c), id)
+
+	if err != nil {
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Deleted),
+	})
+}
+
+// @Tags PricingRule
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param pricing_rule_id query int false "pricing_rule_id"
+// @Param reservation_id query int false "reservation_id"
+// @Param room_type_id query int false "room_type_id"
+// @Param date_from query string false "date_from"
+// @Param date_to query string false "date_to"
+// @Produce json
+// @Success 200 {array} models.PriceAdjustmentLog
+// @Router /pricing-rules/adjustments [get]
+func (handler *PricingRuleHandler) findAdjustments(c echo.Context) error {
+
+	filter := dto.PriceAdjustmentFilter{}
+	filter.PaginationFilter = *c.Get(paginationInput).(*dto.PaginationFilter)
+
+	if err := c.Bind(&filter); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	list, err := handler.Service.FindAdjustments(tenantContext(c), &filter)
+
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         list,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// ============================= register routes ================================================== //
+func (handler *PricingRuleHandler) registerRoutes() {
+	routeGroup := handler.Router.Group("/pricing-rules")
+	routeGroup.POST("", handler.create)
+	routeGroup.GET("/adjustments", handler.findAdjustments, middlewares2.PaginationMiddleware)
+	routeGroup.PUT("/:id", handler.update)
+	routeGroup.GET("/:id", handler.find)
+	routeGroup.DELETE("/:id", handler.delete)
+	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
+}
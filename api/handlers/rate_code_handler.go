@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
 	"reservation-api/internal/services/domain_services"
 	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/translator"
@@ -64,7 +66,7 @@ func (handler *RateCodeHandler) create(c echo.Context) error {
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
 			ResponseCode: http.StatusBadRequest,
-			Message:      err.Error(),
+			Message:      localizeError(c, err),
 		})
 	}
 
@@ -126,6 +128,13 @@ func (handler *RateCodeHandler) update(c echo.Context) error {
 		})
 	} else {
 
+		if errors.Is(err, repositories.InvalidParentRateCodeErr) || errors.Is(err, models.AddOnNotFoundErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusInternalServerError, nil)
 	}
@@ -265,6 +274,64 @@ func (handler *RateCodeHandler) addDetails(c echo.Context) error {
 	})
 }
 
+// @Tags RateCode
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Param  RateCalendar body  dto.RateCalendarUpdateDto true "RateCalendar"
+// @Success 200 {object} dto.RateCalendarDiffDto
+// @Router /rate-codes/{id}/calendar [post]
+func (handler *RateCodeHandler) updateCalendar(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	input := dto.RateCalendarUpdateDto{}
+	if err := c.Bind(&input); err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), message_keys.BadRequest),
+		})
+	}
+
+	result, err := handler.RateCodeDetailService.UpdateCalendar(tenantContext(c), id, &input, currentUser(c))
+	if err != nil {
+
+		if errors.Is(err, domain_services.InvalidRateCalendarErr) || errors.Is(err, repositories.DerivedRateCodePricesErr) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	message := message_keys.Updated
+	if input.DryRun {
+		message = ""
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message),
+	})
+}
+
 // ============================= register routes ================================================== //
 func (handler *RateCodeHandler) registerRoutes() {
 	routeGroup := handler.Router.Group("/rate-codes")
@@ -274,4 +341,5 @@ func (handler *RateCodeHandler) registerRoutes() {
 	routeGroup.DELETE("/:id", handler.delete)
 	routeGroup.GET("", handler.findAll, middlewares2.PaginationMiddleware)
 	routeGroup.POST("/add-details/:id", handler.addDetails)
+	routeGroup.POST("/:id/calendar", handler.updateCalendar)
 }
@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -11,8 +12,10 @@ import (
 	"reservation-api/internal/dto"
 	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
 	"reservation-api/internal/services/common_services"
 	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal/utils/date_utils"
 	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/translator"
 	"strconv"
@@ -110,6 +113,14 @@ func (handler *ReservationHandler) createRequest(c echo.Context) error {
 				Message: translator.Localize(c.Request().Context(), message_keys.CheckOutDateEmptyError)})
 	}
 
+	// stay restrictions of updates are checked against the booked reservation when it is updated.
+	if request.RateCodeId != 0 && request.RequestType == dto.CreateReservation {
+		if err := handler.Service.CheckStayRestrictions(tenantContext(c), request.RoomId, request.RateCodeId,
+			request.CheckInDate, request.CheckOutDate); err != nil {
+			return handler.stayRestrictionError(c, err)
+		}
+	}
+
 	// create new reservation request for requested room.
 	result, err := handler.Service.CreateReservationRequest(tenantContext(c), &request)
 	if err != nil {
@@ -175,7 +186,7 @@ func (handler *ReservationHandler) create(c echo.Context) error {
 			})
 	}
 
-	hasReservationConflict, err := handler.Service.HasReservationConflict(tenantContext(c), reservation.CheckinDate, reservation.CheckoutDate, reservation.RoomId)
+	hasReservationConflict, err := handler.Service.HasReservationConflict(tenantContext(c), reservation.CheckinDate, reservation.CheckoutDate, reservation.RoomId, 0)
 	if err != nil {
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusBadRequest, nil)
@@ -188,13 +199,28 @@ func (handler *ReservationHandler) create(c echo.Context) error {
 			})
 	}
 	handler.setReservationFields(&reservation, reservationRequest)
+
+	if err := handler.Service.CheckStayRestrictions(tenantContext(c), reservation.RoomId, reservation.RateCodeId,
+		reservation.CheckinDate, reservation.CheckoutDate); err != nil {
+		return handler.stayRestrictionError(c, err)
+	}
+
 	reservation.SetAudit(user)
 	// create new reservation.
 	result, err := handler.Service.Create(tenantContext(c), &reservation)
 	if err != nil {
+
+		if errors.Is(err, models.PromotionNotFoundErr) || errors.Is(err, models.PromotionNotApplicableErr) ||
+			errors.Is(err, repositories.RateNotAvailableErr) || models.IsOccupancyErr(err) || models.IsAddOnErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusConflict, commons.ApiResponse{
-			Message: err.Error(),
+			Message: localizeError(c, err),
 		})
 	}
 
@@ -277,7 +303,8 @@ func (handler *ReservationHandler) update(c echo.Context) error {
 			})
 	}
 
-	hasReservationConflict, err := handler.Service.HasReservationConflict(tenantContext(c), reservation.CheckinDate, reservation.CheckoutDate, reservation.RoomId)
+	handler.setReservationFields(&reservation, reservationRequest)
+	hasReservationConflict, err := handler.Service.HasReservationConflict(tenantContext(c), reservation.CheckinDate, reservation.CheckoutDate, reservation.RoomId, id)
 	if err != nil {
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusBadRequest, nil)
@@ -289,14 +316,28 @@ func (handler *ReservationHandler) update(c echo.Context) error {
 				Message: translator.Localize(c.Request().Context(), message_keys.ReservationConflictError),
 			})
 	}
+
+	if err := handler.Service.CheckUpdateStayRestrictions(tenantContext(c), reservationModel, &reservation); err != nil {
+		return handler.stayRestrictionError(c, err)
+	}
+
 	reservation.SetUpdatedBy(user)
-	handler.setReservationFields(&reservation, reservationRequest)
 	// create new reservation.
 	result, err := handler.Service.Update(tenantContext(c), id, &reservation)
 	if err != nil {
+
+		if errors.Is(err, models.PromotionNotFoundErr) || errors.Is(err, models.PromotionNotApplicableErr) ||
+			errors.Is(err, repositories.RateNotAvailableErr) || errors.Is(err, repositories.ReservationNotEditableErr) ||
+			models.IsOccupancyErr(err) || models.IsAddOnErr(err) {
+			return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+				ResponseCode: http.StatusBadRequest,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusConflict, commons.ApiResponse{
-			Message: err.Error(),
+			Message: localizeError(c, err),
 		})
 	}
 
@@ -323,12 +364,52 @@ func (handler *ReservationHandler) cancelRequest(c echo.Context) error {
 	return c.JSON(http.StatusOK, nil)
 }
 
+// Cancels a confirmed reservation, the penalty of rate code's cancellation policy is recorded as a DEBIT payment.
+
+// @Tags Reservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {object} dto.ReservationCancellationDto
+// @Router /reservation/{id}/cancel [post]
+func (handler *ReservationHandler) cancel(c echo.Context) error {
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	result, err := handler.Service.Cancel(tenantContext(c), id, currentUser(c))
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusConflict, commons.ApiResponse{
+			ResponseCode: http.StatusConflict,
+			Message:      localizeError(c, err),
+		})
+	}
+
+	if result == nil {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Updated),
+	})
+}
+
 // @Tags Reservation
 // @Accept json
 // @Param X-Tenant-ID header int true "X-Tenant-ID"
 // @Produce json
 // @Param  GetRatePriceDto body  dto.GetRatePriceDto true "GetRatePriceDto"
-// @Success 200 {object} dto.RateCodePricesDto
+// @Success 200 {array} dto.RateCodeQuoteDto
 // @Router /reservations/recommend-rate-codes/{id} [post]
 func (handler *ReservationHandler) recommendRateCodes(c echo.Context) error {
 
@@ -350,6 +431,12 @@ func (handler *ReservationHandler) recommendRateCodes(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, nil)
 	}
 
+	for _, quote := range result {
+		if quote.Restriction != "" {
+			quote.Restriction = translator.Localize(c.Request().Context(), quote.Restriction)
+		}
+	}
+
 	return c.JSON(http.StatusOK, commons.ApiResponse{
 		Data: result,
 	})
@@ -401,8 +488,24 @@ func (handler *ReservationHandler) changeStatus(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, nil)
 	}
 
-	reservation, err := handler.Service.Find(tenantContext(c), id)
+	statusVal, err := strconv.ParseUint(c.QueryParam("status"), 10, 64)
 	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+
+	reservation, err := handler.Service.ChangeStatus(tenantContext(c), id, models.ReservationCheckStatus(statusVal), currentUser(c))
+	if err != nil {
+
+		if errors.Is(err, models.InvalidStatusTransitionErr) || errors.Is(err, models.EarlyCheckInErr) ||
+			errors.Is(err, domain_services.UnpaidBalanceErr) {
+
+			return c.JSON(http.StatusConflict, commons.ApiResponse{
+				ResponseCode: http.StatusConflict,
+				Message:      translator.Localize(c.Request().Context(), err.Error()),
+			})
+		}
+
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusInternalServerError, nil)
 	}
@@ -411,31 +514,136 @@ func (handler *ReservationHandler) changeStatus(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, nil)
 	}
 
-	statusVal, err := strconv.ParseUint(c.QueryParam("status"), 10, 64)
-	status := models.ReservationCheckStatus(statusVal)
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data: reservation,
+	})
+}
+
+// @Tags Reservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param Id path int true "Id"
+// @Produce json
+// @Success 200 {array} models.ReservationStatusHistory
+// @Router /reservation/{id}/history [get]
+func (handler *ReservationHandler) history(c echo.Context) error {
 
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		handler.Logger.LogError(err.Error())
 		return c.JSON(http.StatusBadRequest, nil)
 	}
 
-	if status == models.CheckIn || status == models.Checkout {
-		reservation.CheckStatus = status
-		_, err := handler.Service.ChangeStatus(tenantContext(c), id, status)
+	reservation, err := handler.Service.Find(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	if reservation == nil {
+		return c.JSON(http.StatusNotFound, nil)
+	}
+
+	result, err := handler.Service.FindStatusHistory(tenantContext(c), id)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags Reservation
+// @Description lists active reservations of a room whose stays overlap, they are booked before overlapping stays
+// @Description are prevented and must be moved or cancelled
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Produce json
+// @Success 200 {array} dto.StayOverlapDto
+// @Router /reservation/overlaps [get]
+func (handler *ReservationHandler) stayOverlaps(c echo.Context) error {
+
+	result, err := handler.Service.FindStayOverlaps(tenantContext(c))
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
+	}
+
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         result,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// @Tags Reservation
+// @Accept json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param from query string true "from date (2006-01-02)"
+// @Param to query string true "to date (2006-01-02)"
+// @Param room_type_id query int false "room_type_id"
+// @Produce json
+// @Success 200 {object} dto.AvailabilityDto
+// @Router /reservation/availability [get]
+func (handler *ReservationHandler) availability(c echo.Context) error {
+
+	invalidRangeErr := commons.ApiResponse{
+		ResponseCode: http.StatusBadRequest,
+		Message:      translator.Localize(c.Request().Context(), message_keys.InvalidAvailabilityRange),
+	}
+
+	from, err := time.Parse(date_utils.DateLayout, c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, invalidRangeErr)
+	}
+
+	to, err := time.Parse(date_utils.DateLayout, c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, invalidRangeErr)
+	}
+
+	if !to.After(from) || to.Sub(from).Hours()/24 > float64(global_variables.MaxAvailabilityRangeDays) {
+		return c.JSON(http.StatusBadRequest, invalidRangeErr)
+	}
+
+	filter := dto.AvailabilityFilter{From: &from, To: &to}
 
+	if roomTypeIdStr := strings.TrimSpace(c.QueryParam("room_type_id")); roomTypeIdStr != "" {
+		roomTypeId, err := strconv.ParseUint(roomTypeIdStr, 10, 64)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, nil)
+			return c.JSON(http.StatusBadRequest, nil)
 		}
+		filter.RoomTypeId = roomTypeId
+	}
 
-	} else {
-		return c.JSON(http.StatusBadRequest, nil)
+	result, err := handler.Service.GetAvailability(tenantContext(c), &filter)
+	if err != nil {
+		handler.Logger.LogError(err.Error())
+		return c.JSON(http.StatusInternalServerError, nil)
 	}
 
 	return c.JSON(http.StatusOK, commons.ApiResponse{
-		Data: reservation,
+		Data:         result,
+		ResponseCode: http.StatusOK,
 	})
 }
 
+// stayRestrictionError returns localized reason of stay restriction rejections
+// and internal server error for other errors.
+func (handler *ReservationHandler) stayRestrictionError(c echo.Context, err error) error {
+
+	if repositories.IsStayRestrictionErr(err) {
+		return c.JSON(http.StatusBadRequest, commons.ApiResponse{
+			ResponseCode: http.StatusBadRequest,
+			Message:      translator.Localize(c.Request().Context(), err.Error()),
+		})
+	}
+
+	handler.Logger.LogError(err.Error())
+	return c.JSON(http.StatusInternalServerError, nil)
+}
+
 //== **********************************************************************************/
 func (handler *ReservationHandler) setReservationFields(reservation *models.Reservation, reservationRequest *models.ReservationRequest) {
 	reservation.CheckinDate = reservationRequest.CheckInDate
@@ -494,7 +702,11 @@ func (handler *ReservationHandler) registerRoutes(router *echo.Group) {
 	routerGroup.POST("/room-request", handler.createRequest)
 	routerGroup.POST("", handler.create)
 	routerGroup.DELETE("/cancel", handler.cancelRequest)
+	routerGroup.POST("/:id/cancel", handler.cancel)
+	routerGroup.GET("/:id/history", handler.history)
 	routerGroup.POST("/recommend-rate-codes", handler.recommendRateCodes)
+	routerGroup.GET("/availability", handler.availability)
+	routerGroup.GET("/overlaps", handler.stayOverlaps)
 	routerGroup.GET("/:id", handler.find)
 	routerGroup.GET("", handler.findAll)
 	routerGroup.PUT("/:id", handler.update)
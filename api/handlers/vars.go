@@ -10,4 +10,5 @@ var (
 	EXCEL           = "excel"
 	EXCEL_OUTPUT    = "xlsx"
 	PDF             = "pdf"
+	ZIP             = "zip"
 )
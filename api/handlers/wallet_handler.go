@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
 	"github.com/shopspring/decimal"
 	"net/http"
+	middlewares2 "reservation-api/api/middlewares"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
 	"reservation-api/internal/services/domain_services"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/translator"
 	"strconv"
 )
 
@@ -14,158 +20,255 @@ type WalletHandler struct {
 	handlerBase
 }
 
-func (handler *WalletHandler) Register(service *domain_services.WalletService) {
+func (handler *WalletHandler) Register(config *dto.HandlerConfig, service *domain_services.WalletService) {
 	handler.service = service
+	handler.Router = config.Router
+	handler.Logger = config.Logger
 	handler.registerRoutes()
 }
 
 type createWalletRequest struct {
-	UserId  uint64          `json:"user_id"`
-	Balance decimal.Decimal `json:"balance"`
-}
-
-type createWalletResponse struct {
-	UserId  uint64          `json:"user_id"`
-	Balance decimal.Decimal `json:"balance"`
+	UserId  uint64            `json:"user_id"`
+	Type    models.WalletType `json:"type"`
+	Name    string            `json:"name"`
+	GuestId *uint64           `json:"guest_id"`
+	Balance decimal.Decimal   `json:"balance"`
 }
 
 // @Summary Create a new wallet
-// @Description Create a new wallet with the specified owner name and balance
+// @Description Create a new wallet with the specified owner and initial balance, the initial balance is posted as a deposit
 // @Tags Wallet
 // @Accept json
 // @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
 // @Param request body createWalletRequest true "Wallet creation request"
-// @Success 201 {object} createWalletResponse
+// @Success 201 {object} models.Wallet
 // @Router /wallets [post]
 func (handler *WalletHandler) CreateWallet(c echo.Context) error {
 	var req createWalletRequest
 	if err := c.Bind(&req); err != nil {
-		return err
+		return c.JSON(http.StatusBadRequest, nil)
 	}
 	wallet := &models.Wallet{
 		UserId:  req.UserId,
+		Type:    req.Type,
+		Name:    req.Name,
+		GuestId: req.GuestId,
 		Balance: req.Balance,
 	}
-	if _, err := handler.service.Create(c.Request().Context(), wallet); err != nil {
-		return err
+	wallet.SetAudit(currentUser(c))
+	if _, err := handler.service.Create(tenantContext(c), wallet); err != nil {
+		return handler.walletError(c, err)
 	}
-	res := createWalletResponse{
-		UserId:  wallet.UserId,
-		Balance: wallet.Balance,
-	}
-	return c.JSON(http.StatusCreated, res)
-}
-
-type getWalletResponse struct {
-	ID      uint64          `json:"id"`
-	UserId  uint64          `json:"user_id"`
-	Balance decimal.Decimal `json:"balance"`
+	return c.JSON(http.StatusCreated, commons.ApiResponse{
+		Data:         wallet,
+		ResponseCode: http.StatusCreated,
+		Message:      translator.Localize(c.Request().Context(), message_keys.Created),
+	})
 }
 
 // @Summary Get a wallet by ID
 // @Description Get a wallet with the specified ID
 // @Tags Wallet
 // @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
 // @Param id path uint true "Wallet ID"
-// @Success 200 {object} getWalletResponse
+// @Success 200 {object} models.Wallet
 // @Router /wallets/{id} [get]
 func (handler *WalletHandler) GetWallet(c echo.Context) error {
 	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.String(http.StatusBadRequest, "Invalid wallet ID")
 	}
-	wallet, err := handler.service.GetWalletByID(c.Request().Context(), walletID)
+	wallet, err := handler.service.GetWalletByID(tenantContext(c), walletID)
 	if err != nil {
-		return err
-	}
-	res := getWalletResponse{
-		ID:      wallet.Id,
-		UserId:  wallet.UserId,
-		Balance: wallet.Balance,
+		return handler.walletError(c, err)
 	}
-	return c.JSON(http.StatusOK, res)
+	return handler.walletResponse(c, wallet, wallet == nil)
 }
 
-type depositRequest struct {
-	Amount decimal.Decimal `json:"amount"`
+// @Summary Get transactions of a wallet
+// @Description Get ledger of the wallet with the specified ID, the latest transaction first
+// @Tags Wallet
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param id path uint true "Wallet ID"
+// @Param type query int false "Type"
+// @Param date_from query string false "DateFrom"
+// @Param date_to query string false "DateTo"
+// @Success 200 {array} models.WalletTransaction
+// @Router /wallets/{id}/transactions [get]
+func (handler *WalletHandler) GetTransactions(c echo.Context) error {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid wallet ID")
+	}
+	filter := dto.WalletTransactionFilter{}
+	filter.PaginationFilter = *c.Get(paginationInput).(*dto.PaginationFilter)
+	if err := c.Bind(&filter); err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+	list, err := handler.service.FindTransactions(tenantContext(c), walletID, &filter)
+	if err != nil {
+		return handler.walletError(c, err)
+	}
+	return handler.walletResponse(c, list, false)
 }
 
-type depositResponse struct {
-	ID      uint64          `json:"id"`
-	UserId  uint64          `json:"user_id"`
-	Balance decimal.Decimal `json:"balance"`
+type walletTransactionRequest struct {
+	Amount         decimal.Decimal `json:"amount"`
+	IdempotencyKey *string         `json:"idempotency_key"`
 }
 
 // @Summary Deposit funds into a wallet
-// @Description Deposit the specified amount into the wallet with the specified ID
+// @Description Deposit the specified amount into the wallet with the specified ID, retries with the same idempotency key are posted once
 // @Tags Wallet
 // @Accept json
 // @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
 // @Param id path uint true "Wallet ID"
-// @Param request body depositRequest true "Deposit request"
-// @Success 200 {object} depositResponse
+// @Param request body walletTransactionRequest true "Deposit request"
+// @Success 200 {object} models.WalletTransaction
 // @Router /wallets/{id}/deposit [post]
 func (handler *WalletHandler) Deposit(c echo.Context) error {
 	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.String(http.StatusBadRequest, "Invalid wallet ID")
 	}
-	var req depositRequest
+	var req walletTransactionRequest
 	if err := c.Bind(&req); err != nil {
-		return err
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+	transaction, err := handler.service.Deposit(tenantContext(c), walletID, req.Amount, req.IdempotencyKey, currentUser(c))
+	if err != nil {
+		return handler.walletError(c, err)
 	}
-	amount := req.Amount
-	wallet, err := handler.service.Deposit(c.Request().Context(), walletID, amount)
+	return handler.walletResponse(c, transaction, transaction == nil)
+}
+
+// @Summary Withdraw funds from a wallet
+// @Description Withdraw the specified amount from the wallet with the specified ID, retries with the same idempotency key are posted once
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param id path uint true "Wallet ID"
+// @Param request body walletTransactionRequest true "Withdrawal request"
+// @Success 200 {object} models.WalletTransaction
+// @Router /wallets/{id}/withdraw [post]
+func (handler *WalletHandler) Withdraw(c echo.Context) error {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		return err
+		return c.String(http.StatusBadRequest, "Invalid wallet ID")
 	}
-	res := depositResponse{
-		ID:      wallet.Id,
-		UserId:  wallet.UserId,
-		Balance: wallet.Balance,
+	var req walletTransactionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
 	}
-	return c.JSON(http.StatusOK, res)
+	transaction, err := handler.service.Withdraw(tenantContext(c), walletID, req.Amount, req.IdempotencyKey, currentUser(c))
+	if err != nil {
+		return handler.walletError(c, err)
+	}
+	return handler.walletResponse(c, transaction, transaction == nil)
 }
 
-type withdrawRequest struct {
-	Amount decimal.Decimal `json:"amount"`
+type transferRequest struct {
+	ToWalletId     uint64          `json:"to_wallet_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	IdempotencyKey *string         `json:"idempotency_key"`
 }
 
-type withdrawResponse struct {
-	ID      uint64          `json:"id"`
-	UserId  uint64          `json:"user_id"`
-	Balance decimal.Decimal `json:"balance"`
+// @Summary Transfer funds to another wallet
+// @Description Transfer the specified amount from the wallet with the specified ID to another wallet
+// @Tags Wallet
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
+// @Param id path uint true "Wallet ID"
+// @Param request body transferRequest true "Transfer request"
+// @Success 200 {array} models.WalletTransaction
+// @Router /wallets/{id}/transfer [post]
+func (handler *WalletHandler) Transfer(c echo.Context) error {
+	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid wallet ID")
+	}
+	var req transferRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, nil)
+	}
+	transactions, err := handler.service.Transfer(tenantContext(c), walletID, req.ToWalletId, req.Amount,
+		req.IdempotencyKey, currentUser(c))
+	if err != nil {
+		return handler.walletError(c, err)
+	}
+	return handler.walletResponse(c, transactions, transactions == nil)
 }
 
-// @Summary Withdraw funds from a wallet
-// @Description Withdraw the specified amount from the wallet with the specified ID
+type payFolioRequest struct {
+	FolioId        uint64          `json:"folio_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	IdempotencyKey *string         `json:"idempotency_key"`
+}
+
+// @Summary Pay a reservation folio from a wallet
+// @Description Pay the specified amount of a folio from the wallet, the whole folio balance is paid and the folio is settled if amount is zero
 // @Tags Wallet
 // @Accept json
 // @Produce json
+// @Param X-Tenant-ID header int true "X-Tenant-ID"
 // @Param id path uint true "Wallet ID"
-// @Param request body withdrawRequest true "Withdrawal request"
-// @Success 200 {object} withdrawResponse
-// @Router /wallets/{id}/withdraw [post]
-func (handler *WalletHandler) Withdraw(c echo.Context) error {
+// @Param request body payFolioRequest true "Folio payment request"
+// @Success 200 {object} models.WalletTransaction
+// @Router /wallets/{id}/pay-folio [post]
+func (handler *WalletHandler) PayFolio(c echo.Context) error {
 	walletID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		return c.String(http.StatusBadRequest, "Invalid wallet ID")
 	}
-	var req withdrawRequest
+	var req payFolioRequest
 	if err := c.Bind(&req); err != nil {
-		return err
+		return c.JSON(http.StatusBadRequest, nil)
 	}
-	amount := req.Amount
-	wallet, err := handler.service.Withdraw(c.Request().Context(), walletID, amount)
+	transaction, err := handler.service.PayFolio(tenantContext(c), walletID, req.FolioId, req.Amount,
+		req.IdempotencyKey, currentUser(c))
 	if err != nil {
-		return err
+		return handler.walletError(c, err)
+	}
+	return handler.walletResponse(c, transaction, transaction == nil)
+}
+
+// walletResponse returns data of a wallet request or not found if the wallet or the folio does not exist.
+func (handler *WalletHandler) walletResponse(c echo.Context, data interface{}, notFound bool) error {
+	if notFound {
+		return c.JSON(http.StatusNotFound, commons.ApiResponse{
+			ResponseCode: http.StatusNotFound,
+			Message:      translator.Localize(c.Request().Context(), message_keys.NotFound),
+		})
 	}
-	res := withdrawResponse{
-		ID:      wallet.Id,
-		UserId:  wallet.UserId,
-		Balance: wallet.Balance,
+	return c.JSON(http.StatusOK, commons.ApiResponse{
+		Data:         data,
+		ResponseCode: http.StatusOK,
+	})
+}
+
+// walletError returns rejected wallet transactions as bad request and reused idempotency keys as conflict.
+func (handler *WalletHandler) walletError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.WalletIdempotencyKeyErr):
+		status = http.StatusConflict
+	case errors.Is(err, models.InvalidWalletAmountErr), errors.Is(err, models.InsufficientWalletBalanceErr),
+		errors.Is(err, models.SameWalletTransferErr), models.IsFolioErr(err):
+		status = http.StatusBadRequest
+	default:
+		handler.Logger.LogError(err.Error())
+		return c.JSON(status, nil)
 	}
-	return c.JSON(http.StatusOK, res)
+	return c.JSON(status, commons.ApiResponse{
+		ResponseCode: status,
+		Message:      localizeError(c, err),
+	})
 }
 
 func (handler *WalletHandler) registerRoutes() {
@@ -173,6 +276,9 @@ func (handler *WalletHandler) registerRoutes() {
 	routeGroup := handler.Router.Group("/wallets")
 	routeGroup.POST("", handler.CreateWallet)
 	routeGroup.GET("/:id", handler.GetWallet)
+	routeGroup.GET("/:id/transactions", handler.GetTransactions, middlewares2.PaginationMiddleware)
 	routeGroup.POST("/:id/deposit", handler.Deposit)
 	routeGroup.POST("/:id/withdraw", handler.Withdraw)
+	routeGroup.POST("/:id/transfer", handler.Transfer)
+	routeGroup.POST("/:id/pay-folio", handler.PayFolio)
 }
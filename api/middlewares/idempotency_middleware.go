@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"reservation-api/internal/global_variables"
+	"reservation-api/internal/services/common_services"
+	"reservation-api/pkg/applogger"
+	"strings"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses which are replayed from a previous request with the same key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyLockExpiration releases keys of requests which never complete, e.g. when the instance is killed.
+	idempotencyLockExpiration = 5 * time.Minute
+	idempotencyExpiration     = 24 * time.Hour
+)
+
+// idempotencyRecord is the stored state of an idempotency key, Status is zero while the first request is in progress.
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyRecorder copies the response body which is written to the client.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware makes mutating requests with an Idempotency-Key header safe to retry. Keys are scoped to the
+// tenant and the user, the first request of a key stores fingerprint of the request and its response in the cache and
+// retries with the same key get the stored response instead of running the request again. A key which is reused with
+// another method, path or body is rejected with 422 and a retry while the first request is running with 409.
+// Requests which fail with an error or a server error are not stored, so they can be retried.
+func IdempotencyMiddleware(cache common_services.CacheManager, logger applogger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			request := c.Request()
+			key := strings.TrimSpace(request.Header.Get(IdempotencyKeyHeader))
+
+			if key == "" || request.Method == http.MethodGet || request.Method == http.MethodHead ||
+				request.Method == http.MethodOptions {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key header is too long")
+			}
+
+			body, err := io.ReadAll(request.Body)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+			request.Body = io.NopCloser(bytes.NewReader(body))
+
+			cacheKey := fmt.Sprintf("idempotency:%s:%s:%s", c.Get(global_variables.TenantIDKey),
+				c.Get(global_variables.ClaimsKey), key)
+			fingerprint := requestFingerprint(request, body)
+
+			lock, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+			acquired, err := cache.SetNX(cacheKey, lock, idempotencyLockExpiration)
+			if err != nil {
+				// requests are not blocked when the cache is down, they run without idempotency.
+				logger.LogError(err.Error())
+				return next(c)
+			}
+
+			if !acquired {
+				return replayIdempotentResponse(c, cache, cacheKey, fingerprint)
+			}
+
+			completed := false
+			defer func() {
+				// the key is released if the request panics or is not stored, so it can be retried.
+				if !completed {
+					if _, err := cache.Del(cacheKey); err != nil {
+						logger.LogError(err.Error())
+					}
+				}
+			}()
+
+			recorder := &idempotencyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				return err
+			}
+
+			response := c.Response()
+			if response.Status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			record, _ := json.Marshal(idempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      response.Status,
+				ContentType: response.Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			})
+
+			expiration := idempotencyExpiration
+			if err := cache.Set(cacheKey, record, &expiration); err != nil {
+				logger.LogError(err.Error())
+				return nil
+			}
+
+			completed = true
+			return nil
+		}
+	}
+}
+
+// replayIdempotentResponse returns the stored response of the key if the request matches its fingerprint.
+func replayIdempotentResponse(c echo.Context, cache common_services.CacheManager, cacheKey, fingerprint string) error {
+
+	data, err := cache.Get(cacheKey)
+	if err != nil {
+		// the key expired after it is checked, the client can retry it.
+		return echo.NewHTTPError(http.StatusConflict, "request with this Idempotency-Key is in progress")
+	}
+
+	record := idempotencyRecord{}
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key is already used for another request")
+	}
+
+	if record.Status == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "request with this Idempotency-Key is in progress")
+	}
+
+	c.Response().Header().Set(IdempotentReplayedHeader, "true")
+	return c.Blob(record.Status, record.ContentType, record.Body)
+}
+
+// requestFingerprint returns hash of method, path and body of the request.
+func requestFingerprint(request *http.Request, body []byte) string {
+
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
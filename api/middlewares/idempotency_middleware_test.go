@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"reservation-api/internal/global_variables"
+	"reservation-api/pkg/applogger"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryCache is an in memory CacheManager.
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *memoryCache) Set(key string, value interface{}, expiration *time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = string(value.([]byte))
+	return nil
+}
+
+func (m *memoryCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+	m.values[key] = string(value.([]byte))
+	return true, nil
+}
+
+func (m *memoryCache) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return value, nil
+}
+
+func (m *memoryCache) Del(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return 1, nil
+}
+
+func (m *memoryCache) Update(key string, value interface{}) error {
+	return m.Set(key, value, nil)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+
+	e := echo.New()
+	cache := &memoryCache{values: make(map[string]string)}
+	calls := 0
+	status := http.StatusCreated
+
+	handler := IdempotencyMiddleware(cache, applogger.New(nil))(func(c echo.Context) error {
+		calls++
+		return c.JSON(status, map[string]int{"call": calls})
+	})
+
+	send := func(key, user, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set(IdempotencyKeyHeader, key)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.Set(global_variables.TenantIDKey, "1")
+		c.Set(global_variables.ClaimsKey, user)
+		if err := handler(c); err != nil {
+			e.HTTPErrorHandler(err, c)
+		}
+		return recorder
+	}
+
+	t.Run("replays_response_of_retry", func(t *testing.T) {
+
+		first := send("key-1", "admin", `{"amount":10}`)
+		retry := send("key-1", "admin", `{"amount":10}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("rejects_key_with_another_payload", func(t *testing.T) {
+
+		response := send("key-1", "admin", `{"amount":20}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("scopes_keys_to_user", func(t *testing.T) {
+
+		response := send("key-1", "other", `{"amount":10}`)
+
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("does_not_store_server_errors", func(t *testing.T) {
+
+		status = http.StatusInternalServerError
+		send("key-2", "admin", `{}`)
+		status = http.StatusCreated
+		response := send("key-2", "admin", `{}`)
+
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("rejects_retry_in_progress", func(t *testing.T) {
+
+		cache.SetNX("idempotency:1:admin:key-3", []byte(`{"fingerprint":"`+requestFingerprint(
+			httptest.NewRequest(http.MethodPost, "/payment", nil), []byte(`{}`))+`"}`), time.Minute)
+
+		response := send("key-3", "admin", `{}`)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("ignores_requests_without_key", func(t *testing.T) {
+
+		send("", "admin", `{}`)
+		send("", "admin", `{}`)
+
+		assert.Equal(t, 6, calls)
+	})
+}
@@ -55,6 +55,17 @@ type Config struct {
 		Password string `yaml:"password"`
 		CacheDB  int    `yaml:"cache_db"`
 	}
+	// PaymentGateway is the card payment provider Config
+	PaymentGateway struct {
+		BaseURL       string `yaml:"base_url"`
+		SecretKey     string `yaml:"secret_key"`
+		WebhookSecret string `yaml:"webhook_secret"`
+		Currency      string `yaml:"currency"`
+	} `yaml:"payment_gateway"`
+	// FieldEncryption encrypts sensitive columns with data keys of tenants in vault
+	FieldEncryption struct {
+		Enabled bool `yaml:"enabled"`
+	} `yaml:"field_encryption"`
 }
 
 // New reads Config from yml file copies to Config struct and returns Config struct
@@ -0,0 +1,61 @@
+package dto
+
+import "time"
+
+type RoomNightStatus string
+
+const (
+	RoomNightFree    RoomNightStatus = "free"
+	RoomNightHeld    RoomNightStatus = "held"
+	RoomNightBooked  RoomNightStatus = "booked"
+	RoomNightBlocked RoomNightStatus = "blocked"
+)
+
+// AvailabilityFilter contains availability calendar query params.
+// Nights are counted from From (inclusive) to To (exclusive).
+type AvailabilityFilter struct {
+	From       *time.Time `json:"from"`
+	To         *time.Time `json:"to"`
+	RoomTypeId uint64     `json:"room_type_id"`
+}
+
+// RoomNightDto is status of a room in a single night.
+type RoomNightDto struct {
+	Date          time.Time       `json:"date"`
+	Status        RoomNightStatus `json:"status"`
+	ReservationId uint64          `json:"reservation_id,omitempty"`
+}
+
+// RoomAvailabilityDto is a row of tape chart that contains per night status of a room.
+type RoomAvailabilityDto struct {
+	RoomId     uint64          `json:"room_id"`
+	RoomName   string          `json:"room_name"`
+	RoomTypeId uint64          `json:"room_type_id"`
+	Nights     []*RoomNightDto `json:"nights"`
+}
+
+// RoomTypeNightDto contains aggregated room counts of a room type in a single night.
+type RoomTypeNightDto struct {
+	Date     time.Time `json:"date"`
+	Total    uint64    `json:"total"`
+	Free     uint64    `json:"free"`
+	Held     uint64    `json:"held"`
+	Booked   uint64    `json:"booked"`
+	Blocked  uint64    `json:"blocked"`
+	Sellable uint64    `json:"sellable"`
+}
+
+// RoomTypeAvailabilityDto contains per night sellable inventory of a room type.
+type RoomTypeAvailabilityDto struct {
+	RoomTypeId   uint64              `json:"room_type_id"`
+	RoomTypeName string              `json:"room_type_name"`
+	Nights       []*RoomTypeNightDto `json:"nights"`
+}
+
+// AvailabilityDto is availability calendar output.
+type AvailabilityDto struct {
+	From      time.Time                  `json:"from"`
+	To        time.Time                  `json:"to"`
+	Rooms     []*RoomAvailabilityDto     `json:"rooms"`
+	RoomTypes []*RoomTypeAvailabilityDto `json:"room_types"`
+}
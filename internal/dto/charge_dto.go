@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"github.com/asaskevich/govalidator"
+	"reservation-api/internal/models"
+)
+
+// ChargeDto charges a card for balance of a reservation, PaymentMethod is the card token which is created by the
+// client with the gateway. Whole balance is charged if Amount is zero and the charge is only authorized unless Capture is set.
+// Amount is in currency of the folio, the card is charged in currency of the reservation.
+type ChargeDto struct {
+	ReservationId uint64  `json:"reservation_id" valid:"required"`
+	PayerId       uint64  `json:"payer_id"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method" valid:"required"`
+	ReceiptEmail  string  `json:"receipt_email"`
+	Capture       bool    `json:"capture"`
+}
+
+func (d *ChargeDto) Validate() (bool, error) {
+	return govalidator.ValidateStruct(d)
+}
+
+// ChargeCaptureDto captures an authorized charge, whole authorized amount is captured if Amount is zero.
+type ChargeCaptureDto struct {
+	Amount float64 `json:"amount"`
+}
+
+// PaymentRefundDto refunds a payment for a reason, the remaining amount of the payment is refunded if Amount is zero.
+type PaymentRefundDto struct {
+	Amount float64             `json:"amount"`
+	Reason models.RefundReason `json:"reason" valid:"range(0|4)"`
+}
+
+func (d *PaymentRefundDto) Validate() (bool, error) {
+	return govalidator.ValidateStruct(d)
+}
@@ -4,4 +4,5 @@ type CurrencyDto struct {
 	BaseDto
 	Name   string `json:"name" valid:"required"`
 	Symbol string `json:"symbol" valid:"required"`
+	Code   string `json:"code"`
 }
@@ -0,0 +1,6 @@
+package dto
+
+// ExchangeRateImportResultDto is result of an exchange rates import.
+type ExchangeRateImportResultDto struct {
+	Imported int `json:"imported"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+// FolioTransferDto moves a folio entry to another folio of the same reservation.
+type FolioTransferDto struct {
+	FolioId uint64 `json:"folio_id"`
+}
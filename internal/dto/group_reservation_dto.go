@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"github.com/asaskevich/govalidator"
+	"time"
+)
+
+// GroupReservationCreateDto creates a group master reservation and one child reservation per room.
+// Child rooms stay tentative until guests are assigned by rooming list and unpicked rooms
+// are released at ReleaseDate.
+type GroupReservationCreateDto struct {
+	HotelId       uint64     `json:"hotel_id"`
+	GroupName     string     `json:"group_name" valid:"required"`
+	SupervisorId  uint64     `json:"supervisor_id" valid:"required"`
+	RateCodeId    uint64     `json:"rate_code_id" valid:"required"`
+	CheckinDate   *time.Time `json:"checkin_date" valid:"required"`
+	CheckoutDate  *time.Time `json:"checkout_date" valid:"required"`
+	ReleaseDate   *time.Time `json:"release_date" valid:"required"`
+	GuestsPerRoom uint64     `json:"guests_per_room"`
+	RoomIds       []uint64   `json:"room_ids" valid:"required"`
+}
+
+func (d *GroupReservationCreateDto) Validate() (bool, error) {
+	return govalidator.ValidateStruct(d)
+}
+
+// RoomingListItemDto assigns guests to a child room reservation of a group.
+type RoomingListItemDto struct {
+	ReservationId uint64   `json:"reservation_id"`
+	SupervisorId  uint64   `json:"supervisor_id"`
+	GuestIds      []uint64 `json:"guest_ids"`
+}
+
+// GroupFolioLineDto is billing summary of a reservation of the group, amounts are net balances of folio entries
+// of the reservation by entry type.
+type GroupFolioLineDto struct {
+	ReservationId uint64  `json:"reservation_id"`
+	RoomId        uint64  `json:"room_id"`
+	IsGroupMaster bool    `json:"is_group_master"`
+	Price         float64 `json:"price"`
+	TaxAmount     float64 `json:"tax_amount"`
+	AddOnAmount   float64 `json:"add_on_amount"`
+	Debits        float64 `json:"debits"`
+	Credits       float64 `json:"credits"`
+	Due           float64 `json:"due"`
+}
+
+// GroupFolioDto is group level billing, charges and payments of master and all child reservations.
+type GroupFolioDto struct {
+	MasterId     uint64               `json:"master_id"`
+	GroupName    string               `json:"group_name"`
+	Lines        []*GroupFolioLineDto `json:"lines"`
+	TotalPrice   float64              `json:"total_price"`
+	TotalTaxes   float64              `json:"total_taxes"`
+	TotalAddOns  float64              `json:"total_add_ons"`
+	TotalDebits  float64              `json:"total_debits"`
+	TotalCredits float64              `json:"total_credits"`
+	TotalDue     float64              `json:"total_due"`
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"github.com/asaskevich/govalidator"
+	"reservation-api/internal/models"
 	"time"
 )
 
@@ -30,3 +32,36 @@ type GuestDto struct {
 	DateOfBirth        *time.Time  `json:"date_of_birth"`
 	Address            string      `json:"address" valid:"required"`
 }
+
+// GuestDuplicateFilter filters probable duplicate guests, MinScore defaults to DefaultDuplicateScore and
+// GuestId limits duplicates to one guest.
+type GuestDuplicateFilter struct {
+	MinScore float64 `json:"min_score" query:"min_score"`
+	GuestId  uint64  `json:"guest_id" query:"guest_id"`
+}
+
+// DefaultDuplicateScore is the minimum score of duplicates when it is not set, e.g. one matching document or
+// an email and a phone.
+const DefaultDuplicateScore = 50
+
+// GuestMergeDto merges MergedId into SurvivorId, the merged guest is deleted.
+type GuestMergeDto struct {
+	SurvivorId uint64 `json:"survivor_id" valid:"required"`
+	MergedId   uint64 `json:"merged_id" valid:"required"`
+}
+
+func (d *GuestMergeDto) Validate() (bool, error) {
+	return govalidator.ValidateStruct(d)
+}
+
+// GuestExportDto is everything which is held about a guest, it is exported as guest.json of the guest archive
+// and documents of Invoices are attached to the archive.
+type GuestExportDto struct {
+	ExportedAt   time.Time             `json:"exported_at"`
+	Guest        *models.Guest         `json:"guest"`
+	Reservations []*models.Reservation `json:"reservations"`
+	Sharers      []*models.Sharer      `json:"sharers"`
+	Payments     []*models.Payment     `json:"payments"`
+	Emails       []*models.GuestEmail  `json:"emails"`
+	Invoices     []*models.Invoice     `json:"invoices"`
+}
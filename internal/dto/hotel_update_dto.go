@@ -21,6 +21,8 @@ type HotelUpdateDto struct {
 	Description  string  `json:"description"  gorm:"type:varchar(255)"`
 	HotelTypeId  uint64  `json:"hotel_type_id" valid:"required"`
 	HotelGradeId uint64  `json:"hotel_grade_id" valid:"required"`
+
+	NoShowCutoffHour uint `json:"no_show_cutoff_hour" valid:"range(0|23)"`
 }
 
 type HotelCreateDto struct {
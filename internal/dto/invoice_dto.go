@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"reservation-api/internal/models"
+	"time"
+)
+
+// InvoiceLineDto is an itemized line of an invoice, payments have negative amounts.
+type InvoiceLineDto struct {
+	Date        *time.Time
+	Description string
+	Amount      float64
+}
+
+// InvoiceDto is the data which an invoice document is rendered from.
+type InvoiceDto struct {
+	Invoice     *models.Invoice
+	Hotel       *models.Hotel
+	Logo        []byte
+	LogoType    string
+	Guest       *models.Guest
+	Reservation *models.Reservation
+	Charges     []*InvoiceLineDto
+	Taxes       []*InvoiceLineDto
+	Payments    []*InvoiceLineDto
+}
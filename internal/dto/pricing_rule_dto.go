@@ -0,0 +1,13 @@
+package dto
+
+import "time"
+
+// PriceAdjustmentFilter filters logged pricing rule adjustments.
+type PriceAdjustmentFilter struct {
+	PaginationFilter
+	PricingRuleId uint64     `json:"pricing_rule_id" query:"pricing_rule_id"`
+	ReservationId uint64     `json:"reservation_id" query:"reservation_id"`
+	RoomTypeId    uint64     `json:"room_type_id" query:"room_type_id"`
+	DateFrom      *time.Time `json:"date_from" query:"date_from"`
+	DateTo        *time.Time `json:"date_to" query:"date_to"`
+}
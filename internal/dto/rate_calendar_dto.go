@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"reservation-api/internal/models"
+	"time"
+)
+
+// RateCalendarUpdateDto sets prices of a rate code for days of a date range, DateStart and DateEnd are included.
+// Weekdays limits the update to days of week (0 is sunday), empty means all days. Rooms are given by ids
+// or room types. Restrictions of the updated days are kept, MinNights and MaxNights are used for days without price.
+type RateCalendarUpdateDto struct {
+	DateStart   *time.Time              `json:"date_start" valid:"required"`
+	DateEnd     *time.Time              `json:"date_end" valid:"required"`
+	Weekdays    []time.Weekday          `json:"weekdays"`
+	RoomIds     []uint64                `json:"room_ids"`
+	RoomTypeIds []uint64                `json:"room_type_ids"`
+	Prices      []*RateCalendarPriceDto `json:"prices" valid:"required"`
+	MinNights   uint64                  `json:"min_nights"`
+	MaxNights   uint64                  `json:"max_nights"`
+	DryRun      bool                    `json:"dry_run"`
+}
+
+type RateCalendarPriceDto struct {
+	GuestCount uint64  `json:"guest_count"`
+	Price      float64 `json:"price"`
+}
+
+// RateCalendarDiffDto is the change of rate code details by a calendar update, Removed details are replaced by
+// Created ones. Nothing is changed in a dry run.
+type RateCalendarDiffDto struct {
+	DryRun  bool                     `json:"dry_run"`
+	Removed []*models.RateCodeDetail `json:"removed"`
+	Created []*models.RateCodeDetail `json:"created"`
+}
@@ -11,7 +11,14 @@ type GetRatePriceDto struct {
 	GuestCount uint64     `json:"guest_count" valid:"required"`
 	DateStart  *time.Time `json:"date_start"  valid:"required"`
 	DateEnd    *time.Time `json:"date_end"    valid:"required"`
-	RateCodeId uint64     `json:"rate_code_id"`
+	// RateCodeId limits recommendations to a rate code, 0 means all rate codes of the room.
+	RateCodeId uint64 `json:"rate_code_id"`
+	// CurrencyId is the guest's currency which quotes are converted to, 0 means currency of each rate code.
+	CurrencyId uint64 `json:"currency_id"`
+	// GuestCount is the adults of the stay, children are priced by age bands of the hotel and extra beds
+	// by the rate code.
+	ChildAges []uint64 `json:"child_ages"`
+	ExtraBeds uint64   `json:"extra_beds"`
 }
 
 func (d *GetRatePriceDto) Validate() (bool, error) {
@@ -21,6 +28,7 @@ func (d *GetRatePriceDto) Validate() (bool, error) {
 type RateCodePricesDto struct {
 	RateCodeName string     `json:"rate_code_name"`
 	RateCodeId   uint64     `json:"rate_code_id"`
+	CurrencyId   uint64     `json:"currency_id"`
 	CreatedAt    *time.Time `json:"created_at"`
 	RoomId       uint64     `json:"room_id"`
 	DetailId     uint64     `json:"detail_id"`
@@ -29,4 +37,45 @@ type RateCodePricesDto struct {
 	Price        float64    `json:"price"`
 	GuestCount   uint64     `json:"guest_count"`
 	RatePriceId  uint64     `json:"rate_price_id"`
+
+	ExtraAdultPrice float64 `json:"extra_adult_price"`
+	ExtraBedPrice   float64 `json:"extra_bed_price"`
+
+	MinNights         uint64 `json:"min_nights"`
+	MaxNights         uint64 `json:"max_nights"`
+	ClosedToArrival   bool   `json:"closed_to_arrival"`
+	ClosedToDeparture bool   `json:"closed_to_departure"`
+	MinAdvanceDays    uint64 `json:"min_advance_days"`
+	MaxAdvanceDays    uint64 `json:"max_advance_days"`
+}
+
+// NightPriceDto is price of a single night of a stay and the rate code detail which it is resolved from.
+type NightPriceDto struct {
+	Date             time.Time `json:"date"`
+	RateCodeId       uint64    `json:"rate_code_id"`
+	RateCodeDetailId uint64    `json:"rate_code_detail_id"`
+	RatePriceId      uint64    `json:"rate_price_id"`
+	Price            float64   `json:"price"`
+	// BasePrice is price of the night before pricing rule adjustment, PricingRuleId is the applied rule.
+	BasePrice     float64 `json:"base_price"`
+	PricingRuleId *uint64 `json:"pricing_rule_id,omitempty"`
+	Occupancy     float64 `json:"occupancy"`
+}
+
+// RateCodeQuoteDto is price of a stay for a rate code with per night breakdown,
+// nights of a stay which crosses season boundaries are priced by different rate code details.
+type RateCodeQuoteDto struct {
+	RateCodeId   uint64  `json:"rate_code_id"`
+	RateCodeName string  `json:"rate_code_name"`
+	Total        float64 `json:"total"`
+	TaxAmount    float64 `json:"tax_amount"`
+	CurrencyId   uint64  `json:"currency_id"`
+	// converted amounts of the quote in the requested currency.
+	QuoteCurrencyId    uint64           `json:"quote_currency_id,omitempty"`
+	ExchangeRate       float64          `json:"exchange_rate,omitempty"`
+	ConvertedTotal     float64          `json:"converted_total,omitempty"`
+	ConvertedTaxAmount float64          `json:"converted_tax_amount,omitempty"`
+	Nights             []*NightPriceDto `json:"nights"`
+	// Restriction is the reason which the stay can not be booked by this rate code, it is empty if stay is bookable.
+	Restriction string `json:"restriction,omitempty"`
 }
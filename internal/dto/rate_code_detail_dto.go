@@ -13,6 +13,11 @@ type RateCodeDetailDto struct {
 	Room       *Room        `json:"room"`
 	RoomId     uint64       `json:"room_id"       valid:"required"`
 
+	ClosedToArrival   bool   `json:"closed_to_arrival"`
+	ClosedToDeparture bool   `json:"closed_to_departure"`
+	MinAdvanceDays    uint64 `json:"min_advance_days"`
+	MaxAdvanceDays    uint64 `json:"max_advance_days"`
+
 	RatePrices []*RateCodeDetailPriceDto `json:"rate_prices" valid:"required"`
 }
 
@@ -9,4 +9,13 @@ type RateCodeDto struct {
 	CurrencyId  uint64       `json:"currency_id" valid:"required"`
 	RateGroupId uint64       `json:"rate_group_id"`
 	Status      bool         `json:"status"`
+
+	CancellationPolicyId *uint64 `json:"cancellation_policy_id"`
+	NoShowFee            float64 `json:"no_show_fee"`
+
+	ParentRateCodeId *uint64 `json:"parent_rate_code_id"`
+	DerivationType   int     `json:"derivation_type"`
+	DerivationValue  float64 `json:"derivation_value"`
+	RoundingType     int     `json:"rounding_type"`
+	RoundingUnit     float64 `json:"rounding_unit"`
 }
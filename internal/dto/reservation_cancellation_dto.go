@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"reservation-api/internal/models"
+	"time"
+)
+
+// ReservationCancellationDto is result of cancelling a reservation,
+// it is also published on message broker as cancellation event.
+type ReservationCancellationDto struct {
+	ReservationId uint64              `json:"reservation_id"`
+	TenantId      uint64              `json:"tenant_id"`
+	CancelledAt   time.Time           `json:"cancelled_at"`
+	CancelledBy   string              `json:"cancelled_by"`
+	Penalty       float64             `json:"penalty"`
+	Payment       *models.Payment     `json:"payment"`
+	Reservation   *models.Reservation `json:"reservation"`
+}
@@ -5,9 +5,13 @@ import "time"
 type ReservationCheckStatus int
 
 const (
-	CheckIn ReservationCheckStatus = iota
-	Checkout
+	CheckedIn ReservationCheckStatus = iota
+	CheckedOut
 	Block
+	Cancelled
+	Tentative
+	Confirmed
+	NoShow
 )
 
 type ReservationCreateDto struct {
@@ -31,3 +35,15 @@ type ReservationCreateDto struct {
 	CheckStatus  ReservationCheckStatus `json:"check_status" valid:"required"`
 	Sharers      []*SharerDto           `json:"sharers"`
 }
+
+// StayOverlapDto is a pair of active reservations of a room whose stays overlap, they are booked before overlaps
+// are prevented and must be moved or cancelled before the stays of the room can be constrained.
+type StayOverlapDto struct {
+	RoomId                   uint64     `json:"room_id"`
+	ReservationId            uint64     `json:"reservation_id"`
+	CheckinDate              *time.Time `json:"checkin_date"`
+	CheckoutDate             *time.Time `json:"checkout_date"`
+	OverlappingReservationId uint64     `json:"overlapping_reservation_id"`
+	OverlappingCheckinDate   *time.Time `json:"overlapping_checkin_date"`
+	OverlappingCheckoutDate  *time.Time `json:"overlapping_checkout_date"`
+}
@@ -14,4 +14,6 @@ type RoomRequestDto struct {
 	CheckInDate  *time.Time             `json:"check_in_date"`
 	CheckOutDate *time.Time             `json:"check_out_date"`
 	RoomId       uint64                 `json:"room_id"`
+	// RateCodeId is optional, if it is set stay restrictions of the rate code are checked.
+	RateCodeId uint64 `json:"rate_code_id"`
 }
@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"reservation-api/internal/models"
+	"time"
+)
+
+// WalletTransactionFilter filters ledger of a wallet.
+type WalletTransactionFilter struct {
+	PaginationFilter
+	Type     *models.WalletTransactionType `json:"type" query:"type"`
+	DateFrom *time.Time                    `json:"date_from" query:"date_from"`
+	DateTo   *time.Time                    `json:"date_to" query:"date_to"`
+}
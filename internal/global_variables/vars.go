@@ -6,16 +6,20 @@ package global_variables
 import "time"
 
 var (
-	RoomDefaultLockMinute   float64 = 20
-	RoomDefaultLockDuration         = time.Now().Add(time.Minute * 20)
-	HotelsBucketName                = "hotels-bucket"
-	EmailQueueName                  = "email_queue"
-	ReservationQueueName            = "reservation_queue"
-	SendEmailRetryCount     uint    = 3
-	TenantIDKey                     = "TenantID"
-	TenantIDCtx                     = "TenantIDCtx"
-	ClaimsKey                       = "Claims"
-	CurrentLang                     = "CurrentLang"
-	UserClaims                      = "user_claims"
-	DefaultTenantID                 = uint64(1)
+	RoomDefaultLockMinute      float64 = 20
+	RoomDefaultLockDuration            = time.Now().Add(time.Minute * 20)
+	HotelsBucketName                   = "hotels-bucket"
+	InvoicesBucketName                 = "invoices-bucket"
+	EmailQueueName                     = "email_queue"
+	ReservationQueueName               = "reservation_queue"
+	ReservationCancelQueueName         = "reservation_cancel_queue"
+	SendEmailRetryCount        uint    = 3
+	TenantIDKey                        = "TenantID"
+	TenantIDCtx                        = "TenantIDCtx"
+	ClaimsKey                          = "Claims"
+	CurrentLang                        = "CurrentLang"
+	UserClaims                         = "user_claims"
+	DefaultTenantID                    = uint64(1)
+	MaxAvailabilityRangeDays           = 366
+	SystemUsername                     = "system"
 )
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"github.com/asaskevich/govalidator"
+	"math"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+var (
+	AddOnNotFoundErr     = errors.New(message_keys.AddOnNotFound)
+	InvalidAddOnDatesErr = errors.New(message_keys.InvalidAddOnDates)
+)
+
+type AddOnPricing int
+
+const (
+	PerStayAddOn AddOnPricing = iota
+	PerNightAddOn
+	PerPersonAddOn
+	PerPersonPerNightAddOn
+)
+
+// AddOn is a service of a hotel which is sold with reservations, like breakfast, parking, airport transfer or spa.
+type AddOn struct {
+	BaseModel
+	HotelId uint64       `json:"hotel_id" valid:"required" gorm:"index"`
+	Name    string       `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	Pricing AddOnPricing `json:"pricing" valid:"range(0|3)"`
+	Price   float64      `json:"price"`
+	Active  bool         `json:"active"`
+}
+
+func (a *AddOn) Validate() (bool, error) {
+
+	return govalidator.ValidateStruct(a)
+}
+
+func (a *AddOn) SetAudit(username string) {
+	a.CreatedBy = username
+	a.UpdatedBy = username
+}
+
+func (a *AddOn) SetUpdatedBy(username string) {
+	a.UpdatedBy = username
+}
+
+// Calculate returns amount of given quantity of the add-on for nights and guests, it is rounded to cents.
+func (a *AddOn) Calculate(quantity uint64, nights uint64, guests uint64) float64 {
+
+	amount := a.Price * float64(quantity)
+
+	switch a.Pricing {
+	case PerNightAddOn:
+		amount *= float64(nights)
+	case PerPersonAddOn:
+		amount *= float64(guests)
+	case PerPersonPerNightAddOn:
+		amount *= float64(guests) * float64(nights)
+	}
+
+	return math.Round(amount*100) / 100
+}
+
+// ReservationAddOn is an add-on which is sold with a reservation, DateStart and DateEnd are the nights which the
+// add-on is used in and they are the stay if they are empty. Included add-ons are bundled by the rate code,
+// they are part of the room price and their amount is zero.
+type ReservationAddOn struct {
+	BaseModel
+	ReservationId uint64     `json:"reservation_id" gorm:"index"`
+	AddOnId       uint64     `json:"add_on_id" valid:"required"`
+	AddOn         *AddOn     `json:"add_on,omitempty" valid:"-" gorm:"foreignKey:AddOnId;references:id"`
+	Quantity      uint64     `json:"quantity"`
+	DateStart     *time.Time `json:"date_start"`
+	DateEnd       *time.Time `json:"date_end"`
+	UnitPrice     float64    `json:"unit_price"`
+	Amount        float64    `json:"amount"`
+	Included      bool       `json:"included"`
+}
+
+// RateCodeAddOn is an add-on which is bundled in prices of a rate code as an inclusive package,
+// like bed and breakfast.
+type RateCodeAddOn struct {
+	BaseModel
+	RateCodeId uint64 `json:"rate_code_id" gorm:"index"`
+	AddOnId    uint64 `json:"add_on_id" valid:"required"`
+	Quantity   uint64 `json:"quantity"`
+}
+
+// PriceAddOns returns add-ons of a stay and their total amount. Add-ons bundled by the rate code are included with
+// zero amount, selected add-ons are priced by catalog which has active add-ons of the hotel by id. Included lines of
+// selected are ignored because they are replaced by bundles. It returns AddOnNotFoundErr if an add-on is not in the
+// catalog and InvalidAddOnDatesErr if add-on dates are not inside the stay.
+func PriceAddOns(selected []*ReservationAddOn, catalog map[uint64]*AddOn, bundles []*RateCodeAddOn,
+	checkin, checkout time.Time, guests uint64) ([]*ReservationAddOn, float64, error) {
+
+	result := make([]*ReservationAddOn, 0, len(selected)+len(bundles))
+	total := 0.0
+
+	for _, bundle := range bundles {
+
+		addOn, ok := catalog[bundle.AddOnId]
+		if !ok {
+			continue
+		}
+
+		start, end := checkin, checkout
+		result = append(result, &ReservationAddOn{
+			AddOnId:   bundle.AddOnId,
+			Quantity:  uint64(math.Max(1, float64(bundle.Quantity))),
+			DateStart: &start,
+			DateEnd:   &end,
+			UnitPrice: addOn.Price,
+			Included:  true,
+		})
+	}
+
+	for _, item := range selected {
+
+		if item.Included {
+			continue
+		}
+
+		addOn, ok := catalog[item.AddOnId]
+		if !ok {
+			return nil, 0, AddOnNotFoundErr
+		}
+
+		start, end := checkin, checkout
+		if item.DateStart != nil {
+			start = *item.DateStart
+		}
+		if item.DateEnd != nil {
+			end = *item.DateEnd
+		}
+
+		if date_utils.TruncateToDay(start).Before(date_utils.TruncateToDay(checkin)) ||
+			date_utils.TruncateToDay(end).After(date_utils.TruncateToDay(checkout)) || end.Before(start) {
+			return nil, 0, InvalidAddOnDatesErr
+		}
+
+		if item.Quantity == 0 {
+			item.Quantity = 1
+		}
+
+		item.DateStart = &start
+		item.DateEnd = &end
+		item.UnitPrice = addOn.Price
+		item.Amount = addOn.Calculate(item.Quantity, uint64(len(date_utils.Nights(start, end))), guests)
+		total += item.Amount
+		result = append(result, item)
+	}
+
+	return result, math.Round(total*100) / 100, nil
+}
+
+// IsAddOnErr reports whether err is a rejection of add-ons of a reservation.
+func IsAddOnErr(err error) bool {
+	return errors.Is(err, AddOnNotFoundErr) || errors.Is(err, InvalidAddOnDatesErr)
+}
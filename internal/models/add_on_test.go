@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAddOnCalculate(t *testing.T) {
+
+	testCases := []struct {
+		name  string
+		addOn AddOn
+		want  float64
+	}{
+		{name: "transfer", addOn: AddOn{Pricing: PerStayAddOn, Price: 40}, want: 80},
+		{name: "parking", addOn: AddOn{Pricing: PerNightAddOn, Price: 10}, want: 60},
+		{name: "spa", addOn: AddOn{Pricing: PerPersonAddOn, Price: 25}, want: 150},
+		{name: "breakfast", addOn: AddOn{Pricing: PerPersonPerNightAddOn, Price: 12.5}, want: 225},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.addOn.Calculate(2, 3, 3))
+		})
+	}
+}
+
+func TestPriceAddOns(t *testing.T) {
+
+	checkin := time.Date(2023, 6, 1, 14, 0, 0, 0, time.UTC)
+	checkout := time.Date(2023, 6, 4, 12, 0, 0, 0, time.UTC)
+	parkingStart := time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	breakfast := &AddOn{Name: "Breakfast", Pricing: PerPersonPerNightAddOn, Price: 10}
+	breakfast.Id = 1
+	parking := &AddOn{Name: "Parking", Pricing: PerNightAddOn, Price: 15}
+	parking.Id = 2
+	catalog := map[uint64]*AddOn{1: breakfast, 2: parking}
+
+	selected := []*ReservationAddOn{
+		{AddOnId: 2, DateStart: &parkingStart},
+		{AddOnId: 1, Included: true, Quantity: 5},
+	}
+	bundles := []*RateCodeAddOn{{AddOnId: 1}}
+
+	result, total, err := PriceAddOns(selected, catalog, bundles, checkin, checkout, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.True(t, result[0].Included)
+	assert.Equal(t, 0.0, result[0].Amount)
+	assert.Equal(t, 30.0, result[1].Amount)
+	assert.Equal(t, 30.0, total)
+
+	_, _, err = PriceAddOns([]*ReservationAddOn{{AddOnId: 3}}, catalog, nil, checkin, checkout, 2)
+	assert.Equal(t, AddOnNotFoundErr, err)
+
+	late := checkout.AddDate(0, 0, 1)
+	_, _, err = PriceAddOns([]*ReservationAddOn{{AddOnId: 2, DateEnd: &late}}, catalog, nil, checkin, checkout, 2)
+	assert.Equal(t, InvalidAddOnDatesErr, err)
+}
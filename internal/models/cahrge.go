@@ -1,10 +1,98 @@
 package models
 
+import (
+	"errors"
+	"math"
+	"reservation-api/internal_errors/message_keys"
+)
+
+var (
+	InvalidChargeAmountErr = errors.New(message_keys.InvalidChargeAmount)
+	InvalidChargeStatusErr = errors.New(message_keys.InvalidChargeStatus)
+	ChargeDeclinedErr      = errors.New(message_keys.ChargeDeclined)
+	ChargeCurrencyErr      = errors.New(message_keys.ChargeCurrency)
+)
+
+type ChargeStatus int
+
+const (
+	// ChargePending charges are sent to the gateway and wait for it or for an action of the card holder.
+	ChargePending ChargeStatus = iota
+	// ChargeAuthorized charges hold the amount on the card until they are captured or voided.
+	ChargeAuthorized
+	// ChargeCaptured charges are paid and have a payment on the reservation folio.
+	ChargeCaptured
+	ChargeVoided
+	ChargeFailed
+)
+
+// Charge is a card payment of a reservation through the payment gateway, GatewayReference is id of the charge
+// in the gateway. When the charge is captured, the captured amount is paid by a CREDIT payment of PayerId.
+// Amount is charged in Currency, which is currency of the reservation, and FolioAmount is the same amount in currency
+// of the folio, ExchangeRate converts folio amounts to the charge currency.
 type Charge struct {
 	BaseModel
-	Amount        float64     `json:"amount"`
-	ReceiptEmail  string      `json:"receipt_email"`
-	Reservation   Reservation `json:"reservation"  gorm:"foreignkey:ReservationId"`
-	ReservationId uint64      `json:"reservation_id"`
-	CreatedBy     string      `json:"created_by"`
+	ReservationId    uint64       `json:"reservation_id" gorm:"index"`
+	Reservation      *Reservation `json:"reservation,omitempty" gorm:"foreignKey:ReservationId;references:id"`
+	PayerId          uint64       `json:"payer_id"`
+	Amount           float64      `json:"amount"`
+	Currency         string       `json:"currency" gorm:"type:varchar(3)"`
+	FolioAmount      float64      `json:"folio_amount"`
+	ExchangeRate     float64      `json:"exchange_rate"`
+	ReceiptEmail     string       `json:"receipt_email"`
+	Status           ChargeStatus `json:"status"`
+	GatewayReference string       `json:"gateway_reference" gorm:"type:varchar(255);index"`
+	AmountCaptured   float64      `json:"amount_captured"`
+	FailureMessage   string       `json:"failure_message"`
+	PaymentId        *uint64      `json:"payment_id"`
+}
+
+func (c *Charge) SetAudit(username string) {
+	c.CreatedBy = username
+	c.UpdatedBy = username
+}
+
+func (c *Charge) SetUpdatedBy(username string) {
+	c.UpdatedBy = username
+}
+
+// Transition moves the charge to status and reports whether it is moved. Charges move forward only, captured, voided
+// and failed charges are final, so late or repeated gateway notifications do not change them.
+func (c *Charge) Transition(status ChargeStatus) bool {
+
+	if c.Status == ChargeCaptured || c.Status == ChargeVoided || c.Status == ChargeFailed {
+		return false
+	}
+
+	if status == c.Status || (status == ChargePending && c.Status == ChargeAuthorized) {
+		return false
+	}
+
+	c.Status = status
+	return true
+}
+
+// ToChargeAmount converts an amount in currency of the folio to currency of the charge.
+func (c *Charge) ToChargeAmount(amount float64) float64 {
+
+	if c.ExchangeRate == 0 {
+		return amount
+	}
+
+	return ConvertAmount(amount, c.ExchangeRate)
+}
+
+// ToFolioAmount converts an amount in currency of the charge to currency of the folio, the whole amount of the charge
+// is converted to FolioAmount exactly.
+func (c *Charge) ToFolioAmount(amount float64) float64 {
+
+	if c.ExchangeRate == 0 {
+		return amount
+	}
+
+	if amount == c.Amount {
+		return c.FolioAmount
+	}
+
+	return math.Round(amount/c.ExchangeRate*100) / 100
 }
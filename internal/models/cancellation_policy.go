@@ -0,0 +1,80 @@
+package models
+
+import (
+	"github.com/asaskevich/govalidator"
+	"math"
+	"time"
+)
+
+type CancellationPenaltyType int
+
+const (
+	// FirstNightPenalty charges price of the first night of the stay, reservations without nightly prices are
+	// charged their average night price.
+	FirstNightPenalty CancellationPenaltyType = iota
+	// PercentagePenalty charges PenaltyValue percent of the reservation price.
+	PercentagePenalty
+	// FixedPenalty charges PenaltyValue as a fixed amount.
+	FixedPenalty
+	// FullStayPenalty charges the whole reservation price.
+	FullStayPenalty
+)
+
+// CancellationPolicy describes how much a guest pays when a reservation is cancelled,
+// for example "free until 48h before arrival, then first night".
+type CancellationPolicy struct {
+	BaseModel
+	Name                  string                  `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	Description           string                  `json:"description"`
+	HotelId               uint64                  `json:"hotel_id" valid:"required"`
+	Hotel                 *Hotel                  `json:"hotel" valid:"-"`
+	FreeCancellationHours uint64                  `json:"free_cancellation_hours"`
+	PenaltyType           CancellationPenaltyType `json:"penalty_type" valid:"range(0|3)"`
+	PenaltyValue          float64                 `json:"penalty_value"`
+}
+
+func (c *CancellationPolicy) Validate() (bool, error) {
+
+	return govalidator.ValidateStruct(c)
+}
+
+func (c *CancellationPolicy) SetAudit(username string) {
+	c.CreatedBy = username
+	c.UpdatedBy = username
+}
+
+func (c *CancellationPolicy) SetUpdatedBy(username string) {
+	c.UpdatedBy = username
+}
+
+// CalculatePenalty returns penalty of cancelling given reservation at cancelTime.
+// Cancellation is free until FreeCancellationHours before checkin and penalty never exceeds the reservation price.
+func (c *CancellationPolicy) CalculatePenalty(reservation *Reservation, cancelTime time.Time) float64 {
+
+	if reservation.CheckinDate == nil || reservation.Price <= 0 {
+		return 0
+	}
+
+	if reservation.CheckinDate.Sub(cancelTime).Hours() >= float64(c.FreeCancellationHours) {
+		return 0
+	}
+
+	penalty := 0.0
+
+	switch c.PenaltyType {
+	case FirstNightPenalty:
+		if night := reservation.FirstNight(); night != nil {
+			penalty = night.Price
+		} else if reservation.Nights > 0 {
+			penalty = reservation.Price / reservation.Nights
+		}
+	case PercentagePenalty:
+		penalty = reservation.Price * c.PenaltyValue / 100
+	case FixedPenalty:
+		penalty = c.PenaltyValue
+	case FullStayPenalty:
+		penalty = reservation.Price
+	}
+
+	return math.Round(math.Min(math.Max(penalty, 0), reservation.Price)*100) / 100
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCalculatePenalty(t *testing.T) {
+
+	checkin := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	reservation := &Reservation{CheckinDate: &checkin, Price: 300, Nights: 3}
+
+	testCases := []struct {
+		name       string
+		policy     CancellationPolicy
+		cancelTime time.Time
+		want       float64
+	}{
+		{name: "free_window", policy: CancellationPolicy{FreeCancellationHours: 48, PenaltyType: FullStayPenalty},
+			cancelTime: checkin.Add(-72 * time.Hour), want: 0},
+		{name: "first_night", policy: CancellationPolicy{FreeCancellationHours: 48, PenaltyType: FirstNightPenalty},
+			cancelTime: checkin.Add(-24 * time.Hour), want: 100},
+		{name: "percentage", policy: CancellationPolicy{PenaltyType: PercentagePenalty, PenaltyValue: 25},
+			cancelTime: checkin.Add(time.Hour), want: 75},
+		{name: "fixed", policy: CancellationPolicy{PenaltyType: FixedPenalty, PenaltyValue: 40},
+			cancelTime: checkin.Add(time.Hour), want: 40},
+		{name: "fixed_capped", policy: CancellationPolicy{PenaltyType: FixedPenalty, PenaltyValue: 500},
+			cancelTime: checkin.Add(time.Hour), want: 300},
+		{name: "full_stay", policy: CancellationPolicy{PenaltyType: FullStayPenalty},
+			cancelTime: checkin.Add(time.Hour), want: 300},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.policy.CalculatePenalty(reservation, testCase.cancelTime))
+		})
+	}
+}
+
+func TestCalculatePenaltyFirstNightPrice(t *testing.T) {
+
+	checkin := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	second, first := checkin.AddDate(0, 0, 1), checkin
+	reservation := &Reservation{CheckinDate: &checkin, Price: 300, Nights: 2, NightPrices: []*ReservationNight{
+		{Date: &second, Price: 120},
+		{Date: &first, Price: 180},
+	}}
+
+	policy := CancellationPolicy{PenaltyType: FirstNightPenalty}
+	assert.Equal(t, 180.0, policy.CalculatePenalty(reservation, checkin.Add(time.Hour)))
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChargeTransition(t *testing.T) {
+
+	charge := &Charge{Status: ChargePending}
+
+	assert.True(t, charge.Transition(ChargeAuthorized))
+	assert.False(t, charge.Transition(ChargePending), "authorized charge can not go back to pending")
+	assert.False(t, charge.Transition(ChargeAuthorized))
+	assert.True(t, charge.Transition(ChargeCaptured))
+	assert.Equal(t, ChargeCaptured, charge.Status)
+
+	for _, status := range []ChargeStatus{ChargePending, ChargeAuthorized, ChargeVoided, ChargeFailed, ChargeCaptured} {
+		assert.False(t, charge.Transition(status), "captured charge is final")
+	}
+
+	failed := &Charge{Status: ChargePending}
+	assert.True(t, failed.Transition(ChargeFailed))
+	assert.False(t, failed.Transition(ChargeCaptured))
+}
+
+func TestChargeCurrencyConversion(t *testing.T) {
+
+	charge := &Charge{FolioAmount: 100, ExchangeRate: 3}
+	charge.Amount = charge.ToChargeAmount(charge.FolioAmount)
+
+	assert.Equal(t, float64(300), charge.Amount)
+	assert.Equal(t, float64(100), charge.ToFolioAmount(300))
+	assert.Equal(t, 33.33, charge.ToFolioAmount(100))
+
+	// charges without exchange rate are in currency of the folio.
+	legacy := &Charge{Amount: 50}
+	assert.Equal(t, float64(50), legacy.ToChargeAmount(50))
+	assert.Equal(t, float64(20), legacy.ToFolioAmount(20))
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"github.com/asaskevich/govalidator"
+	"reservation-api/internal_errors/message_keys"
+)
+
+var (
+	InvalidChildAgeBandErr = errors.New(message_keys.InvalidChildAgeBand)
+	ChildAgeBandOverlapErr = errors.New(message_keys.ChildAgeBandOverlap)
+)
+
+// ChildAgeBand is an age range of children of a hotel which rate codes price children by, MinAge and MaxAge are
+// inclusive. Children whose age is not in a band of the hotel are counted as adults.
+type ChildAgeBand struct {
+	BaseModel
+	HotelId uint64 `json:"hotel_id" valid:"required" gorm:"index"`
+	Name    string `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	MinAge  uint64 `json:"min_age"`
+	MaxAge  uint64 `json:"max_age" valid:"range(0|17)"`
+}
+
+func (b *ChildAgeBand) Validate() (bool, error) {
+
+	ok, err := govalidator.ValidateStruct(b)
+	if err != nil {
+		return ok, err
+	}
+
+	if b.MinAge > b.MaxAge {
+		return false, InvalidChildAgeBandErr
+	}
+
+	return ok, nil
+}
+
+func (b *ChildAgeBand) SetAudit(username string) {
+	b.CreatedBy = username
+	b.UpdatedBy = username
+}
+
+func (b *ChildAgeBand) SetUpdatedBy(username string) {
+	b.UpdatedBy = username
+}
+
+// Overlaps reports whether two bands have at least one common age.
+func (b *ChildAgeBand) Overlaps(other *ChildAgeBand) bool {
+	return b.MinAge <= other.MaxAge && other.MinAge <= b.MaxAge
+}
+
+// FindAgeBand returns the band which given age is in, it returns nil if no band contains the age.
+func FindAgeBand(bands []*ChildAgeBand, age uint64) *ChildAgeBand {
+
+	for _, band := range bands {
+		if age >= band.MinAge && age <= band.MaxAge {
+			return band
+		}
+	}
+
+	return nil
+}
@@ -8,6 +8,8 @@ type Currency struct {
 	BaseModel
 	Name   string `json:"name" valid:"required"    gorm:"type:varchar(50)"`
 	Symbol string `json:"symbol" valid:"required"  gorm:"type:varchar(50)"`
+	// Code is ISO 4217 code of the currency like USD, it is used by exchange rates import.
+	Code string `json:"code" valid:"stringlength(3|3)" gorm:"type:varchar(3)"`
 }
 
 // Validate validates currency struct
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reservation-api/internal/global_variables"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/field_encryption"
+	"time"
+)
+
+var (
+	MissingTenantErr           = errors.New("field encryption needs tenant of the context")
+	FieldEncryptionDisabledErr = errors.New(message_keys.FieldEncryptionDisabled)
+)
+
+// encryptedTimeLayouts parse dates which are stored before their columns are encrypted.
+var encryptedTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05-07", "2006-01-02"}
+
+// EncryptedString is a string column which is encrypted with the data key of the tenant when it is written and
+// decrypted when it is read, values which are not encrypted yet are read as they are.
+// Encrypted columns can not be searched, search them by a blind index.
+type EncryptedString string
+
+func (EncryptedString) GormDataType() string {
+	return "text"
+}
+
+func (s EncryptedString) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return encryptedValue(ctx, db, string(s))
+}
+
+func (s *EncryptedString) Scan(value interface{}) error {
+
+	text, err := decryptedValue(value)
+	if err != nil {
+		return err
+	}
+
+	*s = EncryptedString(text)
+	return nil
+}
+
+// EncryptedTime is a time column which is encrypted like EncryptedString, it is a time.Time in json.
+type EncryptedTime struct {
+	time.Time
+}
+
+func (EncryptedTime) GormDataType() string {
+	return "text"
+}
+
+func (t EncryptedTime) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return encryptedValue(ctx, db, t.Format(time.RFC3339Nano))
+}
+
+func (t *EncryptedTime) Scan(value interface{}) error {
+
+	if value, ok := value.(time.Time); ok {
+		t.Time = value
+		return nil
+	}
+
+	text, err := decryptedValue(value)
+	if err != nil {
+		return err
+	}
+
+	for _, layout := range encryptedTimeLayouts {
+		if t.Time, err = time.Parse(layout, text); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// BlindIndex returns a keyed hash of the value in the tenant of ctx, equal values have equal indexes so encrypted
+// columns are searched by them. The value is returned as it is if field encryption is not configured.
+func BlindIndex(ctx context.Context, value string) (string, error) {
+
+	keyring := field_encryption.Default()
+	if keyring == nil || value == "" {
+		return value, nil
+	}
+
+	tenantId, ok := ctx.Value(global_variables.TenantIDKey).(uint64)
+	if !ok {
+		return "", MissingTenantErr
+	}
+
+	return keyring.BlindIndex(tenantId, value)
+}
+
+// ActiveCiphertextPrefix returns prefix of values which are encrypted with the active data key of the tenant of ctx,
+// it is empty if field encryption is not configured.
+func ActiveCiphertextPrefix(ctx context.Context) (string, error) {
+
+	keyring := field_encryption.Default()
+	if keyring == nil {
+		return "", nil
+	}
+
+	tenantId, ok := ctx.Value(global_variables.TenantIDKey).(uint64)
+	if !ok {
+		return "", MissingTenantErr
+	}
+
+	return keyring.ActivePrefix(tenantId)
+}
+
+// encryptedValue returns the value which is encrypted with the data key of the tenant of ctx, the statement fails if
+// it can not be encrypted.
+func encryptedValue(ctx context.Context, db *gorm.DB, value string) clause.Expr {
+
+	keyring := field_encryption.Default()
+	if keyring == nil || value == "" {
+		return clause.Expr{SQL: "?", Vars: []interface{}{value}}
+	}
+
+	tenantId, ok := ctx.Value(global_variables.TenantIDKey).(uint64)
+	if !ok {
+		db.AddError(MissingTenantErr)
+		return clause.Expr{SQL: "NULL"}
+	}
+
+	encrypted, err := keyring.Encrypt(tenantId, value)
+	if err != nil {
+		db.AddError(err)
+		return clause.Expr{SQL: "NULL"}
+	}
+
+	return clause.Expr{SQL: "?", Vars: []interface{}{encrypted}}
+}
+
+func decryptedValue(value interface{}) (string, error) {
+
+	text := ""
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		text = value
+	case []byte:
+		text = string(value)
+	default:
+		return "", fmt.Errorf("can not scan %T into an encrypted column", value)
+	}
+
+	if !field_encryption.IsEncrypted(text) {
+		return text, nil
+	}
+
+	keyring := field_encryption.Default()
+	if keyring == nil {
+		return "", field_encryption.UnknownKeyErr
+	}
+
+	return keyring.Decrypt(text)
+}
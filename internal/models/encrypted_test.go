@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"reservation-api/internal/global_variables"
+	"reservation-api/pkg/field_encryption"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memorySecretStore is an in memory field_encryption.SecretStore.
+type memorySecretStore map[string]string
+
+func (s memorySecretStore) GetAt(ctx context.Context, path, key string) (string, int, error) {
+	if value, ok := s[path]; ok {
+		return value, 1, nil
+	}
+	return "", 0, nil
+}
+
+func (s memorySecretStore) PutAt(ctx context.Context, path, key, val string, version int) error {
+	s[path] = val
+	return nil
+}
+
+func TestEncryptedColumns(t *testing.T) {
+
+	keyring, err := field_encryption.NewKeyring(context.Background(), memorySecretStore{})
+	assert.Nil(t, err)
+	field_encryption.SetDefault(keyring)
+	defer field_encryption.SetDefault(nil)
+
+	ctx := context.WithValue(context.Background(), global_variables.TenantIDKey, uint64(3))
+
+	t.Run("encrypts_strings", func(t *testing.T) {
+
+		expr := EncryptedString("0012345678").GormValue(ctx, &gorm.DB{})
+		stored := expr.Vars[0].(string)
+		assert.True(t, strings.HasPrefix(stored, "enc:v1:3:1:"))
+
+		var value EncryptedString
+		assert.Nil(t, value.Scan([]byte(stored)))
+		assert.Equal(t, EncryptedString("0012345678"), value)
+
+		assert.Nil(t, value.Scan("plain"))
+		assert.Equal(t, EncryptedString("plain"), value)
+	})
+
+	t.Run("encrypts_times", func(t *testing.T) {
+
+		birth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+		expr := EncryptedTime{birth}.GormValue(ctx, &gorm.DB{})
+
+		var value EncryptedTime
+		assert.Nil(t, value.Scan(expr.Vars[0]))
+		assert.True(t, birth.Equal(value.Time))
+
+		assert.Nil(t, value.Scan("1990-05-01 00:00:00+00"))
+		assert.True(t, birth.Equal(value.Time))
+	})
+
+	t.Run("fails_without_tenant", func(t *testing.T) {
+
+		db := &gorm.DB{}
+		EncryptedString("0012345678").GormValue(context.Background(), db)
+		assert.Equal(t, MissingTenantErr, db.Error)
+	})
+
+	t.Run("indexes_documents", func(t *testing.T) {
+
+		first, _ := DocumentIndex(ctx, "001-234-5678")
+		second, _ := DocumentIndex(ctx, "0012345678")
+		assert.Equal(t, first, second)
+		assert.Len(t, first, 64)
+	})
+}
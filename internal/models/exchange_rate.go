@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"github.com/asaskevich/govalidator"
+	"io"
+	"math"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/internal_errors/message_keys"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidExchangeRatesFileErr = errors.New(message_keys.InvalidExchangeRatesFile)
+	DuplicateExchangeRateErr    = errors.New(message_keys.DuplicateExchangeRate)
+
+	exchangeRatesCsvHeader = []string{"from", "to", "rate", "effective_date"}
+)
+
+// ExchangeRate is the rate which converts one unit of FromCurrency to ToCurrency, a rate is effective from
+// its EffectiveDate until the next rate of the same currency pair.
+type ExchangeRate struct {
+	BaseModel
+	FromCurrencyId uint64     `json:"from_currency_id" valid:"required" gorm:"uniqueIndex:exchange_rates_pair_date"`
+	FromCurrency   *Currency  `json:"from_currency" valid:"-" gorm:"foreignKey:FromCurrencyId;references:id"`
+	ToCurrencyId   uint64     `json:"to_currency_id" valid:"required" gorm:"uniqueIndex:exchange_rates_pair_date"`
+	ToCurrency     *Currency  `json:"to_currency" valid:"-" gorm:"foreignKey:ToCurrencyId;references:id"`
+	Rate           float64    `json:"rate" valid:"required"`
+	EffectiveDate  *time.Time `json:"effective_date" valid:"required" gorm:"uniqueIndex:exchange_rates_pair_date"`
+}
+
+func (e *ExchangeRate) Validate() (bool, error) {
+
+	return govalidator.ValidateStruct(e)
+}
+
+func (e *ExchangeRate) SetAudit(username string) {
+	e.CreatedBy = username
+	e.UpdatedBy = username
+}
+
+func (e *ExchangeRate) SetUpdatedBy(username string) {
+	e.UpdatedBy = username
+}
+
+// ConvertAmount converts amount by given exchange rate and rounds it to cents.
+func ConvertAmount(amount float64, rate float64) float64 {
+
+	return math.Round(amount*rate*100) / 100
+}
+
+// ExchangeRateCsvRow is a row of exchange rates csv file, currencies are referenced by their upper case ISO codes.
+type ExchangeRateCsvRow struct {
+	FromCurrencyCode string
+	ToCurrencyCode   string
+	Rate             float64
+	EffectiveDate    time.Time
+}
+
+// ParseExchangeRatesCsv reads rows of exchange rates csv with from,to,rate,effective_date columns, the first row
+// must be the header and dates are in YYYY-MM-DD format. It returns DuplicateExchangeRateErr if a currency pair has
+// more than one rate for an effective date.
+func ParseExchangeRatesCsv(reader io.Reader) ([]*ExchangeRateCsvRow, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = len(exchangeRatesCsvHeader)
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return nil, InvalidExchangeRatesFileErr
+	}
+
+	for i, column := range exchangeRatesCsvHeader {
+		if strings.ToLower(strings.TrimSpace(records[0][i])) != column {
+			return nil, InvalidExchangeRatesFileErr
+		}
+	}
+
+	rows := make([]*ExchangeRateCsvRow, 0, len(records)-1)
+	seen := make(map[string]bool)
+
+	for _, record := range records[1:] {
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil || rate <= 0 {
+			return nil, InvalidExchangeRatesFileErr
+		}
+
+		effectiveDate, err := time.Parse(date_utils.DateLayout, strings.TrimSpace(record[3]))
+		if err != nil {
+			return nil, InvalidExchangeRatesFileErr
+		}
+
+		row := &ExchangeRateCsvRow{
+			FromCurrencyCode: strings.ToUpper(strings.TrimSpace(record[0])),
+			ToCurrencyCode:   strings.ToUpper(strings.TrimSpace(record[1])),
+			Rate:             rate,
+			EffectiveDate:    effectiveDate,
+		}
+
+		// rates of a pair and date are one row in the database, so they can not be imported twice at once.
+		key := row.FromCurrencyCode + "/" + row.ToCurrencyCode + "/" + effectiveDate.Format(date_utils.DateLayout)
+		if seen[key] {
+			return nil, DuplicateExchangeRateErr
+		}
+		seen[key] = true
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reservation-api/internal/utils/date_utils"
+	"strings"
+	"testing"
+)
+
+func TestConvertAmount(t *testing.T) {
+
+	assert.Equal(t, 92.0, ConvertAmount(100, 0.92))
+	assert.Equal(t, 33.33, ConvertAmount(100, 1.0/3))
+	assert.Equal(t, 4500000.0, ConvertAmount(100, 45000))
+}
+
+func TestParseExchangeRatesCsv(t *testing.T) {
+
+	file := "from,to,rate,effective_date\nusd, eur, 0.92, 2023-05-01\nEUR,IRR,45000,2023-05-01\n"
+
+	rows, err := ParseExchangeRatesCsv(strings.NewReader(file))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "USD", rows[0].FromCurrencyCode)
+	assert.Equal(t, "EUR", rows[0].ToCurrencyCode)
+	assert.Equal(t, 0.92, rows[0].Rate)
+	assert.Equal(t, "2023-05-01", rows[0].EffectiveDate.Format(date_utils.DateLayout))
+}
+
+func TestParseExchangeRatesCsvInvalidFile(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		file string
+	}{
+		{name: "empty", file: ""},
+		{name: "wrong_header", file: "a,b,c,d\nUSD,EUR,0.9,2023-05-01\n"},
+		{name: "missing_column", file: "from,to,rate,effective_date\nUSD,EUR,0.9\n"},
+		{name: "invalid_rate", file: "from,to,rate,effective_date\nUSD,EUR,abc,2023-05-01\n"},
+		{name: "negative_rate", file: "from,to,rate,effective_date\nUSD,EUR,-1,2023-05-01\n"},
+		{name: "invalid_date", file: "from,to,rate,effective_date\nUSD,EUR,0.9,01/05/2023\n"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := ParseExchangeRatesCsv(strings.NewReader(testCase.file))
+			assert.Equal(t, InvalidExchangeRatesFileErr, err)
+		})
+	}
+}
+
+func TestParseExchangeRatesCsvDuplicateRate(t *testing.T) {
+
+	file := "from,to,rate,effective_date\nUSD,EUR,0.92,2023-05-01\nusd,eur,0.93,2023-05-01\n"
+
+	_, err := ParseExchangeRatesCsv(strings.NewReader(file))
+	assert.Equal(t, DuplicateExchangeRateErr, err)
+
+	file = "from,to,rate,effective_date\nUSD,EUR,0.92,2023-05-01\nUSD,EUR,0.93,2023-05-02\nEUR,USD,1.08,2023-05-01\n"
+
+	rows, err := ParseExchangeRatesCsv(strings.NewReader(file))
+	assert.Nil(t, err)
+	assert.Len(t, rows, 3)
+}
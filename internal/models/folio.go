@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+var (
+	FolioSettledErr       = errors.New(message_keys.FolioSettled)
+	FolioNotBalancedErr   = errors.New(message_keys.FolioNotBalanced)
+	InvalidFolioEntryErr  = errors.New(message_keys.InvalidFolioEntry)
+	FolioEntryReversedErr = errors.New(message_keys.FolioEntryReversed)
+)
+
+// balanceTolerance ignores rounding remainders of folio balances.
+const balanceTolerance = 0.005
+
+type FolioStatus int
+
+const (
+	FolioOpen FolioStatus = iota
+	// FolioSettled folios are closed with zero balance, nothing can be posted to them anymore.
+	FolioSettled
+)
+
+type FolioEntryType int
+
+const (
+	RoomChargeEntry FolioEntryType = iota
+	TaxEntry
+	AddOnEntry
+	PaymentEntry
+	RefundEntry
+	AdjustmentEntry
+)
+
+// IsCharge reports whether entries of the type are posted from price of the reservation,
+// they are corrected by repricing the reservation and can not be posted by hand.
+func (t FolioEntryType) IsCharge() bool {
+	return t == RoomChargeEntry || t == TaxEntry || t == AddOnEntry
+}
+
+// Folio is a guest account of a reservation, a reservation can have more than one folio to split its bill
+// between payers, e.g. room charges on the company folio and add-ons on the guest folio.
+// Balance is sum of debits minus credits of its entries, a positive balance is owed by the payer.
+type Folio struct {
+	BaseModel
+	ReservationId uint64        `json:"reservation_id" valid:"required" gorm:"index"`
+	Name          string        `json:"name" gorm:"type:varchar(255)"`
+	PayerId       *uint64       `json:"payer_id"`
+	Status        FolioStatus   `json:"status"`
+	Balance       float64       `json:"balance"`
+	SettledAt     *time.Time    `json:"settled_at"`
+	Entries       []*FolioEntry `json:"entries,omitempty" gorm:"foreignKey:FolioId;references:id"`
+}
+
+func (f *Folio) SetAudit(username string) {
+	f.CreatedBy = username
+	f.UpdatedBy = username
+}
+
+func (f *Folio) SetUpdatedBy(username string) {
+	f.UpdatedBy = username
+}
+
+// FolioEntry is an immutable posting of a folio, either Debit (charges and refunds) or Credit (payments) is set.
+// Entries are never updated or deleted, they are corrected by a reversal entry which posts the same amount
+// on the other side. Balance is the running balance of the folio after the entry.
+type FolioEntry struct {
+	BaseModel
+	FolioId       uint64         `json:"folio_id" gorm:"index"`
+	ReservationId uint64         `json:"reservation_id" gorm:"index"`
+	EntryType     FolioEntryType `json:"entry_type"`
+	Description   string         `json:"description" gorm:"type:varchar(255)"`
+	Date          *time.Time     `json:"date"`
+	Debit         float64        `json:"debit"`
+	Credit        float64        `json:"credit"`
+	Balance       float64        `json:"balance"`
+	// ReversalOfId is the entry which is cancelled by this entry, an entry can be reversed only once.
+	ReversalOfId *uint64 `json:"reversal_of_id" gorm:"uniqueIndex"`
+	PaymentId    *uint64 `json:"payment_id" gorm:"index"`
+}
+
+// Post adds the entry to the folio and moves the folio balance, amounts are rounded to cents.
+// It returns FolioSettledErr if the folio is settled and InvalidFolioEntryErr if the entry
+// does not have exactly one positive side.
+func (f *Folio) Post(entry *FolioEntry, now time.Time) error {
+
+	if f.Status == FolioSettled {
+		return FolioSettledErr
+	}
+
+	entry.Debit = math.Round(entry.Debit*100) / 100
+	entry.Credit = math.Round(entry.Credit*100) / 100
+
+	if entry.Debit < 0 || entry.Credit < 0 || (entry.Debit == 0) == (entry.Credit == 0) {
+		return InvalidFolioEntryErr
+	}
+
+	if entry.Date == nil {
+		entry.Date = &now
+	}
+
+	f.Balance = math.Round((f.Balance+entry.Debit-entry.Credit)*100) / 100
+
+	entry.Id = 0
+	entry.FolioId = f.Id
+	entry.ReservationId = f.ReservationId
+	entry.Balance = f.Balance
+
+	return nil
+}
+
+// Reversal returns an entry which cancels the entry, it returns FolioEntryReversedErr
+// if the entry is a reversal itself.
+func (e *FolioEntry) Reversal() (*FolioEntry, error) {
+
+	if e.ReversalOfId != nil {
+		return nil, FolioEntryReversedErr
+	}
+
+	id := e.Id
+	return &FolioEntry{
+		EntryType:    e.EntryType,
+		Description:  fmt.Sprintf("reversal of #%d %s", e.Id, e.Description),
+		Date:         e.Date,
+		Debit:        e.Credit,
+		Credit:       e.Debit,
+		ReversalOfId: &id,
+		PaymentId:    e.PaymentId,
+	}, nil
+}
+
+// Settle closes the folio, it returns FolioNotBalancedErr if the folio has balance
+// and FolioSettledErr if it is already settled.
+func (f *Folio) Settle(now time.Time) error {
+
+	if f.Status == FolioSettled {
+		return FolioSettledErr
+	}
+
+	if math.Abs(f.Balance) > balanceTolerance {
+		return FolioNotBalancedErr
+	}
+
+	f.Status = FolioSettled
+	f.SettledAt = &now
+
+	return nil
+}
+
+// IsFolioErr reports whether err is a rejection of a folio operation.
+func IsFolioErr(err error) bool {
+	return errors.Is(err, FolioSettledErr) || errors.Is(err, FolioNotBalancedErr) ||
+		errors.Is(err, InvalidFolioEntryErr) || errors.Is(err, FolioEntryReversedErr)
+}
+
+// ReservationCharges returns folio entries of price of the reservation: a room charge per night,
+// its discount, taxes and add-ons which are not included in the room price.
+func ReservationCharges(reservation *Reservation) []*FolioEntry {
+
+	entries := make([]*FolioEntry, 0, len(reservation.NightPrices)+len(reservation.Taxes)+len(reservation.AddOns)+1)
+
+	for _, night := range reservation.NightPrices {
+		if night.Price > 0 {
+			entries = append(entries, &FolioEntry{EntryType: RoomChargeEntry, Description: "room charge",
+				Date: night.Date, Debit: night.Price})
+		}
+	}
+
+	if reservation.DiscountAmount > 0 {
+		entries = append(entries, &FolioEntry{EntryType: RoomChargeEntry,
+			Description: fmt.Sprintf("discount %s", reservation.PromoCode), Credit: reservation.DiscountAmount})
+	}
+
+	for _, tax := range reservation.Taxes {
+		if tax.Amount > 0 {
+			entries = append(entries, &FolioEntry{EntryType: TaxEntry, Description: tax.Name, Debit: tax.Amount})
+		}
+	}
+
+	for _, addOn := range reservation.AddOns {
+		if addOn.Amount > 0 {
+			entries = append(entries, &FolioEntry{EntryType: AddOnEntry, Description: fmt.Sprintf("add-on #%d", addOn.AddOnId),
+				Date: addOn.DateStart, Debit: addOn.Amount})
+		}
+	}
+
+	return entries
+}
+
+// NetFolioEntries returns entries which are not reversed, reversed entries and their reversals are left out.
+func NetFolioEntries(entries []*FolioEntry) []*FolioEntry {
+
+	reversed := make(map[uint64]bool)
+	for _, entry := range entries {
+		if entry.ReversalOfId != nil {
+			reversed[*entry.ReversalOfId] = true
+		}
+	}
+
+	result := make([]*FolioEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ReversalOfId == nil && !reversed[entry.Id] {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFolioPost(t *testing.T) {
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	folio := &Folio{BaseModel: BaseModel{Id: 3}, ReservationId: 7}
+
+	charge := &FolioEntry{EntryType: RoomChargeEntry, Debit: 100.004}
+	assert.Nil(t, folio.Post(charge, now))
+	assert.Equal(t, 100.0, charge.Debit)
+	assert.Equal(t, 100.0, charge.Balance)
+	assert.Equal(t, uint64(3), charge.FolioId)
+	assert.Equal(t, uint64(7), charge.ReservationId)
+	assert.Equal(t, &now, charge.Date)
+
+	payment := &FolioEntry{EntryType: PaymentEntry, Credit: 40}
+	assert.Nil(t, folio.Post(payment, now))
+	assert.Equal(t, 60.0, payment.Balance)
+	assert.Equal(t, 60.0, folio.Balance)
+
+	assert.Equal(t, InvalidFolioEntryErr, folio.Post(&FolioEntry{Debit: 10, Credit: 10}, now))
+	assert.Equal(t, InvalidFolioEntryErr, folio.Post(&FolioEntry{}, now))
+	assert.Equal(t, InvalidFolioEntryErr, folio.Post(&FolioEntry{Debit: -5}, now))
+	assert.Equal(t, 60.0, folio.Balance)
+
+	assert.Equal(t, FolioNotBalancedErr, folio.Settle(now))
+	assert.Nil(t, folio.Post(&FolioEntry{EntryType: PaymentEntry, Credit: 60}, now))
+	assert.Nil(t, folio.Settle(now))
+	assert.Equal(t, FolioSettled, folio.Status)
+	assert.Equal(t, FolioSettledErr, folio.Post(&FolioEntry{Debit: 1}, now))
+	assert.Equal(t, FolioSettledErr, folio.Settle(now))
+}
+
+func TestFolioEntryReversal(t *testing.T) {
+
+	entry := &FolioEntry{BaseModel: BaseModel{Id: 9}, EntryType: TaxEntry, Description: "vat", Debit: 12.5}
+
+	reversal, err := entry.Reversal()
+	assert.Nil(t, err)
+	assert.Equal(t, 12.5, reversal.Credit)
+	assert.Equal(t, 0.0, reversal.Debit)
+	assert.Equal(t, TaxEntry, reversal.EntryType)
+	assert.Equal(t, uint64(9), *reversal.ReversalOfId)
+
+	_, err = reversal.Reversal()
+	assert.Equal(t, FolioEntryReversedErr, err)
+}
+
+func TestReservationCharges(t *testing.T) {
+
+	first := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+
+	reservation := &Reservation{
+		NightPrices:    []*ReservationNight{{Date: &first, Price: 100}, {Date: &second, Price: 120}},
+		PromoCode:      "SPRING",
+		DiscountAmount: 22,
+		Taxes:          []*ReservationTax{{Name: "vat", Amount: 19.8}, {Name: "city", Amount: 0}},
+		AddOns:         []*ReservationAddOn{{AddOnId: 4, Amount: 30}, {AddOnId: 5, Included: true}},
+	}
+
+	charges := ReservationCharges(reservation)
+
+	assert.Len(t, charges, 5)
+	balance := 0.0
+	for _, charge := range charges {
+		balance += charge.Debit - charge.Credit
+	}
+	assert.InDelta(t, 247.8, balance, 0.0001)
+	assert.Equal(t, 22.0, charges[2].Credit)
+	assert.Equal(t, TaxEntry, charges[3].EntryType)
+	assert.Equal(t, AddOnEntry, charges[4].EntryType)
+}
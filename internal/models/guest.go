@@ -1,27 +1,32 @@
 package models
 
 import (
+	"context"
 	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Guest struct {
 	BaseModel
-	Country            *Country   `json:"country" valid:"-"`
-	CountryId          uint64     `json:"country_id" valid:"required"`
-	Gender             Gender     `json:"gender" valid:"required"`
-	FirstName          string     `json:"first_name" valid:"required"  gorm:"type:varchar(255)"`
-	MiddleName         string     `json:"middle_name"  gorm:"type:varchar(255)"`
-	LastName           string     `json:"last_name" valid:"required"  gorm:"type:varchar(255)"`
-	NationalId         string     `json:"national_id" valid:"required"  gorm:"type:varchar(255)"`
-	CellNumber         string     `json:"cell_number" valid:"required"  gorm:"type:varchar(20)"`
-	PhoneNumber        string     `json:"phone_number"  gorm:"type:varchar(20)"`
-	PassportNumber     string     `json:"passport_number"  gorm:"type:varchar(50)"`
-	PassportIssueDate  string     `json:"passport_date_of_issue"`
-	PassportExpireDate string     `json:"passport_expire_date"`
-	Email              string     `json:"email" valid:"email"  gorm:"type:varchar(255)"`
-	DateOfBirth        *time.Time `json:"date_of_birth"`
-	Address            string     `json:"address" valid:"required"`
+	Country            *Country        `json:"country" valid:"-"`
+	CountryId          uint64          `json:"country_id" valid:"required"`
+	Gender             Gender          `json:"gender" valid:"required"`
+	FirstName          string          `json:"first_name" valid:"required"  gorm:"type:varchar(255)"`
+	MiddleName         string          `json:"middle_name"  gorm:"type:varchar(255)"`
+	LastName           string          `json:"last_name" valid:"required"  gorm:"type:varchar(255)"`
+	NationalId         EncryptedString `json:"national_id" valid:"required"  gorm:"type:text"`
+	NationalIdIndex    string          `json:"-" gorm:"type:varchar(64);index"`
+	CellNumber         string          `json:"cell_number" valid:"required"  gorm:"type:varchar(20)"`
+	PhoneNumber        string          `json:"phone_number"  gorm:"type:varchar(20)"`
+	PassportNumber     EncryptedString `json:"passport_number"  gorm:"type:text"`
+	PassportIndex      string          `json:"-" gorm:"type:varchar(64);index"`
+	PassportIssueDate  string          `json:"passport_date_of_issue"`
+	PassportExpireDate string          `json:"passport_expire_date"`
+	Email              string          `json:"email" valid:"email"  gorm:"type:varchar(255)"`
+	DateOfBirth        *EncryptedTime  `json:"date_of_birth" gorm:"type:text"`
+	Address            string          `json:"address" valid:"required"`
+	AnonymizedAt       *time.Time      `json:"anonymized_at"`
 }
 
 // Validate validates guest model.
@@ -38,3 +43,21 @@ func (g *Guest) SetAudit(username string) {
 func (g *Guest) SetUpdatedBy(username string) {
 	g.UpdatedBy = username
 }
+
+// BeforeSave sets blind indexes of identity documents of the guest, documents are encrypted so they are found
+// by their indexes.
+func (g *Guest) BeforeSave(tx *gorm.DB) (err error) {
+
+	if g.NationalIdIndex, err = DocumentIndex(tx.Statement.Context, string(g.NationalId)); err != nil {
+		return err
+	}
+
+	g.PassportIndex, err = DocumentIndex(tx.Statement.Context, string(g.PassportNumber))
+	return err
+}
+
+// DocumentIndex returns the blind index of a national id or a passport number, documents which differ only in case,
+// spaces or dashes have the same index.
+func DocumentIndex(ctx context.Context, document string) (string, error) {
+	return BlindIndex(ctx, normalizeDocument(document))
+}
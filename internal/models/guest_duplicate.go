@@ -0,0 +1,317 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"reservation-api/internal_errors/message_keys"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	SameGuestMergeErr = errors.New(message_keys.SameGuestMerge)
+)
+
+const (
+	// MaxDuplicateBlockSize skips values which are shared by many guests, like placeholder phone numbers,
+	// they are not evidence of a duplicate and would make comparisons quadratic.
+	MaxDuplicateBlockSize = 50
+	// nameSimilarityThreshold is the minimum Jaro-Winkler similarity of names which are scored as the same name.
+	nameSimilarityThreshold = 0.88
+)
+
+// GuestDuplicate is a pair of guest profiles which probably belong to the same person. Score is 0 to 100 and
+// Reasons are the fields which match.
+type GuestDuplicate struct {
+	Guest     *Guest   `json:"guest"`
+	Duplicate *Guest   `json:"duplicate"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// GuestMergeLog is an audit record of merging a guest profile into a surviving profile. MergedGuest is an encrypted
+// JSON snapshot of the merged profile and MovedReferences has the number of rows of each table which are moved to
+// the survivor.
+type GuestMergeLog struct {
+	BaseModel
+	SurvivorId      uint64          `json:"survivor_id" gorm:"index"`
+	MergedId        uint64          `json:"merged_id" gorm:"index"`
+	MergedGuest     EncryptedString `json:"merged_guest" gorm:"type:text"`
+	MovedReferences string          `json:"moved_references" gorm:"type:text"`
+}
+
+// GuestSimilarity scores how probably two guests are the same person and returns the matched fields.
+// Identity documents are strong evidence, contacts are medium evidence and names are weak evidence,
+// the score is capped at 100.
+func GuestSimilarity(a, b *Guest) (float64, []string) {
+
+	score := 0.0
+	reasons := make([]string, 0)
+
+	if key := normalizeDocument(string(a.NationalId)); key != "" && key == normalizeDocument(string(b.NationalId)) {
+		score += 50
+		reasons = append(reasons, "national_id")
+	}
+
+	if key := normalizeDocument(string(a.PassportNumber)); key != "" && key == normalizeDocument(string(b.PassportNumber)) {
+		score += 50
+		reasons = append(reasons, "passport_number")
+	}
+
+	if key := normalizeEmail(a.Email); key != "" && key == normalizeEmail(b.Email) {
+		score += 30
+		reasons = append(reasons, "email")
+	}
+
+	if sharePhone(a, b) {
+		score += 25
+		reasons = append(reasons, "phone")
+	}
+
+	nameA, nameB := normalizeName(a.FirstName+" "+a.LastName), normalizeName(b.FirstName+" "+b.LastName)
+	if similarity := jaroWinkler(nameA, nameB); nameA != "" && similarity >= nameSimilarityThreshold {
+		score += math.Round(20 * similarity)
+		reasons = append(reasons, "name")
+	}
+
+	if a.DateOfBirth != nil && b.DateOfBirth != nil && a.DateOfBirth.Format("2006-01-02") == b.DateOfBirth.Format("2006-01-02") {
+		score += 10
+		reasons = append(reasons, "date_of_birth")
+	}
+
+	return math.Min(score, 100), reasons
+}
+
+// FindGuestDuplicates returns pairs of guests which score at least minScore, the most probable first. Only guests which
+// share a document, an email, a phone or a name key are compared. Pairs are limited to guestId if it is not zero.
+func FindGuestDuplicates(guests []*Guest, minScore float64, guestId uint64) []*GuestDuplicate {
+
+	blocks := make(map[string][]*Guest)
+	for _, guest := range guests {
+		for _, key := range duplicateBlockKeys(guest) {
+			blocks[key] = append(blocks[key], guest)
+		}
+	}
+
+	compared := make(map[[2]uint64]bool)
+	result := make([]*GuestDuplicate, 0)
+
+	for _, block := range blocks {
+
+		if len(block) < 2 || len(block) > MaxDuplicateBlockSize {
+			continue
+		}
+
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+
+				a, b := block[i], block[j]
+				if a.Id > b.Id {
+					a, b = b, a
+				}
+
+				pair := [2]uint64{a.Id, b.Id}
+				if a.Id == b.Id || compared[pair] || (guestId != 0 && a.Id != guestId && b.Id != guestId) {
+					continue
+				}
+				compared[pair] = true
+
+				if score, reasons := GuestSimilarity(a, b); score >= minScore {
+					result = append(result, &GuestDuplicate{Guest: a, Duplicate: b, Score: score, Reasons: reasons})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Guest.Id < result[j].Guest.Id ||
+			(result[i].Guest.Id == result[j].Guest.Id && result[i].Duplicate.Id < result[j].Duplicate.Id)
+	})
+
+	return result
+}
+
+// FillFrom copies fields of the merged guest which the guest does not have, fields of the guest are kept.
+func (g *Guest) FillFrom(merged *Guest) {
+
+	fill := func(field *string, value string) {
+		if strings.TrimSpace(*field) == "" {
+			*field = value
+		}
+	}
+
+	fill(&g.MiddleName, merged.MiddleName)
+	fill(&g.CellNumber, merged.CellNumber)
+	fill(&g.PhoneNumber, merged.PhoneNumber)
+	fill(&g.PassportIssueDate, merged.PassportIssueDate)
+	fill(&g.PassportExpireDate, merged.PassportExpireDate)
+	fill(&g.Email, merged.Email)
+	fill(&g.Address, merged.Address)
+
+	if strings.TrimSpace(string(g.NationalId)) == "" {
+		g.NationalId = merged.NationalId
+	}
+
+	if strings.TrimSpace(string(g.PassportNumber)) == "" {
+		g.PassportNumber = merged.PassportNumber
+	}
+
+	if g.DateOfBirth == nil {
+		g.DateOfBirth = merged.DateOfBirth
+	}
+}
+
+// duplicateBlockKeys returns keys which candidate duplicates of the guest share. Repositories select candidates
+// by the same keys, so they must be changed together.
+func duplicateBlockKeys(guest *Guest) []string {
+
+	keys := make([]string, 0, 6)
+
+	if key := normalizeDocument(string(guest.NationalId)); key != "" {
+		keys = append(keys, "national_id:"+key)
+	}
+	if key := normalizeDocument(string(guest.PassportNumber)); key != "" {
+		keys = append(keys, "passport:"+key)
+	}
+	if key := normalizeEmail(guest.Email); key != "" {
+		keys = append(keys, "email:"+key)
+	}
+	for _, phone := range []string{guest.CellNumber, guest.PhoneNumber} {
+		if key := normalizePhone(phone); key != "" {
+			keys = append(keys, "phone:"+key)
+		}
+	}
+
+	// names are blocked by the last name prefix and the first letter of the first name, so small typos at the end
+	// of names still meet. Spaces are removed, so names with and without spaces meet too.
+	first := []rune(strings.ReplaceAll(normalizeName(guest.FirstName), " ", ""))
+	last := []rune(strings.ReplaceAll(normalizeName(guest.LastName), " ", ""))
+	if len(first) > 0 && len(last) >= 3 {
+		keys = append(keys, "name:"+string(last[:3])+string(first[0]))
+	}
+
+	return keys
+}
+
+func sharePhone(a, b *Guest) bool {
+
+	for _, x := range []string{a.CellNumber, a.PhoneNumber} {
+		for _, y := range []string{b.CellNumber, b.PhoneNumber} {
+			if key := normalizePhone(x); key != "" && key == normalizePhone(y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// normalizeDocument returns upper case letters and digits of a document number.
+func normalizeDocument(value string) string {
+
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, value))
+}
+
+func normalizeEmail(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// normalizePhone returns the last 10 digits of a phone number, so numbers with and without country code match.
+// Numbers shorter than 7 digits are ignored.
+func normalizePhone(value string) string {
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+
+	if len(digits) < 7 {
+		return ""
+	}
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+
+	return digits
+}
+
+// normalizeName returns lower case words of a name separated by one space.
+func normalizeName(value string) string {
+
+	return strings.Join(strings.Fields(strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return ' '
+	}, value))), " ")
+}
+
+// jaroWinkler returns Jaro-Winkler similarity of two strings, 1 is equal and 0 has nothing in common.
+func jaroWinkler(a, b string) float64 {
+
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	window := int(math.Max(float64(len(s)), float64(len(t))))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	sMatches, tMatches := make([]bool, len(s)), make([]bool, len(t))
+	matches := 0
+
+	for i := range s {
+		start, end := int(math.Max(0, float64(i-window))), int(math.Min(float64(len(t)), float64(i+window+1)))
+		for j := start; j < end; j++ {
+			if !tMatches[j] && s[i] == t[j] {
+				sMatches[i], tMatches[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range s {
+		if !sMatches[i] {
+			continue
+		}
+		for !tMatches[k] {
+			k++
+		}
+		if s[i] != t[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < 4 && prefix < len(s) && prefix < len(t) && s[prefix] == t[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGuestSimilarity(t *testing.T) {
+
+	birth := EncryptedTime{time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)}
+	a := &Guest{FirstName: "Ali", LastName: "Rezaei", NationalId: "001-234-5678", CellNumber: "+98 912 123 4567",
+		Email: "Ali@Example.com", DateOfBirth: &birth}
+	b := &Guest{FirstName: "ali", LastName: "Rezaie", NationalId: "0012345678", CellNumber: "09121234567",
+		Email: "ali@example.com ", DateOfBirth: &birth}
+
+	score, reasons := GuestSimilarity(a, b)
+	assert.Equal(t, float64(100), score)
+	assert.Equal(t, []string{"national_id", "email", "phone", "name", "date_of_birth"}, reasons)
+
+	score, reasons = GuestSimilarity(a, &Guest{FirstName: "Sara", LastName: "Ahmadi", CellNumber: "12345"})
+	assert.Equal(t, float64(0), score)
+	assert.Empty(t, reasons)
+
+	assert.Equal(t, float64(1), jaroWinkler("martha", "martha"))
+	assert.InDelta(t, 0.961, jaroWinkler("martha", "marhta"), 0.001)
+	assert.Equal(t, float64(0), jaroWinkler("abc", ""))
+}
+
+func TestFindGuestDuplicates(t *testing.T) {
+
+	guests := []*Guest{
+		{BaseModel: BaseModel{Id: 1}, FirstName: "John", LastName: "Smith", PassportNumber: "X1234567"},
+		{BaseModel: BaseModel{Id: 2}, FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"},
+		{BaseModel: BaseModel{Id: 3}, FirstName: "Jon", LastName: "Smith", PassportNumber: "x 1234567"},
+		{BaseModel: BaseModel{Id: 4}, FirstName: "Janet", LastName: "Doe", Email: "JANE@example.com", CellNumber: "5551234567"},
+		{BaseModel: BaseModel{Id: 5}, FirstName: "Peter", LastName: "Parker", CellNumber: "5551234567"},
+	}
+
+	duplicates := FindGuestDuplicates(guests, 50, 0)
+	assert.Len(t, duplicates, 2)
+	assert.Equal(t, uint64(1), duplicates[0].Guest.Id)
+	assert.Equal(t, uint64(3), duplicates[0].Duplicate.Id)
+	assert.Equal(t, uint64(2), duplicates[1].Guest.Id)
+	assert.Equal(t, uint64(4), duplicates[1].Duplicate.Id)
+	assert.GreaterOrEqual(t, duplicates[0].Score, duplicates[1].Score)
+
+	duplicates = FindGuestDuplicates(guests, 20, 5)
+	assert.Len(t, duplicates, 1)
+	assert.Equal(t, uint64(4), duplicates[0].Guest.Id)
+	assert.Equal(t, []string{"phone"}, duplicates[0].Reasons)
+}
+
+func TestGuestFillFrom(t *testing.T) {
+
+	survivor := &Guest{FirstName: "Ali", Email: "ali@example.com", PassportNumber: " "}
+	survivor.FillFrom(&Guest{FirstName: "Aly", Email: "other@example.com", PassportNumber: "P123", Address: "Tehran"})
+
+	assert.Equal(t, "Ali", survivor.FirstName)
+	assert.Equal(t, "ali@example.com", survivor.Email)
+	assert.Equal(t, EncryptedString("P123"), survivor.PassportNumber)
+	assert.Equal(t, "Tehran", survivor.Address)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// AnonymizedGuestName is the first name of anonymized guests, their last name is a random pseudonym.
+const AnonymizedGuestName = "Anonymized"
+
+// GuestEmail is an email which is sent to a guest, it is a part of data which is exported for the guest.
+type GuestEmail struct {
+	BaseModel
+	GuestId       uint64     `json:"guest_id" gorm:"index"`
+	ReservationId uint64     `json:"reservation_id"`
+	Recipient     string     `json:"recipient" gorm:"type:varchar(255)"`
+	Subject       string     `json:"subject" gorm:"type:varchar(255)"`
+	Body          string     `json:"body" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// RetentionPolicy holds how long profiles of guests are kept, profiles of guests who have not stayed for
+// GuestRetentionDays are anonymized. Guests are never anonymized automatically if GuestRetentionDays is zero.
+type RetentionPolicy struct {
+	BaseModel
+	GuestRetentionDays uint `json:"guest_retention_days"`
+}
+
+func (p *RetentionPolicy) SetAudit(username string) {
+	p.CreatedBy = username
+	p.UpdatedBy = username
+}
+
+func (p *RetentionPolicy) SetUpdatedBy(username string) {
+	p.UpdatedBy = username
+}
+
+// GuestCutoff returns the time which guests who have not stayed after it are anonymized, ok is false if guests
+// are not anonymized automatically.
+func (p *RetentionPolicy) GuestCutoff(now time.Time) (cutoff time.Time, ok bool) {
+
+	if p == nil || p.GuestRetentionDays == 0 {
+		return time.Time{}, false
+	}
+
+	return now.AddDate(0, 0, -int(p.GuestRetentionDays)), true
+}
+
+// IsAnonymized reports whether personal data of the guest is erased.
+func (g *Guest) IsAnonymized() bool {
+	return g.AnonymizedAt != nil
+}
+
+// Anonymize irreversibly replaces personal data of the guest with the pseudonym. Country and gender are kept for
+// statistics, they do not identify the guest alone.
+func (g *Guest) Anonymize(pseudonym string, now time.Time) {
+
+	g.FirstName = AnonymizedGuestName
+	g.MiddleName = ""
+	g.LastName = pseudonym
+	g.NationalId = EncryptedString(pseudonym)
+	g.CellNumber = ""
+	g.PhoneNumber = ""
+	g.PassportNumber = ""
+	g.PassportIssueDate = ""
+	g.PassportExpireDate = ""
+	g.Email = ""
+	g.DateOfBirth = nil
+	g.Address = ""
+	g.AnonymizedAt = &now
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGuestAnonymize(t *testing.T) {
+
+	birth := EncryptedTime{time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)}
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	guest := &Guest{CountryId: 1, Gender: "Female", FirstName: "Sara", LastName: "Ahmadi", NationalId: "0012345678",
+		CellNumber: "09121234567", Email: "sara@example.com", PassportNumber: "P123", DateOfBirth: &birth, Address: "Tehran"}
+
+	guest.Anonymize("anon-1a2b", now)
+
+	assert.True(t, guest.IsAnonymized())
+	assert.Equal(t, AnonymizedGuestName, guest.FirstName)
+	assert.Equal(t, "anon-1a2b", guest.LastName)
+	assert.Equal(t, EncryptedString("anon-1a2b"), guest.NationalId)
+	assert.Empty(t, guest.CellNumber+guest.Email+string(guest.PassportNumber)+guest.Address)
+	assert.Nil(t, guest.DateOfBirth)
+	assert.Equal(t, uint64(1), guest.CountryId)
+
+	_, ok := (&RetentionPolicy{}).GuestCutoff(now)
+	assert.False(t, ok)
+	cutoff, ok := (&RetentionPolicy{GuestRetentionDays: 365}).GuestCutoff(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), cutoff)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/asaskevich/govalidator"
 	"os"
+	"time"
 )
 
 type Hotel struct {
@@ -30,6 +31,17 @@ type Hotel struct {
 	HotelGradeId uint64      `json:"hotel_grade_id" gorm:"foreignKey:HotelGrade" valid:"required"`
 	Thumbnails   []*os.File  `json:"thumbnails" gorm:"-"`
 	ExtraData    string      `json:"extra_data"`
+	// Logo is file name of the hotel logo in hotels bucket, it is printed on invoices.
+	Logo string `json:"logo" gorm:"type:varchar(255)"`
+	// NoShowCutoffHour is the hour of the day after arrival when not checked-in reservations are marked as no-show.
+	NoShowCutoffHour uint `json:"no_show_cutoff_hour" valid:"range(0|23)"`
+}
+
+// NoShowCutoff returns the time after which a reservation arriving at checkinDate is a no-show.
+func (h *Hotel) NoShowCutoff(checkinDate time.Time) time.Time {
+
+	arrivalDay := time.Date(checkinDate.Year(), checkinDate.Month(), checkinDate.Day(), 0, 0, 0, 0, checkinDate.Location())
+	return arrivalDay.AddDate(0, 0, 1).Add(time.Duration(h.NoShowCutoffHour) * time.Hour)
 }
 
 func (h *Hotel) Validate() (bool, error) {
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNoShowCutoff(t *testing.T) {
+
+	checkin := time.Date(2023, 1, 10, 14, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC), (&Hotel{}).NoShowCutoff(checkin))
+	assert.Equal(t, time.Date(2023, 1, 11, 6, 0, 0, 0, time.UTC), (&Hotel{NoShowCutoffHour: 6}).NoShowCutoff(checkin))
+}
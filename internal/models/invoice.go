@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Invoice is an issued invoice of a reservation. Number is sequential without gaps per hotel, each tenant has its
+// own database so numbers are sequential per tenant too. The rendered document is stored in BucketName/FileName and
+// it is reissued as it is until new entries are posted to folios of the reservation (entries after LastEntryId).
+type Invoice struct {
+	BaseModel
+	HotelId       uint64     `json:"hotel_id" gorm:"uniqueIndex:invoices_hotel_number_idx"`
+	Number        uint64     `json:"number" gorm:"uniqueIndex:invoices_hotel_number_idx"`
+	InvoiceNo     string     `json:"invoice_no" gorm:"type:varchar(50)"`
+	ReservationId uint64     `json:"reservation_id" gorm:"index"`
+	GuestId       uint64     `json:"guest_id"`
+	IssuedAt      *time.Time `json:"issued_at"`
+	LastEntryId   uint64     `json:"last_entry_id"`
+	TotalCharges  float64    `json:"total_charges"`
+	TotalTaxes    float64    `json:"total_taxes"`
+	TotalPayments float64    `json:"total_payments"`
+	Balance       float64    `json:"balance"`
+	BucketName    string     `json:"bucket_name" gorm:"type:varchar(255)"`
+	FileName      string     `json:"file_name" gorm:"type:varchar(255)"`
+	VersionID     string     `json:"version_id" gorm:"type:varchar(255)"`
+}
+
+// InvoiceSequence holds the last invoice number of a hotel.
+type InvoiceSequence struct {
+	BaseModel
+	HotelId    uint64 `json:"hotel_id" gorm:"uniqueIndex"`
+	LastNumber uint64 `json:"last_number"`
+}
+
+func (s *InvoiceSequence) SetAudit(username string) {
+	s.CreatedBy = username
+	s.UpdatedBy = username
+}
+
+// FormatInvoiceNumber returns printed number of an invoice of the hotel.
+func FormatInvoiceNumber(hotelId uint64, number uint64) string {
+	return fmt.Sprintf("INV-%d-%06d", hotelId, number)
+}
+
+// SetTotals sets totals of the invoice by folio entries of the reservation, reversed entries are left out.
+// Refunds reduce payments and the rest of entries except taxes are charges.
+func (i *Invoice) SetTotals(entries []*FolioEntry) {
+
+	i.TotalCharges, i.TotalTaxes, i.TotalPayments = 0, 0, 0
+
+	for _, entry := range NetFolioEntries(entries) {
+
+		amount := entry.Debit - entry.Credit
+
+		switch entry.EntryType {
+		case TaxEntry:
+			i.TotalTaxes += amount
+		case PaymentEntry, RefundEntry:
+			i.TotalPayments -= amount
+		default:
+			i.TotalCharges += amount
+		}
+	}
+
+	i.TotalCharges = math.Round(i.TotalCharges*100) / 100
+	i.TotalTaxes = math.Round(i.TotalTaxes*100) / 100
+	i.TotalPayments = math.Round(i.TotalPayments*100) / 100
+	i.Balance = math.Round((i.TotalCharges+i.TotalTaxes-i.TotalPayments)*100) / 100
+}
+
+func (i *Invoice) SetAudit(username string) {
+	i.CreatedBy = username
+	i.UpdatedBy = username
+}
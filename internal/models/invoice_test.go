@@ -0,0 +1,36 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInvoiceSetTotals(t *testing.T) {
+
+	reversedId := uint64(2)
+	entries := []*FolioEntry{
+		{BaseModel: BaseModel{Id: 1}, EntryType: RoomChargeEntry, Debit: 100},
+		{BaseModel: BaseModel{Id: 2}, EntryType: RoomChargeEntry, Debit: 100},
+		{BaseModel: BaseModel{Id: 3}, EntryType: RoomChargeEntry, Credit: 100, ReversalOfId: &reversedId},
+		{BaseModel: BaseModel{Id: 4}, EntryType: RoomChargeEntry, Debit: 90},
+		{BaseModel: BaseModel{Id: 5}, EntryType: TaxEntry, Debit: 17.1},
+		{BaseModel: BaseModel{Id: 6}, EntryType: AddOnEntry, Debit: 25},
+		{BaseModel: BaseModel{Id: 7}, EntryType: PaymentEntry, Credit: 200},
+		{BaseModel: BaseModel{Id: 8}, EntryType: RefundEntry, Debit: 20},
+	}
+
+	assert.Len(t, NetFolioEntries(entries), 6)
+
+	invoice := &Invoice{}
+	invoice.SetTotals(entries)
+
+	assert.Equal(t, 215.0, invoice.TotalCharges)
+	assert.Equal(t, 17.1, invoice.TotalTaxes)
+	assert.Equal(t, 180.0, invoice.TotalPayments)
+	assert.Equal(t, 52.1, invoice.Balance)
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+
+	assert.Equal(t, "INV-3-000042", FormatInvoiceNumber(3, 42))
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"reservation-api/internal_errors/message_keys"
+)
+
+var (
+	NoAdultGuestErr          = errors.New(message_keys.NoAdultGuest)
+	MaxGuestCountExceededErr = errors.New(message_keys.MaxGuestCountExceeded)
+	MaxBedsExceededErr       = errors.New(message_keys.MaxBedsExceeded)
+
+	occupancyErrs = []error{NoAdultGuestErr, MaxGuestCountExceededErr, MaxBedsExceededErr}
+)
+
+// ReservationChild is a child guest of a reservation, ChildAgeBandId is the age band of the hotel which the child
+// is priced by and it is empty if the child is counted as an adult.
+type ReservationChild struct {
+	BaseModel
+	ReservationId  uint64  `json:"reservation_id" gorm:"index"`
+	Age            uint64  `json:"age" valid:"range(0|17)"`
+	ChildAgeBandId *uint64 `json:"child_age_band_id"`
+}
+
+// RateCodeDetailChildPrice is price of a child of an age band per night, children of bands without a price are free.
+type RateCodeDetailChildPrice struct {
+	BaseModel
+	RateCodeDetailId uint64  `json:"rate_code_detail_id" gorm:"index"`
+	ChildAgeBandId   uint64  `json:"child_age_band_id" valid:"required"`
+	Price            float64 `json:"price"`
+}
+
+// CheckOccupancy checks that a stay of given adults, children and extra beds fits in the room, the room type must be
+// loaded. A stay needs at least one adult, all guests are counted against RoomType.MaxGuestCount and extra beds
+// against Room.MaxBeds.
+func CheckOccupancy(room *Room, adults, children, extraBeds uint64) error {
+
+	if adults == 0 {
+		return NoAdultGuestErr
+	}
+
+	if adults+children > room.RoomType.MaxGuestCount {
+		return MaxGuestCountExceededErr
+	}
+
+	if extraBeds > room.MaxBeds {
+		return MaxBedsExceededErr
+	}
+
+	return nil
+}
+
+// IsOccupancyErr reports whether err is an occupancy rejection.
+func IsOccupancyErr(err error) bool {
+
+	for _, occupancyErr := range occupancyErrs {
+		if errors.Is(err, occupancyErr) {
+			return true
+		}
+	}
+
+	return false
+}
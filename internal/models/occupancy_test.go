@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckOccupancy(t *testing.T) {
+
+	room := &Room{MaxBeds: 1, RoomType: RoomType{MaxGuestCount: 3}}
+
+	assert.Nil(t, CheckOccupancy(room, 2, 1, 1))
+	assert.Equal(t, NoAdultGuestErr, CheckOccupancy(room, 0, 2, 0))
+	assert.Equal(t, MaxGuestCountExceededErr, CheckOccupancy(room, 2, 2, 0))
+	assert.Equal(t, MaxBedsExceededErr, CheckOccupancy(room, 1, 0, 2))
+	assert.True(t, IsOccupancyErr(MaxBedsExceededErr))
+}
+
+func TestFindAgeBand(t *testing.T) {
+
+	infant := &ChildAgeBand{Name: "Infant", MinAge: 0, MaxAge: 2}
+	child := &ChildAgeBand{Name: "Child", MinAge: 3, MaxAge: 11}
+	bands := []*ChildAgeBand{infant, child}
+
+	assert.Equal(t, infant, FindAgeBand(bands, 2))
+	assert.Equal(t, child, FindAgeBand(bands, 3))
+	assert.Nil(t, FindAgeBand(bands, 12))
+	assert.True(t, child.Overlaps(&ChildAgeBand{MinAge: 11, MaxAge: 14}))
+	assert.False(t, infant.Overlaps(child))
+}
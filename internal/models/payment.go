@@ -1,6 +1,16 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"math"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+var (
+	RefundExceedsPaymentErr = errors.New(message_keys.RefundExceedsPayment)
+	InvalidRefundErr        = errors.New(message_keys.InvalidRefund)
+)
 
 type PaymentType int
 
@@ -9,6 +19,16 @@ const (
 	CREDIT
 )
 
+type RefundReason int
+
+const (
+	RefundRequestedByGuest RefundReason = iota
+	RefundCancellation
+	RefundDuplicate
+	RefundOverpayment
+	RefundFraudulent
+)
+
 type Payment struct {
 	BaseModel
 	Amount        float64     `json:"amount"`
@@ -18,4 +38,54 @@ type Payment struct {
 	PaymentDate   *time.Time  `json:"payment_date"`
 	Reservation   Reservation `json:"reservation"`
 	ReservationId uint64      `json:"reservation_id"`
+	// GatewayReference is id of the charge in the payment gateway for card payments.
+	GatewayReference string `json:"gateway_reference" gorm:"type:varchar(255)"`
+	// RefundOfId is the payment which is refunded by this payment, refunds have negative amount
+	// and RefundedAmount of the refunded payment is the sum of its refunds.
+	RefundOfId     *uint64      `json:"refund_of_id" gorm:"index"`
+	RefundReason   RefundReason `json:"refund_reason"`
+	RefundedAmount float64      `json:"refunded_amount"`
+}
+
+func (p *Payment) SetAudit(username string) {
+	p.CreatedBy = username
+	p.UpdatedBy = username
+}
+
+func (p *Payment) SetUpdatedBy(username string) {
+	p.UpdatedBy = username
+}
+
+// Refund returns a refund of amount of the payment and adds it to refunded amount of the payment, the remaining amount
+// is refunded if amount is zero. It returns InvalidRefundErr if the payment is not a CREDIT payment, e.g. a penalty, or
+// is a refund itself and RefundExceedsPaymentErr if amount is more than the amount which is not refunded yet.
+func (p *Payment) Refund(amount float64, reason RefundReason, now time.Time) (*Payment, error) {
+
+	if p.PaymentType != CREDIT || p.RefundOfId != nil || p.Amount <= 0 {
+		return nil, InvalidRefundErr
+	}
+
+	remaining := math.Round((p.Amount-p.RefundedAmount)*100) / 100
+	amount = math.Round(amount*100) / 100
+	if amount == 0 {
+		amount = remaining
+	}
+
+	if amount < 0 || remaining <= 0 || amount > remaining {
+		return nil, RefundExceedsPaymentErr
+	}
+
+	p.RefundedAmount = math.Round((p.RefundedAmount+amount)*100) / 100
+
+	id := p.Id
+	return &Payment{
+		Amount:           -amount,
+		PaymentType:      p.PaymentType,
+		PayerId:          p.PayerId,
+		PaymentDate:      &now,
+		ReservationId:    p.ReservationId,
+		GatewayReference: p.GatewayReference,
+		RefundOfId:       &id,
+		RefundReason:     reason,
+	}, nil
 }
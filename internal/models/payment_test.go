@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPaymentRefund(t *testing.T) {
+
+	now := time.Now()
+	payment := &Payment{BaseModel: BaseModel{Id: 5}, Amount: 100, PaymentType: CREDIT, PayerId: 2, ReservationId: 3,
+		GatewayReference: "pi_1"}
+
+	refund, err := payment.Refund(30.004, RefundOverpayment, now)
+	assert.Nil(t, err)
+	assert.Equal(t, -30.0, refund.Amount)
+	assert.Equal(t, uint64(5), *refund.RefundOfId)
+	assert.Equal(t, RefundOverpayment, refund.RefundReason)
+	assert.Equal(t, "pi_1", refund.GatewayReference)
+	assert.Equal(t, 30.0, payment.RefundedAmount)
+
+	_, err = payment.Refund(70.01, RefundOverpayment, now)
+	assert.Equal(t, RefundExceedsPaymentErr, err)
+	_, err = payment.Refund(-1, RefundOverpayment, now)
+	assert.Equal(t, RefundExceedsPaymentErr, err)
+
+	refund, err = payment.Refund(0, RefundCancellation, now)
+	assert.Nil(t, err)
+	assert.Equal(t, -70.0, refund.Amount)
+	assert.Equal(t, 100.0, payment.RefundedAmount)
+
+	_, err = payment.Refund(0, RefundCancellation, now)
+	assert.Equal(t, RefundExceedsPaymentErr, err)
+
+	_, err = refund.Refund(10, RefundDuplicate, now)
+	assert.Equal(t, InvalidRefundErr, err)
+}
+
+func TestPaymentRefundOfDebit(t *testing.T) {
+
+	penalty := &Payment{BaseModel: BaseModel{Id: 6}, Amount: 50, PaymentType: DEBIT, PayerId: 2, ReservationId: 3}
+
+	_, err := penalty.Refund(0, RefundCancellation, time.Now())
+	assert.Equal(t, InvalidRefundErr, err)
+	assert.Equal(t, 0.0, penalty.RefundedAmount)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"github.com/asaskevich/govalidator"
+	"math"
+	"time"
+)
+
+type OccupancyCondition int
+
+const (
+	// OccupancyAbove matches nights which occupancy is more than Threshold percent.
+	OccupancyAbove OccupancyCondition = iota
+	// OccupancyBelow matches nights which occupancy is less than Threshold percent.
+	OccupancyBelow
+)
+
+type PriceAdjustmentType int
+
+const (
+	// PercentageAdjustment changes the night price by Value percent.
+	PercentageAdjustment PriceAdjustmentType = iota
+	// FixedAdjustment adds Value to the night price.
+	FixedAdjustment
+)
+
+// PricingRule is a yield management rule which adjusts night prices by occupancy of the room type,
+// e.g. raise the price 12% when occupancy is above 80% or drop it 10% when it is below 30% within
+// 3 days of arrival. A negative Value lowers the price. A rule without room type or rate code applies to all of them.
+// When more than one rule matches a night, the rule with the lowest Priority applies.
+type PricingRule struct {
+	BaseModel
+	Name       string             `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	RoomTypeId *uint64            `json:"room_type_id"`
+	RateCodeId *uint64            `json:"rate_code_id"`
+	Condition  OccupancyCondition `json:"condition" valid:"range(0|1)"`
+	// Threshold is occupancy percent of the room type in the night.
+	Threshold float64 `json:"threshold" valid:"range(0|100)"`
+	// MaxDaysBeforeArrival limits the rule to bookings made at most this many days before arrival,
+	// the rule applies to all bookings if it is empty.
+	MaxDaysBeforeArrival *uint64             `json:"max_days_before_arrival"`
+	AdjustmentType       PriceAdjustmentType `json:"adjustment_type" valid:"range(0|1)"`
+	Value                float64             `json:"value"`
+	Priority             int                 `json:"priority"`
+	Active               bool                `json:"active"`
+}
+
+func (p *PricingRule) Validate() (bool, error) {
+
+	return govalidator.ValidateStruct(p)
+}
+
+func (p *PricingRule) SetAudit(username string) {
+	p.CreatedBy = username
+	p.UpdatedBy = username
+}
+
+func (p *PricingRule) SetUpdatedBy(username string) {
+	p.UpdatedBy = username
+}
+
+// Matches reports whether the rule applies to a night of given rate code and occupancy percent
+// which is booked daysBeforeArrival days before arrival.
+func (p *PricingRule) Matches(rateCodeId uint64, occupancy float64, daysBeforeArrival int64) bool {
+
+	if !p.Active || (p.RateCodeId != nil && *p.RateCodeId != rateCodeId) {
+		return false
+	}
+
+	if p.MaxDaysBeforeArrival != nil && (daysBeforeArrival < 0 || daysBeforeArrival > int64(*p.MaxDaysBeforeArrival)) {
+		return false
+	}
+
+	if p.Condition == OccupancyBelow {
+		return occupancy < p.Threshold
+	}
+
+	return occupancy > p.Threshold
+}
+
+// Adjust returns the night price adjusted by the rule rounded to cents, adjusted price is never negative.
+func (p *PricingRule) Adjust(price float64) float64 {
+
+	adjusted := price + p.Value
+	if p.AdjustmentType == PercentageAdjustment {
+		adjusted = price * (100 + p.Value) / 100
+	}
+
+	return math.Max(0, math.Round(adjusted*100)/100)
+}
+
+// SelectPricingRule returns the rule which applies to a night, rules must be sorted by priority.
+// It returns nil if no rule matches.
+func SelectPricingRule(rules []*PricingRule, rateCodeId uint64, occupancy float64, daysBeforeArrival int64) *PricingRule {
+
+	for _, rule := range rules {
+		if rule.Matches(rateCodeId, occupancy, daysBeforeArrival) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// PriceAdjustmentLog is an audit record of a pricing rule applied to a night price of a reservation.
+type PriceAdjustmentLog struct {
+	BaseModel
+	PricingRuleId uint64     `json:"pricing_rule_id" gorm:"index"`
+	ReservationId uint64     `json:"reservation_id" gorm:"index;not null"`
+	RoomId        uint64     `json:"room_id"`
+	RoomTypeId    uint64     `json:"room_type_id"`
+	RateCodeId    uint64     `json:"rate_code_id"`
+	Date          *time.Time `json:"date"`
+	Occupancy     float64    `json:"occupancy"`
+	BasePrice     float64    `json:"base_price"`
+	AdjustedPrice float64    `json:"adjusted_price"`
+}
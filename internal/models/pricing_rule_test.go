@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPricingRuleAdjust(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		rule PricingRule
+		want float64
+	}{
+		{name: "raise_percent", rule: PricingRule{AdjustmentType: PercentageAdjustment, Value: 12}, want: 112},
+		{name: "drop_percent", rule: PricingRule{AdjustmentType: PercentageAdjustment, Value: -10}, want: 90},
+		{name: "fixed", rule: PricingRule{AdjustmentType: FixedAdjustment, Value: 7.5}, want: 107.5},
+		{name: "not_negative", rule: PricingRule{AdjustmentType: FixedAdjustment, Value: -150}, want: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.rule.Adjust(100))
+		})
+	}
+}
+
+func TestSelectPricingRule(t *testing.T) {
+
+	days := uint64(3)
+	rateCodeId := uint64(9)
+
+	high := &PricingRule{BaseModel: BaseModel{Id: 1}, Condition: OccupancyAbove, Threshold: 80, Value: 12, Active: true}
+	lastMinute := &PricingRule{BaseModel: BaseModel{Id: 2}, Condition: OccupancyBelow, Threshold: 30, Value: -10,
+		MaxDaysBeforeArrival: &days, Active: true}
+	corporate := &PricingRule{BaseModel: BaseModel{Id: 3}, Condition: OccupancyBelow, Threshold: 50, Value: -5,
+		RateCodeId: &rateCodeId, Active: true}
+	inactive := &PricingRule{BaseModel: BaseModel{Id: 4}, Condition: OccupancyBelow, Threshold: 100, Active: false}
+
+	rules := []*PricingRule{high, lastMinute, corporate, inactive}
+
+	assert.Equal(t, high, SelectPricingRule(rules, 1, 85, 30))
+	assert.Nil(t, SelectPricingRule(rules, 1, 80, 30))
+	assert.Equal(t, lastMinute, SelectPricingRule(rules, 1, 20, 3))
+	assert.Nil(t, SelectPricingRule(rules, 1, 20, 4))
+	assert.Equal(t, corporate, SelectPricingRule(rules, rateCodeId, 20, 4))
+	assert.Nil(t, SelectPricingRule(rules, 1, 60, 0))
+}
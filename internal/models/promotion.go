@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+	"github.com/asaskevich/govalidator"
+	"math"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/internal_errors/message_keys"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PromotionDiscountType int
+
+const (
+	// PercentageDiscount discounts Value percent of the room price.
+	PercentageDiscount PromotionDiscountType = iota
+	// FixedDiscount discounts Value as amount, it never exceeds the room price.
+	FixedDiscount
+	// FreeNightsDiscount makes FreeNights cheapest nights free for every StayNights nights, like "stay 4 pay 3".
+	FreeNightsDiscount
+)
+
+var (
+	PromotionNotFoundErr      = errors.New(message_keys.PromotionNotFound)
+	PromotionNotApplicableErr = errors.New(message_keys.PromotionNotApplicable)
+	PromotionUsageLimitErr    = errors.New(message_keys.PromotionUsageLimit)
+)
+
+// Promotion is a discount which guests get by its Code. A promotion is applicable if it is booked in its
+// booking window, every night of the stay is in its stay window and rate code and room type of the reservation
+// are eligible, empty windows and eligibility lists do not limit the promotion.
+type Promotion struct {
+	BaseModel
+	Code            string                `json:"code" valid:"required" gorm:"type:varchar(50);uniqueIndex"`
+	Name            string                `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	DiscountType    PromotionDiscountType `json:"discount_type" valid:"range(0|2)"`
+	Value           float64               `json:"value"`
+	StayNights      uint64                `json:"stay_nights"`
+	FreeNights      uint64                `json:"free_nights"`
+	BookingStart    *time.Time            `json:"booking_start"`
+	BookingEnd      *time.Time            `json:"booking_end"`
+	StayStart       *time.Time            `json:"stay_start"`
+	StayEnd         *time.Time            `json:"stay_end"`
+	MaxUses         uint64                `json:"max_uses"`
+	MaxUsesPerGuest uint64                `json:"max_uses_per_guest"`
+	UsedCount       uint64                `json:"used_count"`
+	Active          bool                  `json:"active"`
+	RateCodes       []*PromotionRateCode  `json:"rate_codes" valid:"-"`
+	RoomTypes       []*PromotionRoomType  `json:"room_types" valid:"-"`
+}
+
+// PromotionRateCode is a rate code which a promotion is eligible for.
+type PromotionRateCode struct {
+	BaseModel
+	PromotionId uint64 `json:"promotion_id" gorm:"index"`
+	RateCodeId  uint64 `json:"rate_code_id"`
+}
+
+// PromotionRoomType is a room type which a promotion is eligible for.
+type PromotionRoomType struct {
+	BaseModel
+	PromotionId uint64 `json:"promotion_id" gorm:"index"`
+	RoomTypeId  uint64 `json:"room_type_id"`
+}
+
+// PromotionRedemption is use of a promotion by a reservation.
+type PromotionRedemption struct {
+	BaseModel
+	PromotionId    uint64  `json:"promotion_id" gorm:"index"`
+	ReservationId  uint64  `json:"reservation_id" gorm:"uniqueIndex"`
+	GuestId        uint64  `json:"guest_id" gorm:"index"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
+
+func (p *Promotion) Validate() (bool, error) {
+
+	if p.DiscountType == FreeNightsDiscount && (p.StayNights == 0 || p.FreeNights == 0 || p.FreeNights > p.StayNights) {
+		return false, errors.New("free_nights: must be between 1 and stay_nights")
+	}
+
+	return govalidator.ValidateStruct(p)
+}
+
+func (p *Promotion) SetAudit(username string) {
+	p.CreatedBy = username
+	p.UpdatedBy = username
+}
+
+func (p *Promotion) SetUpdatedBy(username string) {
+	p.UpdatedBy = username
+}
+
+// NormalizeCode returns promo code in the form which it is stored and searched by.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CheckApplicable returns PromotionNotApplicableErr if the promotion can not be applied to a stay
+// which is booked at now, otherwise it returns nil. Usage limits are checked on redemption.
+func (p *Promotion) CheckApplicable(now, checkin, checkout time.Time, rateCodeId, roomTypeId uint64) error {
+
+	if !p.Active {
+		return PromotionNotApplicableErr
+	}
+
+	if (p.BookingStart != nil && now.Before(*p.BookingStart)) || (p.BookingEnd != nil && now.After(*p.BookingEnd)) {
+		return PromotionNotApplicableErr
+	}
+
+	for _, night := range date_utils.Nights(checkin, checkout) {
+		if p.StayStart != nil && night.Before(date_utils.TruncateToDay(*p.StayStart)) {
+			return PromotionNotApplicableErr
+		}
+		if p.StayEnd != nil && night.After(date_utils.TruncateToDay(*p.StayEnd)) {
+			return PromotionNotApplicableErr
+		}
+	}
+
+	if len(p.RateCodes) > 0 && !p.hasRateCode(rateCodeId) {
+		return PromotionNotApplicableErr
+	}
+
+	if len(p.RoomTypes) > 0 && !p.hasRoomType(roomTypeId) {
+		return PromotionNotApplicableErr
+	}
+
+	return nil
+}
+
+// Discount returns discount amount of a stay with given night prices.
+func (p *Promotion) Discount(nightPrices []float64) float64 {
+
+	total := 0.0
+	for _, price := range nightPrices {
+		total += price
+	}
+
+	discount := 0.0
+
+	switch p.DiscountType {
+	case PercentageDiscount:
+		discount = total * p.Value / 100
+	case FixedDiscount:
+		discount = p.Value
+	case FreeNightsDiscount:
+		if p.StayNights == 0 {
+			break
+		}
+		freeNights := int(uint64(len(nightPrices)) / p.StayNights * p.FreeNights)
+		prices := append([]float64{}, nightPrices...)
+		sort.Float64s(prices)
+		for i := 0; i < freeNights && i < len(prices); i++ {
+			discount += prices[i]
+		}
+	}
+
+	discount = math.Min(discount, total)
+
+	return math.Round(discount*100) / 100
+}
+
+func (p *Promotion) hasRateCode(rateCodeId uint64) bool {
+
+	for _, rateCode := range p.RateCodes {
+		if rateCode.RateCodeId == rateCodeId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Promotion) hasRoomType(roomTypeId uint64) bool {
+
+	for _, roomType := range p.RoomTypes {
+		if roomType.RoomTypeId == roomTypeId {
+			return true
+		}
+	}
+
+	return false
+}
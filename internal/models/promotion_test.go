@@ -0,0 +1,67 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPromotionDiscount(t *testing.T) {
+
+	testCases := []struct {
+		name      string
+		promotion Promotion
+		nights    []float64
+		want      float64
+	}{
+		{name: "percentage", promotion: Promotion{DiscountType: PercentageDiscount, Value: 10}, nights: []float64{100, 150}, want: 25},
+		{name: "fixed", promotion: Promotion{DiscountType: FixedDiscount, Value: 30}, nights: []float64{100, 150}, want: 30},
+		{name: "fixed_more_than_price", promotion: Promotion{DiscountType: FixedDiscount, Value: 300}, nights: []float64{100}, want: 100},
+		{name: "stay_4_pay_3", promotion: Promotion{DiscountType: FreeNightsDiscount, StayNights: 4, FreeNights: 1}, nights: []float64{100, 80, 120, 100}, want: 80},
+		{name: "stay_4_pay_3_short_stay", promotion: Promotion{DiscountType: FreeNightsDiscount, StayNights: 4, FreeNights: 1}, nights: []float64{100, 80, 120}, want: 0},
+		{name: "stay_4_pay_3_twice", promotion: Promotion{DiscountType: FreeNightsDiscount, StayNights: 4, FreeNights: 1}, nights: []float64{100, 80, 120, 100, 90, 90, 90, 90}, want: 170},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.promotion.Discount(testCase.nights))
+		})
+	}
+}
+
+func TestPromotionCheckApplicable(t *testing.T) {
+
+	day := func(d int) *time.Time {
+		result := time.Date(2023, 6, d, 0, 0, 0, 0, time.UTC)
+		return &result
+	}
+
+	promotion := func(modify func(p *Promotion)) *Promotion {
+		p := &Promotion{Active: true, BookingStart: day(1), BookingEnd: day(10), StayStart: day(10), StayEnd: day(20)}
+		modify(p)
+		return p
+	}
+
+	testCases := []struct {
+		name      string
+		promotion *Promotion
+		now       time.Time
+		checkin   time.Time
+		checkout  time.Time
+		want      error
+	}{
+		{name: "applicable", promotion: promotion(func(p *Promotion) {}), now: *day(5), checkin: *day(18), checkout: *day(21), want: nil},
+		{name: "inactive", promotion: promotion(func(p *Promotion) { p.Active = false }), now: *day(5), checkin: *day(18), checkout: *day(21), want: PromotionNotApplicableErr},
+		{name: "booked_late", promotion: promotion(func(p *Promotion) {}), now: *day(11), checkin: *day(18), checkout: *day(20), want: PromotionNotApplicableErr},
+		{name: "night_after_stay_window", promotion: promotion(func(p *Promotion) {}), now: *day(5), checkin: *day(19), checkout: *day(22), want: PromotionNotApplicableErr},
+		{name: "other_rate_code", promotion: promotion(func(p *Promotion) { p.RateCodes = []*PromotionRateCode{{RateCodeId: 2}} }), now: *day(5), checkin: *day(12), checkout: *day(14), want: PromotionNotApplicableErr},
+		{name: "eligible_room_type", promotion: promotion(func(p *Promotion) { p.RoomTypes = []*PromotionRoomType{{RoomTypeId: 3}} }), now: *day(5), checkin: *day(12), checkout: *day(14), want: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.promotion.CheckApplicable(testCase.now, testCase.checkin, testCase.checkout, 1, 3)
+			assert.Equal(t, testCase.want, err)
+		})
+	}
+}
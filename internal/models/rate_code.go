@@ -2,10 +2,29 @@ package models
 
 import (
 	"github.com/asaskevich/govalidator"
+	"math"
 )
 
 type RateCodeStats int
 
+type RateDerivationType int
+
+const (
+	// PercentageDerivation adds DerivationValue percent of the parent price, negative values are discounts.
+	PercentageDerivation RateDerivationType = iota
+	// FixedDerivation adds DerivationValue as amount to the parent price.
+	FixedDerivation
+)
+
+type RateRoundingType int
+
+const (
+	NoRounding RateRoundingType = iota
+	RoundNearest
+	RoundUp
+	RoundDown
+)
+
 type RateCode struct {
 	BaseModel
 	Name        string     `json:"name"  valid:"required  gorm:"type:varchar(255)""`
@@ -15,6 +34,23 @@ type RateCode struct {
 	CurrencyId  uint64     `json:"currency_id" valid:"required"`
 	RateGroup   *RateGroup `json:"rate_group" valid:"-"`
 	RateGroupId uint64     `json:"rate_group_id"`
+	// CancellationPolicy is applied when a reservation of this rate code is cancelled,
+	// reservations of rate codes without policy are cancelled free.
+	CancellationPolicy   *CancellationPolicy `json:"cancellation_policy" valid:"-"`
+	CancellationPolicyId *uint64             `json:"cancellation_policy_id"`
+	// NoShowFee is charged when a reservation of this rate code is marked as no-show.
+	NoShowFee float64 `json:"no_show_fee"`
+	// ParentRateCodeId is the rate code which prices of this rate code are derived from, like "BAR -10%".
+	// Derived prices are calculated when rates are resolved, so derived rate codes do not have prices.
+	ParentRateCodeId *uint64            `json:"parent_rate_code_id"`
+	ParentRateCode   *RateCode          `json:"parent_rate_code,omitempty" valid:"-" gorm:"foreignKey:ParentRateCodeId;references:id"`
+	DerivationType   RateDerivationType `json:"derivation_type" valid:"range(0|1)"`
+	DerivationValue  float64            `json:"derivation_value"`
+	// RoundingType rounds derived prices to a multiple of RoundingUnit, unit 0 means 1.
+	RoundingType RateRoundingType `json:"rounding_type" valid:"range(0|3)"`
+	RoundingUnit float64          `json:"rounding_unit"`
+	// AddOns are bundled in prices of the rate code as an inclusive package.
+	AddOns []*RateCodeAddOn `json:"add_ons" gorm:"foreignKey:RateCodeId;references:id"`
 	//Guest       Guest     `json:"guest"  valid:"-"`
 	//GuestId     uint64    `json:"guest_id"  valid:"required"`
 	Status RateCodeStats `json:"status"`
@@ -25,6 +61,40 @@ func (r *RateCode) Validate() (bool, error) {
 	return govalidator.ValidateStruct(r)
 }
 
+// IsDerived reports whether prices of the rate code are derived from a parent rate code.
+func (r *RateCode) IsDerived() bool {
+	return r.ParentRateCodeId != nil && *r.ParentRateCodeId != 0
+}
+
+// DerivePrice returns price of the rate code for given price of its parent rate code, it is never negative.
+func (r *RateCode) DerivePrice(parentPrice float64) float64 {
+
+	price := parentPrice
+
+	switch r.DerivationType {
+	case PercentageDerivation:
+		price += parentPrice * r.DerivationValue / 100
+	case FixedDerivation:
+		price += r.DerivationValue
+	}
+
+	unit := r.RoundingUnit
+	if unit <= 0 {
+		unit = 1
+	}
+
+	switch r.RoundingType {
+	case RoundNearest:
+		price = math.Round(price/unit) * unit
+	case RoundUp:
+		price = math.Ceil(price/unit) * unit
+	case RoundDown:
+		price = math.Floor(price/unit) * unit
+	}
+
+	return math.Max(0, math.Round(price*100)/100)
+}
+
 func (r *RateCode) SetAudit(username string) {
 	r.CreatedBy = username
 	r.UpdatedBy = username
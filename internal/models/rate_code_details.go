@@ -13,7 +13,21 @@ type RateCodeDetail struct {
 	Room       *Room      `json:"room"          gorm:"foreignkey:RoomId"`
 	RoomId     uint64     `json:"room_id"       valid:"required"`
 
+	// stay restrictions of the date range, MaxNights and MaxAdvanceDays are not checked when they are 0.
+	// arrival restrictions are checked by the detail which covers the arrival day
+	// and ClosedToDeparture by the detail which covers the departure day.
+	ClosedToArrival   bool   `json:"closed_to_arrival"`
+	ClosedToDeparture bool   `json:"closed_to_departure"`
+	MinAdvanceDays    uint64 `json:"min_advance_days"`
+	MaxAdvanceDays    uint64 `json:"max_advance_days"`
+
 	RatePrices []*RateCodeDetailPrice `json:"rate_prices" valid:"required"`
+
+	// occupancy prices per night, ExtraAdultPrice is charged for every adult more than the largest guest count
+	// of RatePrices which is not more than the adults, it is not charged if it is 0 and the stay has no price.
+	ExtraAdultPrice float64                     `json:"extra_adult_price"`
+	ExtraBedPrice   float64                     `json:"extra_bed_price"`
+	ChildPrices     []*RateCodeDetailChildPrice `json:"child_prices"`
 }
 
 type RateCodeDetailPrice struct {
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRateCodeDerivePrice(t *testing.T) {
+
+	testCases := []struct {
+		name     string
+		rateCode RateCode
+		parent   float64
+		want     float64
+	}{
+		{name: "non_refundable", rateCode: RateCode{DerivationType: PercentageDerivation, DerivationValue: -10}, parent: 123, want: 110.7},
+		{name: "breakfast", rateCode: RateCode{DerivationType: FixedDerivation, DerivationValue: 15}, parent: 100, want: 115},
+		{name: "round_nearest", rateCode: RateCode{DerivationType: PercentageDerivation, DerivationValue: -10, RoundingType: RoundNearest}, parent: 123, want: 111},
+		{name: "round_down_to_five", rateCode: RateCode{DerivationType: PercentageDerivation, DerivationValue: -10, RoundingType: RoundDown, RoundingUnit: 5}, parent: 123, want: 110},
+		{name: "round_up_to_half", rateCode: RateCode{DerivationType: FixedDerivation, DerivationValue: 0.2, RoundingType: RoundUp, RoundingUnit: 0.5}, parent: 100, want: 100.5},
+		{name: "never_negative", rateCode: RateCode{DerivationType: FixedDerivation, DerivationValue: -50}, parent: 30, want: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.rateCode.DerivePrice(testCase.parent))
+		})
+	}
+}
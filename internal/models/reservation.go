@@ -1,38 +1,154 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"github.com/asaskevich/govalidator"
+	"reservation-api/internal_errors/message_keys"
 	"time"
 )
 
 type ReservationCheckStatus int
 
+// values of first statuses are stored in database before the lifecycle was introduced,
+// so new statuses must be appended to the end.
 const (
-	CheckIn ReservationCheckStatus = iota
-	Checkout
+	CheckedIn ReservationCheckStatus = iota
+	CheckedOut
 	Block
+	Cancelled
+	Tentative
+	Confirmed
+	NoShow
 )
 
+var (
+	InvalidStatusTransitionErr = errors.New(message_keys.InvalidStatusTransition)
+	EarlyCheckInErr            = errors.New(message_keys.EarlyCheckIn)
+)
+
+// ReleasedCheckStatuses are statuses which do not occupy the reservation's room anymore.
+var ReleasedCheckStatuses = []ReservationCheckStatus{CheckedOut, Cancelled, NoShow}
+
+// StayRangeSQL returns SQL daterange of nights of reservations which are selected with alias prefix like "r.", stays
+// are half-open so checkout day of a reservation can be checkin day of another one. Stay dates are stored at noon,
+// so their day is the same in UTC.
+func StayRangeSQL(alias string) string {
+	return fmt.Sprintf("daterange((%[1]scheckin_date AT TIME ZONE 'UTC')::date, (%[1]scheckout_date AT TIME ZONE 'UTC')::date, '[)')", alias)
+}
+
+// statusTransitions is the reservation lifecycle, keys are current statuses
+// and values are statuses which the reservation can move to.
+var statusTransitions = map[ReservationCheckStatus][]ReservationCheckStatus{
+	Tentative: {Confirmed, Cancelled},
+	Confirmed: {CheckedIn, Cancelled, NoShow},
+	CheckedIn: {CheckedOut},
+	Block:     {Cancelled},
+}
+
+// CanTransition reports whether a reservation in from status can move to the to status.
+func CanTransition(from, to ReservationCheckStatus) bool {
+
+	for _, status := range statusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsReleased reports whether the status does not occupy the room anymore.
+func (s ReservationCheckStatus) IsReleased() bool {
+
+	for _, status := range ReleasedCheckStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Reservation struct {
 	BaseModel
-	HotelId      uint64                 `json:"hotel_id" valid:"-"`
-	Hotel        *Hotel                 `json:"hotel" valid:"-"  gorm:"foreignKey:HotelId;references:id"`
-	SupervisorId uint64                 `json:"supervisor_id" valid:"required"`
-	Supervisor   *Guest                 `json:"supervisor" valid:"-"   gorm:"foreignKey:SupervisorId;references:id"`
-	CheckinDate  *time.Time             `json:"checkin_date" valid:"required"`
-	CheckoutDate *time.Time             `json:"checkout_date" valid:"required"`
-	RoomId       uint64                 `json:"room_id" valid:"required"`
-	Room         *Room                  `json:"room" valid:"-"   gorm:"foreignKey:RoomId;references:id"`
-	RateCodeId   uint64                 `json:"rate_code_id" valid:"required"`
-	RateCode     *RateCode              `json:"rate_code" valid:"-"   gorm:"foreignKey:RateCodeId;references:id"`
-	GuestCount   uint64                 `json:"guest_count"`
-	ParentId     uint64                 `json:"parent_id" valid:"-"`
-	Parent       *Reservation           `json:"parent" gorm:"foreignKey:ParentId;references:id"`
-	Price        float64                `json:"price"`
-	Nights       float64                `json:"nights"`
-	RequestKey   string                 `json:"request_key" gorm:"-" valid:"required"`
-	CheckStatus  ReservationCheckStatus `json:"check_status" valid:"required"`
-	Sharers      []*Sharer              `json:"sharers"`
+	HotelId      uint64     `json:"hotel_id" valid:"-"`
+	Hotel        *Hotel     `json:"hotel" valid:"-"  gorm:"foreignKey:HotelId;references:id"`
+	SupervisorId uint64     `json:"supervisor_id" valid:"required"`
+	Supervisor   *Guest     `json:"supervisor" valid:"-"   gorm:"foreignKey:SupervisorId;references:id"`
+	CheckinDate  *time.Time `json:"checkin_date" valid:"required"`
+	CheckoutDate *time.Time `json:"checkout_date" valid:"required"`
+	RoomId       uint64     `json:"room_id" valid:"required"`
+	Room         *Room      `json:"room" valid:"-"   gorm:"foreignKey:RoomId;references:id"`
+	RateCodeId   uint64     `json:"rate_code_id" valid:"required"`
+	RateCode     *RateCode  `json:"rate_code" valid:"-"   gorm:"foreignKey:RateCodeId;references:id"`
+	GuestCount   uint64     `json:"guest_count"`
+	// GuestCount is sum of Adults and ChildGuests, ExtraBeds are charged by the rate code.
+	Adults      uint64              `json:"adults"`
+	ChildGuests []*ReservationChild `json:"child_guests" gorm:"foreignKey:ReservationId;references:id"`
+	ExtraBeds   uint64              `json:"extra_beds"`
+	// AddOns are services sold with the reservation, AddOnAmount is sum of their amounts and it is not taxed.
+	AddOns               []*ReservationAddOn    `json:"add_ons" gorm:"foreignKey:ReservationId;references:id"`
+	AddOnAmount          float64                `json:"add_on_amount"`
+	ConvertedAddOnAmount float64                `json:"converted_add_on_amount"`
+	ParentId             uint64                 `json:"parent_id" valid:"-"`
+	Parent               *Reservation           `json:"parent" gorm:"foreignKey:ParentId;references:id"`
+	Price                float64                `json:"price"`
+	Nights               float64                `json:"nights"`
+	RequestKey           string                 `json:"request_key" gorm:"-" valid:"required"`
+	CheckStatus          ReservationCheckStatus `json:"check_status" valid:"required"`
+	Sharers              []*Sharer              `json:"sharers"`
+	NightPrices          []*ReservationNight    `json:"night_prices" gorm:"foreignKey:ReservationId;references:id"`
+	TaxAmount            float64                `json:"tax_amount"`
+	Taxes                []*ReservationTax      `json:"taxes" gorm:"foreignKey:ReservationId;references:id"`
+	// Price and TaxAmount are in currency of the rate code (BaseCurrencyId), the guest is charged in CurrencyId
+	// by ExchangeRate which is locked when the reservation is booked.
+	BaseCurrencyId     uint64  `json:"base_currency_id"`
+	CurrencyId         uint64  `json:"currency_id"`
+	ExchangeRate       float64 `json:"exchange_rate"`
+	ConvertedPrice     float64 `json:"converted_price"`
+	ConvertedTaxAmount float64 `json:"converted_tax_amount"`
+	// PromoCode is the promotion code which guest books by, Price is discounted by DiscountAmount.
+	PromoCode      string  `json:"promo_code" gorm:"type:varchar(50)"`
+	PromotionId    *uint64 `json:"promotion_id"`
+	DiscountAmount float64 `json:"discount_amount"`
+	// PriceAdjustments are pricing rule adjustments of the night prices, they are logged when the reservation is saved.
+	PriceAdjustments []*PriceAdjustmentLog `json:"-" gorm:"-"`
+	// group booking fields, a group master does not occupy a room and its children are the room reservations.
+	IsGroupMaster bool           `json:"is_group_master"`
+	GroupName     string         `json:"group_name" gorm:"type:varchar(255)"`
+	ReleaseDate   *time.Time     `json:"release_date"`
+	Children      []*Reservation `json:"children,omitempty" gorm:"foreignKey:ParentId;references:id"`
+}
+
+// SetInitialStatus sets status of a new reservation, reservations are created
+// as Confirmed unless they are tentative bookings or room blocks.
+func (r *Reservation) SetInitialStatus() {
+
+	if r.CheckStatus != Tentative && r.CheckStatus != Block {
+		r.CheckStatus = Confirmed
+	}
+}
+
+// ChangesStay reports whether the updated reservation has other stay dates or rate code than the reservation,
+// stay restrictions of the rate code are checked only for such changes.
+func (r *Reservation) ChangesStay(updated *Reservation) bool {
+
+	return r.RateCodeId != updated.RateCodeId || !sameDay(r.CheckinDate, updated.CheckinDate) ||
+		!sameDay(r.CheckoutDate, updated.CheckoutDate)
+}
+
+// CanCheckIn reports whether guest can check in at given time, check-in is not allowed before the arrival day.
+func (r *Reservation) CanCheckIn(now time.Time) bool {
+
+	if r.CheckinDate == nil {
+		return false
+	}
+
+	now = now.In(r.CheckinDate.Location())
+	arrivalDay := time.Date(r.CheckinDate.Year(), r.CheckinDate.Month(), r.CheckinDate.Day(), 0, 0, 0, 0, r.CheckinDate.Location())
+
+	return !now.Before(arrivalDay)
 }
 
 func (r *Reservation) Validate() (bool, error) {
@@ -48,3 +164,12 @@ func (r *Reservation) SetAudit(username string) {
 func (r *Reservation) SetUpdatedBy(username string) {
 	r.UpdatedBy = username
 }
+
+func sameDay(a, b *time.Time) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ReservationNight is price of a single night of a reservation,
+// Reservation.Price is sum of its nights.
+type ReservationNight struct {
+	BaseModel
+	ReservationId    uint64     `json:"reservation_id" gorm:"index"`
+	Date             *time.Time `json:"date"`
+	RateCodeId       uint64     `json:"rate_code_id"`
+	RateCodeDetailId uint64     `json:"rate_code_detail_id"`
+	RatePriceId      uint64     `json:"rate_price_id"`
+	Price            float64    `json:"price"`
+	// BasePrice is price of the night before pricing rule adjustment.
+	BasePrice     float64 `json:"base_price"`
+	PricingRuleId *uint64 `json:"pricing_rule_id"`
+}
+
+// FirstNight returns the earliest night of the reservation, it returns nil if nightly prices are not loaded.
+func (r *Reservation) FirstNight() *ReservationNight {
+
+	var first *ReservationNight
+
+	for _, night := range r.NightPrices {
+		if night.Date != nil && (first == nil || night.Date.Before(*first.Date)) {
+			first = night
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ReservationStatusHistory keeps every status transition of a reservation,
+// FromStatus is nil for the initial status of a new reservation.
+type ReservationStatusHistory struct {
+	BaseModel
+	ReservationId uint64                  `json:"reservation_id" gorm:"index"`
+	FromStatus    *ReservationCheckStatus `json:"from_status"`
+	ToStatus      ReservationCheckStatus  `json:"to_status"`
+	ChangedAt     *time.Time              `json:"changed_at"`
+	Description   string                  `json:"description"`
+}
+
+func (h *ReservationStatusHistory) SetAudit(username string) {
+	h.CreatedBy = username
+	h.UpdatedBy = username
+}
+
+func (h *ReservationStatusHistory) SetUpdatedBy(username string) {
+	h.UpdatedBy = username
+}
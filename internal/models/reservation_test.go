@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCanTransition(t *testing.T) {
+
+	assert.True(t, CanTransition(Tentative, Confirmed))
+	assert.True(t, CanTransition(Confirmed, CheckedIn))
+	assert.True(t, CanTransition(Confirmed, NoShow))
+	assert.True(t, CanTransition(CheckedIn, CheckedOut))
+	assert.True(t, CanTransition(Block, Cancelled))
+
+	assert.False(t, CanTransition(Confirmed, CheckedOut))
+	assert.False(t, CanTransition(CheckedIn, Cancelled))
+	assert.False(t, CanTransition(CheckedOut, CheckedIn))
+	assert.False(t, CanTransition(Cancelled, Confirmed))
+	assert.False(t, CanTransition(NoShow, CheckedIn))
+}
+
+func TestCanCheckIn(t *testing.T) {
+
+	checkin := time.Date(2023, 1, 10, 14, 0, 0, 0, time.UTC)
+	reservation := Reservation{CheckinDate: &checkin}
+
+	assert.False(t, reservation.CanCheckIn(time.Date(2023, 1, 9, 23, 59, 0, 0, time.UTC)))
+	assert.True(t, reservation.CanCheckIn(time.Date(2023, 1, 10, 8, 0, 0, 0, time.UTC)))
+	assert.True(t, reservation.CanCheckIn(time.Date(2023, 1, 11, 8, 0, 0, 0, time.UTC)))
+	assert.False(t, (&Reservation{}).CanCheckIn(checkin))
+}
+
+func TestSetInitialStatus(t *testing.T) {
+
+	reservation := Reservation{CheckStatus: CheckedOut}
+	reservation.SetInitialStatus()
+	assert.Equal(t, Confirmed, reservation.CheckStatus)
+
+	reservation = Reservation{CheckStatus: Block}
+	reservation.SetInitialStatus()
+	assert.Equal(t, Block, reservation.CheckStatus)
+}
+
+func TestReservationChangesStay(t *testing.T) {
+
+	checkin := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	checkout := time.Date(2023, 3, 12, 12, 0, 0, 0, time.UTC)
+	current := &Reservation{RateCodeId: 1, CheckinDate: &checkin, CheckoutDate: &checkout}
+
+	sameCheckin := time.Date(2023, 3, 10, 14, 0, 0, 0, time.UTC)
+	assert.False(t, current.ChangesStay(&Reservation{RateCodeId: 1, CheckinDate: &sameCheckin, CheckoutDate: &checkout}))
+	assert.True(t, current.ChangesStay(&Reservation{RateCodeId: 2, CheckinDate: &checkin, CheckoutDate: &checkout}))
+
+	later := checkout.AddDate(0, 0, 1)
+	assert.True(t, current.ChangesStay(&Reservation{RateCodeId: 1, CheckinDate: &checkin, CheckoutDate: &later}))
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"github.com/asaskevich/govalidator"
+	"math"
+)
+
+type TaxCalculationType int
+
+const (
+	// PercentageTax is Value percent of the room price of the stay.
+	PercentageTax TaxCalculationType = iota
+	// FlatTax is Value as amount, it is multiplied by nights and guests according to the rule basis.
+	FlatTax
+)
+
+type TaxBasis int
+
+const (
+	PerStayTax TaxBasis = iota
+	PerNightTax
+	PerGuestTax
+	PerGuestPerNightTax
+)
+
+// TaxRule is a tax or fee like VAT, city tax or service charge. A rule is scoped to the country, province,
+// city or hotel which is set on it and applies to reservations of hotels inside all of its set scopes,
+// a rule without any scope applies to every hotel.
+type TaxRule struct {
+	BaseModel
+	Name            string             `json:"name" valid:"required" gorm:"type:varchar(255)"`
+	CountryId       *uint64            `json:"country_id"`
+	ProvinceId      *uint64            `json:"province_id"`
+	CityId          *uint64            `json:"city_id"`
+	HotelId         *uint64            `json:"hotel_id"`
+	CalculationType TaxCalculationType `json:"calculation_type" valid:"range(0|1)"`
+	Basis           TaxBasis           `json:"basis" valid:"range(0|3)"`
+	Value           float64            `json:"value"`
+	Active          bool               `json:"active"`
+}
+
+func (t *TaxRule) Validate() (bool, error) {
+
+	return govalidator.ValidateStruct(t)
+}
+
+func (t *TaxRule) SetAudit(username string) {
+	t.CreatedBy = username
+	t.UpdatedBy = username
+}
+
+func (t *TaxRule) SetUpdatedBy(username string) {
+	t.UpdatedBy = username
+}
+
+// Calculate returns amount of the tax for a stay with given room price, nights and guests.
+// Percentage taxes are calculated on the room price and the basis only applies to flat taxes.
+func (t *TaxRule) Calculate(price float64, nights uint64, guests uint64) float64 {
+
+	amount := 0.0
+
+	if t.CalculationType == PercentageTax {
+		amount = price * t.Value / 100
+	} else {
+		switch t.Basis {
+		case PerStayTax:
+			amount = t.Value
+		case PerNightTax:
+			amount = t.Value * float64(nights)
+		case PerGuestTax:
+			amount = t.Value * float64(guests)
+		case PerGuestPerNightTax:
+			amount = t.Value * float64(guests) * float64(nights)
+		}
+	}
+
+	return math.Round(amount*100) / 100
+}
+
+// ReservationTax is an itemized tax line of a reservation.
+type ReservationTax struct {
+	BaseModel
+	ReservationId uint64  `json:"reservation_id" gorm:"index"`
+	TaxRuleId     uint64  `json:"tax_rule_id"`
+	Name          string  `json:"name" gorm:"type:varchar(255)"`
+	Amount        float64 `json:"amount"`
+}
+
+// CalculateTaxes returns tax lines of given rules for the stay, rules with zero amount are skipped.
+func CalculateTaxes(rules []*TaxRule, price float64, nights uint64, guests uint64) []*ReservationTax {
+
+	result := make([]*ReservationTax, 0, len(rules))
+
+	for _, rule := range rules {
+
+		amount := rule.Calculate(price, nights, guests)
+		if amount == 0 {
+			continue
+		}
+
+		result = append(result, &ReservationTax{
+			TaxRuleId: rule.Id,
+			Name:      rule.Name,
+			Amount:    amount,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTaxRuleCalculate(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		rule TaxRule
+		want float64
+	}{
+		{name: "vat", rule: TaxRule{CalculationType: PercentageTax, Value: 9}, want: 27},
+		{name: "service_charge_per_stay", rule: TaxRule{CalculationType: FlatTax, Basis: PerStayTax, Value: 15}, want: 15},
+		{name: "per_night", rule: TaxRule{CalculationType: FlatTax, Basis: PerNightTax, Value: 5}, want: 15},
+		{name: "per_guest", rule: TaxRule{CalculationType: FlatTax, Basis: PerGuestTax, Value: 4}, want: 8},
+		{name: "city_tax", rule: TaxRule{CalculationType: FlatTax, Basis: PerGuestPerNightTax, Value: 2.5}, want: 15},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, testCase.rule.Calculate(300, 3, 2))
+		})
+	}
+}
+
+func TestCalculateTaxes(t *testing.T) {
+
+	rules := []*TaxRule{
+		{Name: "VAT", CalculationType: PercentageTax, Value: 10},
+		{Name: "Empty", CalculationType: FlatTax, Value: 0},
+	}
+
+	result := CalculateTaxes(rules, 200, 2, 1)
+
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, "VAT", result[0].Name)
+	assert.Equal(t, 20.0, result[0].Amount)
+}
@@ -1,11 +1,103 @@
 package models
 
 import (
+	"errors"
 	"github.com/shopspring/decimal"
+	"reservation-api/internal_errors/message_keys"
 )
 
+var (
+	InvalidWalletAmountErr       = errors.New(message_keys.InvalidWalletAmount)
+	InsufficientWalletBalanceErr = errors.New(message_keys.InsufficientWalletBalance)
+	WalletIdempotencyKeyErr      = errors.New(message_keys.WalletIdempotencyKeyReused)
+	SameWalletTransferErr        = errors.New(message_keys.SameWalletTransfer)
+)
+
+type WalletType int
+
+const (
+	GuestWallet WalletType = iota
+	// CorporateWallet is prepaid balance of a company which pays reservations of its guests.
+	CorporateWallet
+)
+
+// Wallet is a prepaid balance which pays folios of reservations. Balance is changed only by posting
+// a WalletTransaction, so the ledger of the wallet always sums up to its balance.
 type Wallet struct {
 	BaseModel
 	UserId  uint64          `gorm:"not null" json:"user_id"`
+	Type    WalletType      `json:"type"`
+	Name    string          `gorm:"type:varchar(255)" json:"name"`
+	GuestId *uint64         `json:"guest_id"`
 	Balance decimal.Decimal `gorm:" type:decimal(10,2);not null;default:0" json:"balance"`
 }
+
+func (w *Wallet) SetAudit(username string) {
+	w.CreatedBy = username
+	w.UpdatedBy = username
+}
+
+type WalletTransactionType int
+
+const (
+	WalletDeposit WalletTransactionType = iota
+	WalletWithdrawal
+	WalletTransferIn
+	WalletTransferOut
+	// WalletFolioPayment pays a reservation folio from the wallet.
+	WalletFolioPayment
+)
+
+// IsDebit reports whether transactions of the type take money out of the wallet.
+func (t WalletTransactionType) IsDebit() bool {
+	return t == WalletWithdrawal || t == WalletTransferOut || t == WalletFolioPayment
+}
+
+// WalletTransaction is an immutable posting of a wallet, Amount is negative for debits and Balance is the balance
+// of the wallet after the transaction. A transaction with IdempotencyKey is posted once per wallet, retries
+// of the request with the same key return the posted transaction.
+type WalletTransaction struct {
+	BaseModel
+	WalletId            uint64                `gorm:"not null;uniqueIndex:idx_wallet_transactions_key" json:"wallet_id"`
+	Type                WalletTransactionType `json:"type"`
+	Amount              decimal.Decimal       `gorm:"type:decimal(10,2);not null" json:"amount"`
+	Balance             decimal.Decimal       `gorm:"type:decimal(10,2);not null" json:"balance"`
+	IdempotencyKey      *string               `gorm:"type:varchar(255);uniqueIndex:idx_wallet_transactions_key" json:"idempotency_key"`
+	Description         string                `gorm:"type:varchar(255)" json:"description"`
+	CounterpartWalletId *uint64               `json:"counterpart_wallet_id"`
+	FolioId             *uint64               `json:"folio_id"`
+	PaymentId           *uint64               `json:"payment_id"`
+}
+
+// Post moves balance of the wallet by amount and returns the transaction, amount is rounded to cents and taken out
+// of the wallet for debit types. It returns InvalidWalletAmountErr if amount is not positive and
+// InsufficientWalletBalanceErr if a debit is more than the balance.
+func (w *Wallet) Post(transactionType WalletTransactionType, amount decimal.Decimal) (*WalletTransaction, error) {
+
+	amount = amount.Round(2)
+	if !amount.IsPositive() {
+		return nil, InvalidWalletAmountErr
+	}
+
+	if transactionType.IsDebit() {
+		if w.Balance.LessThan(amount) {
+			return nil, InsufficientWalletBalanceErr
+		}
+		amount = amount.Neg()
+	}
+
+	w.Balance = w.Balance.Add(amount)
+
+	return &WalletTransaction{
+		WalletId: w.Id,
+		Type:     transactionType,
+		Amount:   amount,
+		Balance:  w.Balance,
+	}, nil
+}
+
+// Matches reports whether the transaction is the result of a request of given type and amount, so a retry with the
+// same idempotency key can return it. A zero amount matches any amount because it is resolved when it is posted.
+func (t *WalletTransaction) Matches(transactionType WalletTransactionType, amount decimal.Decimal) bool {
+	return t.Type == transactionType && (amount.IsZero() || t.Amount.Abs().Equal(amount.Round(2)))
+}
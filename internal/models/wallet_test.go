@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWalletPost(t *testing.T) {
+
+	wallet := &Wallet{BaseModel: BaseModel{Id: 4}, Balance: decimal.NewFromInt(100)}
+
+	deposit, err := wallet.Post(WalletDeposit, decimal.RequireFromString("20.504"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), deposit.WalletId)
+	assert.True(t, deposit.Amount.Equal(decimal.RequireFromString("20.5")))
+	assert.True(t, deposit.Balance.Equal(decimal.RequireFromString("120.5")))
+
+	payment, err := wallet.Post(WalletFolioPayment, decimal.NewFromInt(120))
+	assert.Nil(t, err)
+	assert.True(t, payment.Amount.Equal(decimal.NewFromInt(-120)))
+	assert.True(t, wallet.Balance.Equal(decimal.RequireFromString("0.5")))
+
+	_, err = wallet.Post(WalletWithdrawal, decimal.NewFromInt(1))
+	assert.Equal(t, InsufficientWalletBalanceErr, err)
+	_, err = wallet.Post(WalletDeposit, decimal.NewFromInt(-1))
+	assert.Equal(t, InvalidWalletAmountErr, err)
+	assert.True(t, wallet.Balance.Equal(decimal.RequireFromString("0.5")))
+
+	assert.True(t, payment.Matches(WalletFolioPayment, decimal.NewFromInt(120)))
+	assert.True(t, payment.Matches(WalletFolioPayment, decimal.Zero))
+	assert.False(t, payment.Matches(WalletFolioPayment, decimal.NewFromInt(100)))
+	assert.False(t, payment.Matches(WalletWithdrawal, decimal.NewFromInt(120)))
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+)
+
+type AddOnRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewAddOnRepository returns new AddOnRepository.
+func NewAddOnRepository(r *tenant_database_resolver.TenantDatabaseResolver) *AddOnRepository {
+
+	return &AddOnRepository{DbResolver: r}
+}
+
+func (r *AddOnRepository) Create(ctx context.Context, model *models.AddOn) (*models.AddOn, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *AddOnRepository) Update(ctx context.Context, model *models.AddOn) (*models.AddOn, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	// active can be reset to zero values, so all columns are saved.
+	if tx := db.Select("*").Omit("created_at", "created_by").Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *AddOnRepository) Find(ctx context.Context, id uint64) (*models.AddOn, error) {
+
+	model := models.AddOn{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *AddOnRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.AddOn{}, db, input)
+}
+
+// Delete removes AddOn, add-ons of existing reservations keep their calculated amount.
+func (r *AddOnRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.AddOn{}).Where("id=?", id).Delete(&models.AddOn{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+)
+
+var (
+	CancellationPolicyHasRateCodeErr = errors.New(message_keys.CancellationPolicyHasRateCodeErr)
+)
+
+type CancellationPolicyRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewCancellationPolicyRepository returns new CancellationPolicyRepository.
+func NewCancellationPolicyRepository(r *tenant_database_resolver.TenantDatabaseResolver) *CancellationPolicyRepository {
+
+	return &CancellationPolicyRepository{DbResolver: r}
+}
+
+func (r *CancellationPolicyRepository) Create(ctx context.Context, model *models.CancellationPolicy) (*models.CancellationPolicy, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *CancellationPolicyRepository) Update(ctx context.Context, model *models.CancellationPolicy) (*models.CancellationPolicy, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *CancellationPolicyRepository) Find(ctx context.Context, id uint64) (*models.CancellationPolicy, error) {
+
+	model := models.CancellationPolicy{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *CancellationPolicyRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.CancellationPolicy{}, db, input)
+}
+
+// Delete removes CancellationPolicy, policies which are used by rate codes can not be removed.
+func (r *CancellationPolicyRepository) Delete(ctx context.Context, id uint64) error {
+
+	var count int64 = 0
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.RateCode{}).Where("cancellation_policy_id=?", id).Count(&count); query.Error != nil {
+		return query.Error
+	}
+
+	if count > 0 {
+		return CancellationPolicyHasRateCodeErr
+	}
+
+	if query := db.Model(&models.CancellationPolicy{}).Where("id=?", id).Delete(&models.CancellationPolicy{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
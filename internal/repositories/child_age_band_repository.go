@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+)
+
+type ChildAgeBandRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewChildAgeBandRepository returns new ChildAgeBandRepository.
+func NewChildAgeBandRepository(r *tenant_database_resolver.TenantDatabaseResolver) *ChildAgeBandRepository {
+
+	return &ChildAgeBandRepository{DbResolver: r}
+}
+
+func (r *ChildAgeBandRepository) Create(ctx context.Context, model *models.ChildAgeBand) (*models.ChildAgeBand, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := checkChildAgeBandOverlap(db, model); err != nil {
+		return nil, err
+	}
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *ChildAgeBandRepository) Update(ctx context.Context, model *models.ChildAgeBand) (*models.ChildAgeBand, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := checkChildAgeBandOverlap(db, model); err != nil {
+		return nil, err
+	}
+
+	// min age can be reset to zero, so all columns are saved.
+	if tx := db.Select("*").Omit("created_at", "created_by").Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *ChildAgeBandRepository) Find(ctx context.Context, id uint64) (*models.ChildAgeBand, error) {
+
+	model := models.ChildAgeBand{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *ChildAgeBandRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.ChildAgeBand{}, db, input)
+}
+
+// Delete removes ChildAgeBand, children of existing reservations keep their band id and price.
+func (r *ChildAgeBandRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.ChildAgeBand{}).Where("id=?", id).Delete(&models.ChildAgeBand{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
+
+// checkChildAgeBandOverlap returns ChildAgeBandOverlapErr if given band overlaps another band of its hotel.
+func checkChildAgeBandOverlap(db *gorm.DB, band *models.ChildAgeBand) error {
+
+	bands := make([]*models.ChildAgeBand, 0)
+	if err := db.Where("hotel_id=? AND id<>?", band.HotelId, band.Id).Find(&bands).Error; err != nil {
+		return err
+	}
+
+	for _, other := range bands {
+		if band.Overlaps(other) {
+			return models.ChildAgeBandOverlapErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"strings"
+	"time"
+)
+
+var (
+	ExchangeRateNotFoundErr = errors.New(message_keys.ExchangeRateNotFound)
+)
+
+type ExchangeRateRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewExchangeRateRepository returns new ExchangeRateRepository.
+func NewExchangeRateRepository(r *tenant_database_resolver.TenantDatabaseResolver) *ExchangeRateRepository {
+
+	return &ExchangeRateRepository{DbResolver: r}
+}
+
+func (r *ExchangeRateRepository) Create(ctx context.Context, model *models.ExchangeRate) (*models.ExchangeRate, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *ExchangeRateRepository) Update(ctx context.Context, model *models.ExchangeRate) (*models.ExchangeRate, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *ExchangeRateRepository) Find(ctx context.Context, id uint64) (*models.ExchangeRate, error) {
+
+	model := models.ExchangeRate{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Preload("FromCurrency").Preload("ToCurrency").Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *ExchangeRateRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.ExchangeRate{}, db, input)
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.ExchangeRate{}).Where("id=?", id).Delete(&models.ExchangeRate{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
+
+// FindCurrencyIds returns ids of currencies by their upper case ISO code.
+func (r *ExchangeRateRepository) FindCurrencyIds(ctx context.Context) (map[string]uint64, error) {
+
+	currencies := make([]*models.Currency, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Select("id", "code").Where("code <> ''").Find(&currencies).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]uint64, len(currencies))
+	for _, currency := range currencies {
+		result[strings.ToUpper(currency.Code)] = currency.Id
+	}
+
+	return result, nil
+}
+
+// Import stores given rates in one transaction, a rate of a currency pair and effective date which
+// already exists is replaced by the imported one.
+func (r *ExchangeRateRepository) Import(ctx context.Context, rates []*models.ExchangeRate) error {
+
+	if len(rates) == 0 {
+		return nil
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_currency_id"}, {Name: "to_currency_id"}, {Name: "effective_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by", "updated_at"}),
+	}).Omit("FromCurrency", "ToCurrency").CreateInBatches(&rates, 500).Error
+}
+
+// GetRate returns the rate which converts from currency to the other currency at given time, it is the rate
+// with latest effective date before that time. Converting a currency to itself always has rate 1.
+func (r *ExchangeRateRepository) GetRate(ctx context.Context, fromCurrencyId uint64, toCurrencyId uint64, at time.Time) (float64, error) {
+
+	return findExchangeRate(r.DbResolver.GetTenantDB(ctx), fromCurrencyId, toCurrencyId, at)
+}
+
+func findExchangeRate(db *gorm.DB, fromCurrencyId uint64, toCurrencyId uint64, at time.Time) (float64, error) {
+
+	if fromCurrencyId == toCurrencyId {
+		return 1, nil
+	}
+
+	model := models.ExchangeRate{}
+
+	if err := db.Where("from_currency_id=? AND to_currency_id=? AND effective_date <= ?", fromCurrencyId, toCurrencyId, at).
+		Order("effective_date desc").Limit(1).Find(&model).Error; err != nil {
+		return 0, err
+	}
+
+	if model.Id == 0 {
+		return 0, ExchangeRateNotFoundErr
+	}
+
+	return model.Rate, nil
+}
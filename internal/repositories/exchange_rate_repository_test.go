@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+// dryRunDB returns a database which only builds statements, the database is not connected.
+func dryRunDB(t *testing.T) *gorm.DB {
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+
+	return db
+}
+
+func TestFindExchangeRate(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	statements := make([]string, 0)
+	err := db.Callback().Query().After("gorm:query").Register("test:statements", func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	})
+	assert.Nil(t, err)
+
+	rate, err := findExchangeRate(db, 1, 1, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), rate)
+	assert.Len(t, statements, 0)
+
+	_, err = findExchangeRate(db, 1, 2, time.Now())
+	assert.Equal(t, ExchangeRateNotFoundErr, err)
+	assert.Equal(t, []string{`SELECT * FROM "exchange_rates" WHERE from_currency_id=$1 AND to_currency_id=$2 AND ` +
+		`effective_date <= $3 ORDER BY effective_date desc LIMIT 1`}, statements)
+}
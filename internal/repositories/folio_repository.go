@@ -0,0 +1,433 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
+)
+
+// chargeEntryTypes are folio entry types which are posted from price of reservations.
+var chargeEntryTypes = []models.FolioEntryType{models.RoomChargeEntry, models.TaxEntry, models.AddOnEntry}
+
+type FolioRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewFolioRepository returns new FolioRepository.
+func NewFolioRepository(r *tenant_database_resolver.TenantDatabaseResolver) *FolioRepository {
+
+	return &FolioRepository{DbResolver: r}
+}
+
+// Create opens a new folio for the reservation to split its bill, entries are moved to it by Transfer.
+func (r *FolioRepository) Create(ctx context.Context, model *models.Folio) (*models.Folio, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	model.Status = models.FolioOpen
+	model.Balance = 0
+	model.SettledAt = nil
+
+	if err := db.Omit("Entries").Create(&model).Error; err != nil {
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// Find returns folio with its entries, it returns nil if the folio does not exist.
+func (r *FolioRepository) Find(ctx context.Context, id uint64) (*models.Folio, error) {
+
+	model := models.Folio{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("Entries", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).
+		Where("id=?", id).Find(&model).Error; err != nil {
+		return nil, err
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+// FindByReservation returns folios of the reservation with their entries.
+func (r *FolioRepository) FindByReservation(ctx context.Context, reservationId uint64) ([]*models.Folio, error) {
+
+	result := make([]*models.Folio, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("Entries", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).
+		Where("reservation_id=?", reservationId).Order("id").Find(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Post posts a payment, refund or adjustment to the folio, charges are posted only by pricing the reservation.
+// It returns nil if the folio does not exist.
+func (r *FolioRepository) Post(ctx context.Context, folioId uint64, entry *models.FolioEntry, username string) (*models.FolioEntry, error) {
+
+	if entry.EntryType.IsCharge() || entry.ReversalOfId != nil {
+		return nil, models.InvalidFolioEntryErr
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	folio, err := lockFolio(tx, folioId)
+	if err != nil || folio == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	entry.PaymentId = nil
+	if err := postFolioEntries(tx, folio, []*models.FolioEntry{entry}, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Reverse cancels the entry by posting its reversal to the same folio. It returns nil if the entry does not exist
+// and FolioEntryReversedErr if the entry is already reversed or it is a reversal.
+func (r *FolioRepository) Reverse(ctx context.Context, entryId uint64, username string) (*models.FolioEntry, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	entry, folio, err := lockFolioEntry(tx, entryId)
+	if err != nil || entry == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	reversal, err := reverseFolioEntry(tx, entry, folio, username)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+// Transfer moves the entry to another folio of the same reservation, the entry is reversed on its folio
+// and posted again to the target folio, so both folios keep their history. It returns nil if the entry
+// or the target folio does not exist.
+func (r *FolioRepository) Transfer(ctx context.Context, entryId uint64, folioId uint64, username string) ([]*models.FolioEntry, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	entry, folio, err := lockFolioEntry(tx, entryId)
+	if err != nil || entry == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	target, err := lockFolio(tx, folioId)
+	if err != nil || target == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if target.Id == folio.Id || target.ReservationId != folio.ReservationId {
+		tx.Rollback()
+		return nil, models.InvalidFolioEntryErr
+	}
+
+	reversal, err := reverseFolioEntry(tx, entry, folio, username)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	moved := &models.FolioEntry{
+		EntryType:   entry.EntryType,
+		Description: entry.Description,
+		Date:        entry.Date,
+		Debit:       entry.Debit,
+		Credit:      entry.Credit,
+		PaymentId:   entry.PaymentId,
+	}
+
+	if err := postFolioEntries(tx, target, []*models.FolioEntry{moved}, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return []*models.FolioEntry{reversal, moved}, nil
+}
+
+// Settle closes the folio, it returns FolioNotBalancedErr if the folio has balance.
+// It returns nil if the folio does not exist.
+func (r *FolioRepository) Settle(ctx context.Context, id uint64, username string) (*models.Folio, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	folio, err := lockFolio(tx, id)
+	if err != nil || folio == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := folio.Settle(time.Now()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&models.Folio{}).Where("id=?", id).Updates(map[string]interface{}{
+		"status":     folio.Status,
+		"settled_at": folio.SettledAt,
+		"updated_by": username,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	folio.UpdatedBy = username
+	return folio, nil
+}
+
+// GetBalance returns sum of balances of folios of the reservation, it is the amount which the guest still has to pay.
+func (r *FolioRepository) GetBalance(ctx context.Context, reservationId uint64) (float64, error) {
+
+	var result float64
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(&models.Folio{}).Where("reservation_id=?", reservationId).
+		Select("COALESCE(SUM(balance), 0)").Scan(&result).Error; err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// GetEntryTypeBalances returns debits minus credits of entries of the reservation by entry type.
+func (r *FolioRepository) GetEntryTypeBalances(ctx context.Context, reservationId uint64) (map[models.FolioEntryType]float64, error) {
+
+	rows := make([]*struct {
+		EntryType models.FolioEntryType
+		Balance   float64
+	}, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(&models.FolioEntry{}).Select("entry_type, COALESCE(SUM(debit - credit), 0) AS balance").
+		Where("reservation_id=?", reservationId).Group("entry_type").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[models.FolioEntryType]float64)
+	for _, row := range rows {
+		result[row.EntryType] = row.Balance
+	}
+
+	return result, nil
+}
+
+// lockFolio returns folio with given id and locks its row until the transaction ends,
+// so postings of a folio are serialized and its running balance stays correct. It returns nil if there is no folio.
+func lockFolio(tx *gorm.DB, id uint64) (*models.Folio, error) {
+
+	folio := models.Folio{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&folio).Error; err != nil {
+		return nil, err
+	}
+
+	if folio.Id == 0 {
+		return nil, nil
+	}
+
+	return &folio, nil
+}
+
+// lockFolioEntry returns the entry with its locked folio, it returns nil if there is no entry.
+func lockFolioEntry(tx *gorm.DB, id uint64) (*models.FolioEntry, *models.Folio, error) {
+
+	entry := models.FolioEntry{}
+	if err := tx.Where("id=?", id).Find(&entry).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if entry.Id == 0 {
+		return nil, nil, nil
+	}
+
+	folio, err := lockFolio(tx, entry.FolioId)
+	if err != nil || folio == nil {
+		return nil, nil, err
+	}
+
+	return &entry, folio, nil
+}
+
+// reservationFolio returns the first open folio of the reservation locked, a folio for the payer is opened
+// if the reservation has no open folio.
+func reservationFolio(tx *gorm.DB, reservationId uint64, payerId uint64, username string) (*models.Folio, error) {
+
+	folio := models.Folio{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("reservation_id=? AND status=?", reservationId, models.FolioOpen).
+		Order("id").Limit(1).Find(&folio).Error; err != nil {
+		return nil, err
+	}
+
+	if folio.Id != 0 {
+		return &folio, nil
+	}
+
+	folio = models.Folio{ReservationId: reservationId, Name: "guest", Status: models.FolioOpen}
+	if payerId != 0 {
+		folio.PayerId = &payerId
+	}
+	folio.SetAudit(username)
+
+	if err := tx.Omit("Entries").Create(&folio).Error; err != nil {
+		return nil, err
+	}
+
+	return &folio, nil
+}
+
+// postFolioEntries posts entries to the locked folio and stores its new balance in given transaction.
+func postFolioEntries(tx *gorm.DB, folio *models.Folio, entries []*models.FolioEntry, username string) error {
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if err := folio.Post(entry, now); err != nil {
+			return err
+		}
+		entry.CreatedBy = username
+		entry.UpdatedBy = username
+	}
+
+	if err := tx.Create(&entries).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Folio{}).Where("id=?", folio.Id).Updates(map[string]interface{}{
+		"balance":    folio.Balance,
+		"updated_by": username,
+	}).Error
+}
+
+// reverseFolioEntry posts reversal of the entry to given locked folio, it returns FolioEntryReversedErr
+// if the entry is already reversed or it is a reversal.
+func reverseFolioEntry(tx *gorm.DB, entry *models.FolioEntry, folio *models.Folio, username string) (*models.FolioEntry, error) {
+
+	var count int64 = 0
+	if err := tx.Model(&models.FolioEntry{}).Where("reversal_of_id=?", entry.Id).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		return nil, models.FolioEntryReversedErr
+	}
+
+	reversal, err := entry.Reversal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := postFolioEntries(tx, folio, []*models.FolioEntry{reversal}, username); err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+// postReservationCharges corrects charges of the reservation in its folios to given charges in given transaction.
+// Current charges which are not reversed yet are reversed and the new charges are posted to the open folio
+// of the reservation, nothing is posted if charges are not changed. Reversals of charges on settled folios
+// are posted to the open folio too.
+func postReservationCharges(tx *gorm.DB, reservationId uint64, payerId uint64, charges []*models.FolioEntry, username string) error {
+
+	current := make([]*models.FolioEntry, 0)
+	if err := tx.Where("reservation_id=? AND entry_type IN ? AND reversal_of_id IS NULL", reservationId, chargeEntryTypes).
+		Where("id NOT IN (?)", tx.Model(&models.FolioEntry{}).Select("reversal_of_id").Where("reversal_of_id IS NOT NULL")).
+		Order("id").Find(&current).Error; err != nil {
+		return err
+	}
+
+	if sameCharges(current, charges) {
+		return nil
+	}
+
+	folio, err := reservationFolio(tx, reservationId, payerId, username)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range current {
+
+		target := folio
+		if entry.FolioId != folio.Id {
+
+			if target, err = lockFolio(tx, entry.FolioId); err != nil {
+				return err
+			}
+
+			if target == nil || target.Status == models.FolioSettled {
+				target = folio
+			}
+		}
+
+		if _, err := reverseFolioEntry(tx, entry, target, username); err != nil {
+			return err
+		}
+	}
+
+	return postFolioEntries(tx, folio, charges, username)
+}
+
+// sameCharges reports whether posted charges are equal to the new charges, charges without date
+// are posted at posting time so their dates are not compared.
+func sameCharges(posted []*models.FolioEntry, charges []*models.FolioEntry) bool {
+
+	if len(posted) != len(charges) {
+		return false
+	}
+
+	for i, entry := range posted {
+
+		charge := charges[i]
+		if entry.EntryType != charge.EntryType || entry.Description != charge.Description ||
+			entry.Debit != math.Round(charge.Debit*100)/100 || entry.Credit != math.Round(charge.Credit*100)/100 ||
+			(charge.Date != nil && (entry.Date == nil ||
+				entry.Date.Format(date_utils.DateLayout) != charge.Date.Format(date_utils.DateLayout))) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal_errors/message_keys"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
+)
+
+var (
+	GroupRoomNotFoundErr    = errors.New(message_keys.GroupRoomNotFound)
+	EmptyRoomingListItemErr = errors.New(message_keys.EmptyRoomingListItem)
+)
+
+// GroupReservationRepository stores group bookings, a group is a master reservation
+// and child room reservations which point to the master by ParentId.
+type GroupReservationRepository struct {
+	DbResolver            *tenant_database_resolver.TenantDatabaseResolver
+	ReservationRepository *ReservationRepository
+}
+
+// NewGroupReservationRepository returns new GroupReservationRepository.
+func NewGroupReservationRepository(r *tenant_database_resolver.TenantDatabaseResolver,
+	reservationRepository *ReservationRepository) *GroupReservationRepository {
+
+	return &GroupReservationRepository{
+		DbResolver:            r,
+		ReservationRepository: reservationRepository,
+	}
+}
+
+// Create creates the group master and its child room reservations in one transaction, if one of the rooms is
+// reserved or held by a reservation request for the stay nothing is created and ReservationConflictErr is returned.
+func (r *GroupReservationRepository) Create(ctx context.Context, master *models.Reservation, children []*models.Reservation) (*models.Reservation, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	for _, child := range children {
+
+		conflict, err := r.ReservationRepository.HasConflict(ctx, &dto.RoomRequestDto{
+			RequestType:  dto.CreateReservation,
+			CheckInDate:  child.CheckinDate,
+			CheckOutDate: child.CheckoutDate,
+			RoomId:       child.RoomId,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if conflict {
+			return nil, ReservationConflictErr
+		}
+
+		child.Nights = math.Round(child.CheckoutDate.Sub(*child.CheckinDate).Hours() / 24)
+		if err := r.ReservationRepository.checkOccupancy(ctx, child); err != nil {
+			return nil, err
+		}
+
+		if err := r.ReservationRepository.priceReservation(ctx, child, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	master.IsGroupMaster = true
+	master.CheckStatus = models.Confirmed
+	master.Nights = math.Round(master.CheckoutDate.Sub(*master.CheckinDate).Hours() / 24)
+
+	tx := db.Begin()
+
+	if err := tx.Omit("Children").Create(master).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := addStatusHistory(tx, master.Id, nil, master.CheckStatus, master.CreatedBy); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, child := range children {
+
+		child.ParentId = master.Id
+
+		if err := tx.Create(child).Error; err != nil {
+			tx.Rollback()
+			return nil, translateReservationError(err)
+		}
+
+		if err := addStatusHistory(tx, child.Id, nil, child.CheckStatus, child.CreatedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := logPriceAdjustments(tx, child); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if err := postReservationCharges(tx, child.Id, child.SupervisorId, models.ReservationCharges(child),
+			child.CreatedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, translateReservationError(err)
+	}
+
+	return r.Find(ctx, master.Id)
+}
+
+// Find returns group master with its child reservations, it returns nil if there is no group with given id.
+func (r *GroupReservationRepository) Find(ctx context.Context, id uint64) (*models.Reservation, error) {
+
+	master := models.Reservation{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("Supervisor").Preload("RateCode").
+		Preload("Children", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).
+		Preload("Children.Room").Preload("Children.Supervisor").
+		Preload("Children.Sharers").Preload("Children.Sharers.Guest").Preload("Children.Taxes").
+		Where("id=? AND is_group_master=?", id, true).Find(&master).Error; err != nil {
+		return nil, err
+	}
+
+	if master.Id == 0 {
+		return nil, nil
+	}
+
+	return &master, nil
+}
+
+// ApplyRoomingList assigns guests to child rooms of the group, tentative rooms become confirmed (picked up)
+// and room price is recalculated by the new guest count. Released rooms and rooms without guests can not be assigned,
+// guests must fit the room occupancy.
+func (r *GroupReservationRepository) ApplyRoomingList(ctx context.Context, masterId uint64,
+	items []*dto.RoomingListItemDto, username string) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	for _, item := range items {
+
+		child := models.Reservation{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("AddOns").
+			Where("id=? AND parent_id=?", item.ReservationId, masterId).Find(&child).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if child.Id == 0 || child.CheckStatus.IsReleased() {
+			tx.Rollback()
+			return GroupRoomNotFoundErr
+		}
+
+		if len(item.GuestIds) == 0 {
+			tx.Rollback()
+			return EmptyRoomingListItemErr
+		}
+
+		if item.SupervisorId != 0 {
+			child.SupervisorId = item.SupervisorId
+		}
+		child.GuestCount = uint64(len(item.GuestIds))
+		child.Adults = child.GuestCount
+		child.UpdatedBy = username
+
+		if err := r.ReservationRepository.checkOccupancy(ctx, &child); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Where("reservation_id=?", child.Id).Delete(&models.Sharer{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		for _, guestId := range item.GuestIds {
+
+			sharer := models.Sharer{GuestId: guestId, ReservationId: child.Id}
+			sharer.CreatedBy = username
+			sharer.UpdatedBy = username
+
+			if err := tx.Omit("Guest", "Reservation").Create(&sharer).Error; err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		bookedAt := time.Now()
+		if child.CreatedAt != nil {
+			bookedAt = *child.CreatedAt
+		}
+
+		if err := r.ReservationRepository.priceReservation(ctx, &child, bookedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := replaceNightPrices(tx, &child); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := replaceTaxes(tx, &child); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := logPriceAdjustments(tx, &child); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := replaceAddOns(tx, &child); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := postReservationCharges(tx, child.Id, child.SupervisorId, models.ReservationCharges(&child), username); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		values := map[string]interface{}{
+			"supervisor_id":           child.SupervisorId,
+			"guest_count":             child.GuestCount,
+			"adults":                  child.Adults,
+			"price":                   child.Price,
+			"tax_amount":              child.TaxAmount,
+			"converted_price":         child.ConvertedPrice,
+			"converted_tax_amount":    child.ConvertedTaxAmount,
+			"add_on_amount":           child.AddOnAmount,
+			"converted_add_on_amount": child.ConvertedAddOnAmount,
+			"updated_by":              username,
+		}
+
+		from := child.CheckStatus
+		if from == models.Tentative {
+			values["check_status"] = models.Confirmed
+		}
+
+		if err := tx.Model(&models.Reservation{}).Where("id=?", child.Id).Updates(values).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if from == models.Tentative {
+			if err := addStatusHistory(tx, child.Id, &from, models.Confirmed, username); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// ReleaseUnpickedRooms cancels tentative child rooms of groups whose release date has passed,
+// so rooms which are not picked up by the group return to the inventory. It returns count of released rooms.
+func (r *GroupReservationRepository) ReleaseUnpickedRooms(ctx context.Context, now time.Time, username string) (int, error) {
+
+	ids := make([]uint64, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	masters := db.Model(&models.Reservation{}).Select("id").
+		Where("is_group_master=? AND release_date < ?", true, now)
+
+	if err := db.Model(&models.Reservation{}).
+		Where("parent_id IN (?) AND check_status=?", masters, models.Tentative).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	released := 0
+	from := models.Tentative
+
+	for _, id := range ids {
+
+		tx := db.Begin()
+
+		query := tx.Model(&models.Reservation{}).Where("id=? AND check_status=?", id, from).
+			Updates(map[string]interface{}{
+				"check_status": models.Cancelled,
+				"updated_by":   username,
+			})
+		if query.Error != nil {
+			tx.Rollback()
+			return released, query.Error
+		}
+
+		// room is picked up meanwhile.
+		if query.RowsAffected == 0 {
+			tx.Rollback()
+			continue
+		}
+
+		if err := addStatusHistory(tx, id, &from, models.Cancelled, username); err != nil {
+			tx.Rollback()
+			return released, err
+		}
+
+		// released rooms are not charged.
+		if err := postReservationCharges(tx, id, 0, nil, username); err != nil {
+			tx.Rollback()
+			return released, err
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			return released, err
+		}
+		released++
+	}
+
+	return released, nil
+}
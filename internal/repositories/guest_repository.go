@@ -2,10 +2,17 @@ package repositories
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
 )
 
 type GuestRepository struct {
@@ -61,7 +68,13 @@ func (r *GuestRepository) FindByNationalId(ctx context.Context, id string) (*mod
 	model := models.Guest{}
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	if tx := db.Where(models.Guest{NationalId: id}).Find(&model); tx.Error != nil {
+	// documents are encrypted, they are found by their blind index.
+	index, err := models.DocumentIndex(ctx, id)
+	if err != nil || index == "" {
+		return nil, err
+	}
+
+	if tx := db.Where("national_id_index=?", index).Find(&model); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -77,7 +90,13 @@ func (r *GuestRepository) FindByPassportNumber(ctx context.Context, passNumber s
 	model := models.Guest{}
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	if tx := db.Where(models.Guest{PassportNumber: passNumber}).Find(&model); tx.Error != nil {
+	// documents are encrypted, they are found by their blind index.
+	index, err := models.DocumentIndex(ctx, passNumber)
+	if err != nil || index == "" {
+		return nil, err
+	}
+
+	if tx := db.Where("passport_index=?", index).Find(&model); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -105,3 +124,451 @@ func (r *GuestRepository) FindAll(ctx context.Context, input *dto.PaginationFilt
 	db := r.DbResolver.GetTenantDB(ctx)
 	return paginatedList(&models.Guest{}, db, input)
 }
+
+// FindDuplicates returns a page of pairs of guests which are probably the same person and score at least minScore.
+// Pairs are limited to guestId if it is not zero.
+func (r *GuestRepository) FindDuplicates(ctx context.Context, minScore float64, guestId uint64,
+	input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	guests, err := findDuplicateCandidates(db, guestId)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := models.FindGuestDuplicates(guests, minScore, guestId)
+
+	page, pageSize := input.Page, input.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = len(duplicates)
+	}
+
+	records := make([]*models.GuestDuplicate, 0)
+	if offset := (page - 1) * pageSize; offset < len(duplicates) {
+		records = duplicates[offset:int(math.Min(float64(offset+pageSize), float64(len(duplicates))))]
+	}
+
+	return &commons.PaginatedResult{
+		Records:      records,
+		Page:         uint(page),
+		PerPage:      uint(pageSize),
+		TotalRecords: uint(len(duplicates)),
+		TotalPages:   uint(math.Ceil(float64(len(duplicates)) / math.Max(float64(pageSize), 1))),
+	}, nil
+}
+
+// duplicateCandidatesSQL selects ids of guests which share a block key of models.FindGuestDuplicates with at least one
+// and at most models.MaxDuplicateBlockSize other guests. Keys are built like the keys of models.FindGuestDuplicates,
+// documents are matched by their blind indexes. Blocks are limited to keys of a guest if the guest id is not zero.
+const duplicateCandidatesSQL = `
+WITH candidates AS (
+	SELECT * FROM guests WHERE anonymized_at IS NULL
+), keys AS (
+	SELECT id, 'national_id:' || national_id_index AS key FROM candidates WHERE national_id_index <> ''
+	UNION ALL
+	SELECT id, 'passport:' || passport_index FROM candidates WHERE passport_index <> ''
+	UNION ALL
+	SELECT id, 'email:' || lower(trim(email)) FROM candidates WHERE trim(email) <> ''
+	UNION ALL
+	SELECT id, 'phone:' || right(phones.digits, 10) FROM candidates,
+		LATERAL (VALUES (regexp_replace(cell_number, '[^0-9]', '', 'g')), (regexp_replace(phone_number, '[^0-9]', '', 'g'))) phones(digits)
+	WHERE length(phones.digits) >= 7
+	UNION ALL
+	SELECT id, 'name:' || left(names.last, 3) || left(names.first, 1) FROM candidates,
+		LATERAL (VALUES (regexp_replace(lower(last_name), '[^[:alpha:]]', '', 'g'), regexp_replace(lower(first_name), '[^[:alpha:]]', '', 'g'))) names(last, first)
+	WHERE length(names.last) >= 3 AND names.first <> ''
+), blocks AS (
+	SELECT key FROM keys
+	WHERE @guest_id = 0 OR key IN (SELECT key FROM keys WHERE id = @guest_id)
+	GROUP BY key
+	HAVING count(DISTINCT id) BETWEEN 2 AND @max_block_size
+)
+SELECT DISTINCT keys.id FROM keys INNER JOIN blocks ON blocks.key = keys.key`
+
+// findDuplicateCandidates returns guests which may have a duplicate, they are selected by block keys in the database,
+// so guests which can not match are neither loaded nor decrypted.
+func findDuplicateCandidates(db *gorm.DB, guestId uint64) ([]*models.Guest, error) {
+
+	guests := make([]*models.Guest, 0)
+	candidates := db.Raw(duplicateCandidatesSQL, map[string]interface{}{
+		"guest_id":       guestId,
+		"max_block_size": models.MaxDuplicateBlockSize,
+	})
+
+	if tx := db.Where("id IN (?)", candidates).Order("id").Find(&guests); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return guests, nil
+}
+
+// guestReferences are columns which refer to guests, they are moved to the surviving guest of a merge.
+var guestReferences = []struct {
+	model  interface{}
+	table  string
+	column string
+}{
+	{&models.Reservation{}, "reservations", "supervisor_id"},
+	{&models.Sharer{}, "sharers", "guest_id"},
+	{&models.Payment{}, "payments", "payer_id"},
+	{&models.Folio{}, "folios", "payer_id"},
+	{&models.Charge{}, "charges", "payer_id"},
+	{&models.Wallet{}, "wallets", "guest_id"},
+	{&models.Invoice{}, "invoices", "guest_id"},
+	{&models.PromotionRedemption{}, "promotion_redemptions", "guest_id"},
+	{&models.GuestEmail{}, "guest_emails", "guest_id"},
+}
+
+// Merge merges the merged guest into the survivor in one transaction. Blank fields of the survivor are filled from the
+// merged guest, reservations, sharers, payments and other references are moved to the survivor, the merged guest is
+// deleted and a GuestMergeLog is written. It returns nil if one of the guests does not exist.
+func (r *GuestRepository) Merge(ctx context.Context, survivorId, mergedId uint64, username string) (*models.GuestMergeLog, error) {
+
+	if survivorId == mergedId {
+		return nil, models.SameGuestMergeErr
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	// guests are locked in order of their ids, so opposite merges of two guests do not deadlock.
+	guests := make(map[uint64]*models.Guest)
+	for _, id := range sortedIds(survivorId, mergedId) {
+		guest := models.Guest{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&guest).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if guest.Id == 0 {
+			tx.Rollback()
+			return nil, nil
+		}
+		guests[id] = &guest
+	}
+
+	survivor, merged := guests[survivorId], guests[mergedId]
+	snapshot, err := json.Marshal(merged)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	survivor.FillFrom(merged)
+	survivor.SetUpdatedBy(username)
+	if err := tx.Save(survivor).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// the merged guest is removed from reservations which the survivor already shares, so a reservation does not
+	// have the survivor twice.
+	shared := tx.Model(&models.Sharer{}).Select("reservation_id").Where("guest_id=?", survivorId)
+	if err := tx.Where("guest_id=? AND reservation_id IN (?)", mergedId, shared).Delete(&models.Sharer{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	moved, err := moveGuestReferences(tx, survivorId, mergedId, username)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Where("id=?", mergedId).Delete(&models.Guest{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	references, _ := json.Marshal(moved)
+	log := models.GuestMergeLog{
+		SurvivorId:      survivorId,
+		MergedId:        mergedId,
+		MergedGuest:     models.EncryptedString(snapshot),
+		MovedReferences: string(references),
+	}
+	log.CreatedBy = username
+	log.UpdatedBy = username
+
+	if err := tx.Create(&log).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return &log, nil
+}
+
+// Export returns everything which is held about the guest, it returns nil if the guest does not exist.
+func (r *GuestRepository) Export(ctx context.Context, id uint64) (*dto.GuestExportDto, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	guest, err := r.Find(ctx, id)
+	if err != nil || guest == nil {
+		return nil, err
+	}
+
+	result := &dto.GuestExportDto{
+		ExportedAt:   time.Now(),
+		Guest:        guest,
+		Reservations: make([]*models.Reservation, 0),
+		Sharers:      make([]*models.Sharer, 0),
+		Payments:     make([]*models.Payment, 0),
+		Emails:       make([]*models.GuestEmail, 0),
+		Invoices:     make([]*models.Invoice, 0),
+	}
+
+	shared := db.Model(&models.Sharer{}).Select("reservation_id").Where("guest_id=?", id)
+	if err := db.Where("supervisor_id=? OR id IN (?)", id, shared).Order("id").Find(&result.Reservations).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("guest_id=?", id).Order("id").Find(&result.Sharers).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("payer_id=?", id).Order("id").Find(&result.Payments).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("guest_id=?", id).Order("id").Find(&result.Emails).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("guest_id=?", id).Order("id").Find(&result.Invoices).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Anonymize irreversibly replaces personal data of the guest with a random pseudonym in one transaction, emails which
+// are sent to the guest, receipt emails of its charges and snapshots of its merges are erased too. Reservations and
+// financial records are kept for accounting. It returns nil if the guest does not exist.
+func (r *GuestRepository) Anonymize(ctx context.Context, id uint64, username string) (*models.Guest, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	guest := models.Guest{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&guest).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if guest.Id == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	if guest.IsAnonymized() {
+		tx.Rollback()
+		return &guest, nil
+	}
+
+	pseudonym, err := guestPseudonym()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	guest.Anonymize(pseudonym, time.Now())
+	guest.SetUpdatedBy(username)
+	if err := tx.Save(&guest).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&models.GuestEmail{}).Where("guest_id=?", id).
+		Updates(map[string]interface{}{"recipient": "", "body": "", "updated_by": username}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&models.Charge{}).Where("payer_id=?", id).
+		Updates(map[string]interface{}{"receipt_email": "", "updated_by": username}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&models.GuestMergeLog{}).Where("survivor_id=? OR merged_id=?", id, id).
+		Updates(map[string]interface{}{"merged_guest": "", "updated_by": username}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return &guest, nil
+}
+
+// FindExpired returns ids of guests who are not anonymized and have not stayed or been created after cutoff.
+func (r *GuestRepository) FindExpired(ctx context.Context, cutoff time.Time, limit int) ([]uint64, error) {
+
+	ids := make([]uint64, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	supervised := db.Model(&models.Reservation{}).Select("supervisor_id").Where("checkout_date>=?", cutoff)
+	shared := db.Model(&models.Sharer{}).Select("sharers.guest_id").
+		Joins("JOIN reservations ON reservations.id = sharers.reservation_id").Where("reservations.checkout_date>=?", cutoff)
+
+	query := db.Model(&models.Guest{}).
+		Where("anonymized_at IS NULL AND created_at<?", cutoff).
+		Where("id NOT IN (?) AND id NOT IN (?)", supervised, shared).
+		Order("id").Limit(limit).Pluck("id", &ids)
+
+	if query.Error != nil {
+		return nil, query.Error
+	}
+
+	return ids, nil
+}
+
+// CreateEmail records an email which is sent to a guest.
+func (r *GuestRepository) CreateEmail(ctx context.Context, email *models.GuestEmail) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return db.Create(email).Error
+}
+
+// FindRetentionPolicy returns the retention policy of the tenant, it returns nil if it is not set.
+func (r *GuestRepository) FindRetentionPolicy(ctx context.Context) (*models.RetentionPolicy, error) {
+
+	model := models.RetentionPolicy{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Order("id").Limit(1).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+// SaveRetentionPolicy creates or updates the retention policy of the tenant, a tenant has one policy.
+func (r *GuestRepository) SaveRetentionPolicy(ctx context.Context, policy *models.RetentionPolicy) (*models.RetentionPolicy, error) {
+
+	current, err := r.FindRetentionPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if current == nil {
+		if err := db.Create(policy).Error; err != nil {
+			return nil, err
+		}
+		return policy, nil
+	}
+
+	current.GuestRetentionDays = policy.GuestRetentionDays
+	current.UpdatedBy = policy.UpdatedBy
+	if err := db.Save(current).Error; err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// Reencrypt re-encrypts documents of guests and merge snapshots which are not encrypted with the active data key of
+// the tenant and indexes documents which do not have blind indexes, it returns number of re-encrypted rows.
+// Values are encrypted again when they are saved.
+func (r *GuestRepository) Reencrypt(ctx context.Context, limit int) (int, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	prefix, err := models.ActiveCiphertextPrefix(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	guests := make([]*models.Guest, 0)
+	query := db.Where("(national_id <> '' AND COALESCE(national_id_index, '') = '') OR " +
+		"(passport_number <> '' AND COALESCE(passport_index, '') = '')")
+
+	logs := make([]*models.GuestMergeLog, 0)
+	logsQuery := db.Where("1 = 0")
+
+	if prefix != "" {
+		like := prefix + "%"
+		query = query.Or("(national_id <> '' AND national_id NOT LIKE ?) OR "+
+			"(passport_number <> '' AND passport_number NOT LIKE ?) OR "+
+			"(date_of_birth IS NOT NULL AND date_of_birth NOT LIKE ?)", like, like, like)
+		logsQuery = db.Where("merged_guest <> '' AND merged_guest NOT LIKE ?", like)
+	}
+
+	if err := query.Order("id").Limit(limit).Find(&guests).Error; err != nil {
+		return 0, err
+	}
+
+	if err := logsQuery.Order("id").Limit(limit).Find(&logs).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, guest := range guests {
+		if err := db.Save(guest).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	for _, log := range logs {
+		if err := db.Model(log).Update("merged_guest", log.MergedGuest).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// moveGuestReferences moves guestReferences of the merged guest to the survivor in given transaction, it returns
+// number of moved rows of every column.
+func moveGuestReferences(tx *gorm.DB, survivorId, mergedId uint64, username string) (map[string]int64, error) {
+
+	moved := make(map[string]int64)
+	for _, reference := range guestReferences {
+		query := tx.Model(reference.model).Where(reference.column+"=?", mergedId).
+			Updates(map[string]interface{}{reference.column: survivorId, "updated_by": username})
+		if query.Error != nil {
+			return nil, query.Error
+		}
+		moved[reference.table+"."+reference.column] = query.RowsAffected
+	}
+
+	return moved, nil
+}
+
+// guestPseudonym returns a random pseudonym, it is not derived from the guest so it can not be reversed.
+func guestPseudonym() (string, error) {
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "anon-" + hex.EncodeToString(b), nil
+}
+
+func sortedIds(a, b uint64) []uint64 {
+
+	if a > b {
+		return []uint64{b, a}
+	}
+
+	return []uint64{a, b}
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestMoveGuestReferences(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	statements := make([]string, 0)
+	err := db.Callback().Update().After("gorm:update").Register("test:statements", func(db *gorm.DB) {
+		statements = append(statements, db.Statement.SQL.String())
+	})
+	assert.Nil(t, err)
+
+	moved, err := moveGuestReferences(db, 1, 2, "admin")
+	assert.Nil(t, err)
+	assert.Len(t, statements, len(guestReferences))
+	assert.Contains(t, moved, "guest_emails.guest_id")
+	assert.Contains(t, statements, `UPDATE "guest_emails" SET "guest_id"=$1,"updated_by"=$2,"updated_at"=$3 WHERE guest_id=$4`)
+}
+
+func TestFindDuplicateCandidates(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	statement := ""
+	err := db.Callback().Query().After("gorm:query").Register("test:statements", func(db *gorm.DB) {
+		statement = db.Statement.SQL.String()
+	})
+	assert.Nil(t, err)
+
+	_, err = findDuplicateCandidates(db, 7)
+	assert.Nil(t, err)
+	assert.Contains(t, statement, `SELECT * FROM "guests" WHERE id IN (`)
+	assert.Contains(t, statement, "WHERE $1 = 0 OR key IN (SELECT key FROM keys WHERE id = $2)")
+	assert.Contains(t, statement, "HAVING count(DISTINCT id) BETWEEN 2 AND $3")
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm/clause"
+	"reservation-api/internal/models"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
+)
+
+type InvoiceRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewInvoiceRepository returns new InvoiceRepository.
+func NewInvoiceRepository(r *tenant_database_resolver.TenantDatabaseResolver) *InvoiceRepository {
+
+	return &InvoiceRepository{DbResolver: r}
+}
+
+// FindReservation returns the reservation with its guest, hotel and folio entries to be invoiced,
+// it returns nil if the reservation does not exist.
+func (r *InvoiceRepository) FindReservation(ctx context.Context, id uint64) (*models.Reservation, []*models.FolioEntry, error) {
+
+	reservation := models.Reservation{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("Supervisor").Preload("Hotel.City").Preload("Hotel.Province").
+		Preload("Room.RoomType.Hotel.City").Preload("Room.RoomType.Hotel.Province").
+		Where("id=?", id).Find(&reservation).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if reservation.Id == 0 {
+		return nil, nil, nil
+	}
+
+	entries := make([]*models.FolioEntry, 0)
+	if err := db.Where("reservation_id=?", id).Order("id").Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &reservation, entries, nil
+}
+
+// FindLatest returns the last issued invoice of the reservation, it returns nil if the reservation has no invoice.
+func (r *InvoiceRepository) FindLatest(ctx context.Context, reservationId uint64) (*models.Invoice, error) {
+
+	invoice := models.Invoice{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Where("reservation_id=?", reservationId).Order("id desc").Limit(1).Find(&invoice).Error; err != nil {
+		return nil, err
+	}
+
+	if invoice.Id == 0 {
+		return nil, nil
+	}
+
+	return &invoice, nil
+}
+
+// Issue numbers the invoice by the next number of its hotel and stores it. Sequence row of the hotel is locked
+// until the invoice is stored, so concurrent invoices get consecutive numbers. render is called with the numbered
+// invoice to render and store its document, if it fails nothing is stored and the number is not used.
+// If the last invoice of the reservation already covers entries of the invoice, it is returned and nothing is issued,
+// so concurrent requests do not issue two invoices for the same folios.
+func (r *InvoiceRepository) Issue(ctx context.Context, invoice *models.Invoice, render func(invoice *models.Invoice) error) (*models.Invoice, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	sequence := models.InvoiceSequence{HotelId: invoice.HotelId}
+	sequence.SetAudit(invoice.CreatedBy)
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("hotel_id=?", invoice.HotelId).Find(&sequence).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	latest := models.Invoice{}
+	if err := tx.Where("reservation_id=?", invoice.ReservationId).Order("id desc").Limit(1).Find(&latest).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if latest.Id != 0 && latest.LastEntryId >= invoice.LastEntryId {
+		tx.Rollback()
+		return &latest, nil
+	}
+
+	now := time.Now()
+	invoice.Number = sequence.LastNumber + 1
+	invoice.InvoiceNo = models.FormatInvoiceNumber(invoice.HotelId, invoice.Number)
+	invoice.IssuedAt = &now
+
+	if err := render(invoice); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(&models.InvoiceSequence{}).Where("id=?", sequence.Id).Updates(map[string]interface{}{
+		"last_number": invoice.Number,
+		"updated_by":  invoice.CreatedBy,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Create(invoice).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
@@ -2,8 +2,13 @@ package repositories
 
 import (
 	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
 	"reservation-api/internal/models"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"strings"
+	"time"
 )
 
 // PaymentRepository
@@ -15,39 +20,93 @@ func NewPaymentRepository(r *tenant_database_resolver.TenantDatabaseResolver) *P
 	return &PaymentRepository{r}
 }
 
+// Create creates the payment and posts it to the open folio of its reservation, CREDIT payments are posted
+// as payments and DEBIT payments as adjustment charges.
 func (p *PaymentRepository) Create(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
 
 	db := p.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
 
-	if err := db.Create(payment).Error; err != nil {
+	if err := createPayment(tx, payment, "payment"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
 	return payment, nil
 }
 
+// Find returns the payment, it returns nil if the payment does not exist.
 func (p *PaymentRepository) Find(ctx context.Context, id uint64) (*models.Payment, error) {
 
 	db := p.DbResolver.GetTenantDB(ctx)
 	result := models.Payment{}
 
-	if err := db.Where("id=?").Find(&result).
-		Preload("Reservation").Preload("Payer").Error; err != nil {
+	if err := db.Preload("Reservation").Preload("Payer").Where("id=?", id).Find(&result).Error; err != nil {
 		return nil, err
 	}
 
+	if result.Id == 0 {
+		return nil, nil
+	}
+
 	return &result, nil
 }
 
-func (p *PaymentRepository) Delete(ctx context.Context, id uint64) error {
+// Refund refunds amount of the payment by a linked negative payment and posts it to the open folio of the reservation.
+// The payment row is locked until the refund is stored, so concurrent refunds never refund more than the payment.
+// refundInGateway is called with the locked payment and its refund before the refund is stored, card payments are
+// refunded in the payment gateway by it. It returns nil if the payment does not exist.
+func (p *PaymentRepository) Refund(ctx context.Context, id uint64, amount float64, reason models.RefundReason, username string,
+	refundInGateway func(payment *models.Payment, refund *models.Payment) error) (*models.Payment, error) {
 
 	db := p.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
 
-	if err := db.Model(&models.Payment{}).Where("id=?", id).Delete(&models.Payment{}).Error; err != nil {
-		return err
+	payment := models.Payment{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&payment).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if payment.Id == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	refund, err := payment.Refund(amount, reason, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	refund.SetAudit(username)
+
+	if err := refundInGateway(&payment, refund); err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	return nil
+	if err := tx.Model(&models.Payment{}).Where("id=?", id).Updates(map[string]interface{}{
+		"refunded_amount": payment.RefundedAmount,
+		"updated_by":      username,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := createPayment(tx, refund, "refund"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return refund, nil
 }
 
 func (p *PaymentRepository) GetListByReservationID(ctx context.Context, reservationID uint64, paymentType *models.PaymentType) ([]*models.Payment, error) {
@@ -67,20 +126,239 @@ func (p *PaymentRepository) GetListByReservationID(ctx context.Context, reservat
 	return result, nil
 }
 
-func (p *PaymentRepository) GetBalance(ctx context.Context, reservationID uint64, paymentType *models.PaymentType) (float64, error) {
+// CreateCharge stores a new charge of the reservation, the charge is paid by the reservation supervisor if it has no payer.
+// FolioAmount of the charge is in currency of the folio and whole unpaid balance is charged if it is zero, folios of
+// the reservation are locked while the amount is validated, so open charges never exceed the balance together.
+// The card is charged in currency of the reservation by its locked exchange rate, or in defaultCurrency if the
+// reservation does not have a currency. It returns InvalidChargeAmountErr if the amount is more than unpaid balance.
+func (p *PaymentRepository) CreateCharge(ctx context.Context, charge *models.Charge, defaultCurrency string) (*models.Charge, error) {
 
-	var result float64
 	db := p.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
 
-	query := db.Model(&models.Payment{}).Where("reservation_id=?", reservationID)
+	reservation := models.Reservation{}
+	if err := tx.Select("id", "supervisor_id", "currency_id", "exchange_rate").Where("id=?", charge.ReservationId).
+		Find(&reservation).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	if paymentType != nil {
-		query = query.Where("payment_type=?", paymentType)
+	if charge.PayerId == 0 {
+		charge.PayerId = reservation.SupervisorId
+	}
+
+	balance, err := unchargedBalance(tx, charge.ReservationId)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if charge.FolioAmount == 0 {
+		charge.FolioAmount = balance
+	}
+
+	if charge.FolioAmount <= 0 || charge.FolioAmount > balance {
+		tx.Rollback()
+		return nil, models.InvalidChargeAmountErr
+	}
+
+	charge.Currency = strings.ToUpper(defaultCurrency)
+	charge.ExchangeRate = 1
+
+	if reservation.CurrencyId != 0 {
+
+		currency := models.Currency{}
+		if err := tx.Select("id", "code").Where("id=?", reservation.CurrencyId).Find(&currency).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if currency.Code == "" {
+			tx.Rollback()
+			return nil, models.ChargeCurrencyErr
+		}
+
+		charge.Currency = strings.ToUpper(currency.Code)
+		if reservation.ExchangeRate != 0 {
+			charge.ExchangeRate = reservation.ExchangeRate
+		}
+	}
+
+	charge.Amount = charge.ToChargeAmount(charge.FolioAmount)
+
+	if err := tx.Omit("Reservation").Create(charge).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
 	}
 
-	if err := query.Select("SUM(amount)").Scan(&result).Error; err != nil {
+	return charge, nil
+}
+
+// unchargedBalance locks folios of the reservation and returns their balance minus amounts of pending and authorized
+// charges, the rows stay locked until the transaction ends.
+func unchargedBalance(tx *gorm.DB, reservationId uint64) (float64, error) {
+
+	folios := make([]*models.Folio, 0)
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("reservation_id=?", reservationId).Order("id").
+		Find(&folios).Error; err != nil {
 		return 0, err
 	}
 
-	return result, nil
+	balance := 0.0
+	for _, folio := range folios {
+		balance += folio.Balance
+	}
+
+	var open float64
+	if err := tx.Model(&models.Charge{}).Select("COALESCE(SUM(folio_amount), 0)").
+		Where("reservation_id=? AND status IN ?", reservationId, []models.ChargeStatus{models.ChargePending, models.ChargeAuthorized}).
+		Scan(&open).Error; err != nil {
+		return 0, err
+	}
+
+	return math.Round((balance-open)*100) / 100, nil
+}
+
+// FindChargeByPayment returns the charge which is paid by the payment, it returns nil if the payment is not a charge.
+func (p *PaymentRepository) FindChargeByPayment(ctx context.Context, paymentId uint64) (*models.Charge, error) {
+
+	db := p.DbResolver.GetTenantDB(ctx)
+	charge := models.Charge{}
+
+	if err := db.Where("payment_id=?", paymentId).Find(&charge).Error; err != nil {
+		return nil, err
+	}
+
+	if charge.Id == 0 {
+		return nil, nil
+	}
+
+	return &charge, nil
+}
+
+// FindCharge returns the charge, it returns nil if the charge does not exist.
+func (p *PaymentRepository) FindCharge(ctx context.Context, id uint64) (*models.Charge, error) {
+
+	db := p.DbResolver.GetTenantDB(ctx)
+	charge := models.Charge{}
+
+	if err := db.Where("id=?", id).Find(&charge).Error; err != nil {
+		return nil, err
+	}
+
+	if charge.Id == 0 {
+		return nil, nil
+	}
+
+	return &charge, nil
+}
+
+// UpdateCharge applies state of the charge in the gateway to the stored charge. The charge row is locked, so a captured
+// charge is paid once even if the gateway response and its webhook are applied together. When the charge is captured,
+// a CREDIT payment of the captured amount is created and posted to the reservation folio.
+func (p *PaymentRepository) UpdateCharge(ctx context.Context, id uint64, update *models.Charge, username string) (*models.Charge, error) {
+
+	db := p.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	charge := models.Charge{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&charge).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if charge.Id == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	if charge.GatewayReference == "" {
+		charge.GatewayReference = update.GatewayReference
+	}
+
+	if charge.Transition(update.Status) {
+
+		charge.AmountCaptured = update.AmountCaptured
+		charge.FailureMessage = update.FailureMessage
+
+		if charge.Status == models.ChargeCaptured && charge.AmountCaptured > 0 {
+
+			now := time.Now()
+			// captured amount is in currency of the charge, the folio is credited in its own currency.
+			payment := &models.Payment{
+				Amount:           charge.ToFolioAmount(charge.AmountCaptured),
+				PaymentType:      models.CREDIT,
+				PayerId:          charge.PayerId,
+				PaymentDate:      &now,
+				ReservationId:    charge.ReservationId,
+				GatewayReference: charge.GatewayReference,
+			}
+			payment.SetAudit(username)
+
+			if err := createPayment(tx, payment, "card payment"); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			charge.PaymentId = &payment.Id
+		}
+	}
+
+	charge.SetUpdatedBy(username)
+	if err := tx.Omit("Reservation").Save(&charge).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return &charge, nil
+}
+
+// createPayment creates the payment and posts it to the open folio of its reservation in given transaction.
+func createPayment(tx *gorm.DB, payment *models.Payment, description string) error {
+
+	folio, err := reservationFolio(tx, payment.ReservationId, payment.PayerId, payment.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	return postPayment(tx, folio, payment, description)
+}
+
+// postPayment creates the payment and posts it to the locked folio in given transaction, CREDIT payments are posted
+// as payments and DEBIT payments as adjustment charges.
+func postPayment(tx *gorm.DB, folio *models.Folio, payment *models.Payment, description string) error {
+
+	if err := tx.Create(payment).Error; err != nil {
+		return err
+	}
+
+	entry := &models.FolioEntry{
+		EntryType:   models.PaymentEntry,
+		Description: description,
+		Date:        payment.PaymentDate,
+		Credit:      payment.Amount,
+		PaymentId:   &payment.Id,
+	}
+
+	if payment.PaymentType == models.DEBIT {
+		entry.EntryType = models.AdjustmentEntry
+		entry.Debit, entry.Credit = payment.Amount, 0
+	}
+
+	// refunds have negative amount and are posted on the other side of their payment.
+	if payment.RefundOfId != nil {
+		entry.Debit, entry.Credit = math.Abs(entry.Credit), math.Abs(entry.Debit)
+		if payment.PaymentType == models.CREDIT {
+			entry.EntryType = models.RefundEntry
+		}
+	}
+
+	return postFolioEntries(tx, folio, []*models.FolioEntry{entry}, payment.CreatedBy)
 }
@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
+)
+
+type PricingRuleRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewPricingRuleRepository returns new PricingRuleRepository.
+func NewPricingRuleRepository(r *tenant_database_resolver.TenantDatabaseResolver) *PricingRuleRepository {
+
+	return &PricingRuleRepository{DbResolver: r}
+}
+
+func (r *PricingRuleRepository) Create(ctx context.Context, model *models.PricingRule) (*models.PricingRule, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *PricingRuleRepository) Update(ctx context.Context, model *models.PricingRule) (*models.PricingRule, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	// active and scope fields can be reset to zero values, so all columns are saved.
+	if tx := db.Select("*").Omit("created_at", "created_by").Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *PricingRuleRepository) Find(ctx context.Context, id uint64) (*models.PricingRule, error) {
+
+	model := models.PricingRule{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *PricingRuleRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.PricingRule{}, db, input)
+}
+
+// Delete removes PricingRule, logged adjustments of the rule and prices of existing reservations are kept.
+func (r *PricingRuleRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.PricingRule{}).Where("id=?", id).Delete(&models.PricingRule{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
+
+// FindAdjustments returns logged pricing rule adjustments which match the filter, the latest first.
+func (r *PricingRuleRepository) FindAdjustments(ctx context.Context, filter *dto.PriceAdjustmentFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	logs := make([]*models.PriceAdjustmentLog, 0)
+
+	query := db.Model(&models.PriceAdjustmentLog{}).Order("id desc")
+
+	if filter.PricingRuleId != 0 {
+		query = query.Where("pricing_rule_id=?", filter.PricingRuleId)
+	}
+
+	if filter.ReservationId != 0 {
+		query = query.Where("reservation_id=?", filter.ReservationId)
+	}
+
+	if filter.RoomTypeId != 0 {
+		query = query.Where("room_type_id=?", filter.RoomTypeId)
+	}
+
+	if filter.DateFrom != nil {
+		query = query.Where("date >= ?", filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query = query.Where("date <= ?", filter.DateTo)
+	}
+
+	if err := query.Scan(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return paginateWithFilter(query, logs, filter, filter.Page, filter.PageSize, filter.IgnorePagination), nil
+}
+
+// nightPricing holds pricing rules of a room and live occupancy of its room type for nights of a stay.
+type nightPricing struct {
+	roomId            uint64
+	roomTypeId        uint64
+	rules             []*models.PricingRule
+	occupancy         map[time.Time]float64
+	daysBeforeArrival int64
+}
+
+// loadNightPricing loads active pricing rules of the room type of given room and occupancy of the room type
+// in nights of the stay. Reservation with id excludeReservationId is not counted, so a reservation which is being
+// edited is priced by occupancy of other reservations. bookedAt is the time which days before arrival are counted from.
+func (r *PricingRuleRepository) loadNightPricing(ctx context.Context, roomId uint64, checkin, checkout, bookedAt time.Time,
+	excludeReservationId uint64) (*nightPricing, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	room := models.Room{}
+	if err := db.Select("id", "room_type_id").Where("id=?", roomId).Find(&room).Error; err != nil {
+		return nil, err
+	}
+
+	pricing := &nightPricing{
+		roomId:            roomId,
+		roomTypeId:        room.RoomTypeId,
+		rules:             make([]*models.PricingRule, 0),
+		occupancy:         make(map[time.Time]float64),
+		daysBeforeArrival: int64(date_utils.TruncateToDay(checkin).Sub(date_utils.TruncateToDay(bookedAt)).Hours() / 24),
+	}
+
+	if err := db.Where("active=?", true).
+		Where("room_type_id IS NULL OR room_type_id=?", room.RoomTypeId).
+		Order("priority, id").Find(&pricing.rules).Error; err != nil {
+		return nil, err
+	}
+
+	// occupancy is not needed if there is no rule to evaluate.
+	if len(pricing.rules) == 0 {
+		return pricing, nil
+	}
+
+	var roomCount int64
+	if err := db.Model(&models.Room{}).Where("room_type_id=?", room.RoomTypeId).Count(&roomCount).Error; err != nil {
+		return nil, err
+	}
+
+	stays := make([]*models.Reservation, 0)
+	query := db.Model(&models.Reservation{}).Select("id", "checkin_date", "checkout_date").Where(`
+		room_id IN (?)
+		AND is_group_master = ?
+		AND id <> ?
+		AND checkin_date < ?
+		AND checkout_date > ?
+	`, db.Model(&models.Room{}).Select("id").Where("room_type_id=?", room.RoomTypeId), false,
+		excludeReservationId, checkout, checkin)
+
+	if err := activeReservations(query).Find(&stays).Error; err != nil {
+		return nil, err
+	}
+
+	pricing.occupancy = nightOccupancy(date_utils.Nights(checkin, checkout), roomCount, stays)
+
+	return pricing, nil
+}
+
+// adjust applies pricing rules on given nights and returns logs of the applied adjustments.
+func (p *nightPricing) adjust(nights []*dto.NightPriceDto, username string) []*models.PriceAdjustmentLog {
+
+	logs := make([]*models.PriceAdjustmentLog, 0)
+
+	for _, night := range adjustNightPrices(nights, p.rules, p.occupancy, p.daysBeforeArrival) {
+
+		date := night.Date
+		log := &models.PriceAdjustmentLog{
+			PricingRuleId: *night.PricingRuleId,
+			RoomId:        p.roomId,
+			RoomTypeId:    p.roomTypeId,
+			RateCodeId:    night.RateCodeId,
+			Date:          &date,
+			Occupancy:     night.Occupancy,
+			BasePrice:     night.BasePrice,
+			AdjustedPrice: night.Price,
+		}
+		log.CreatedBy = username
+		log.UpdatedBy = username
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+// logPriceAdjustments stores pricing rule adjustments of the reservation in given transaction.
+func logPriceAdjustments(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if len(reservation.PriceAdjustments) == 0 {
+		return nil
+	}
+
+	for _, log := range reservation.PriceAdjustments {
+		log.Id = 0
+		log.ReservationId = reservation.Id
+	}
+
+	return tx.Create(&reservation.PriceAdjustments).Error
+}
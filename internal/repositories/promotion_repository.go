@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+)
+
+type PromotionRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewPromotionRepository returns new PromotionRepository.
+func NewPromotionRepository(r *tenant_database_resolver.TenantDatabaseResolver) *PromotionRepository {
+
+	return &PromotionRepository{DbResolver: r}
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, model *models.Promotion) (*models.Promotion, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	model.Code = models.NormalizeCode(model.Code)
+	model.UsedCount = 0
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+// Update updates promotion and replaces its eligible rate codes and room types, UsedCount is only
+// changed by redemptions.
+func (r *PromotionRepository) Update(ctx context.Context, model *models.Promotion) (*models.Promotion, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	model.Code = models.NormalizeCode(model.Code)
+
+	tx := db.Begin()
+
+	if err := tx.Select("*").Omit("created_at", "created_by", "used_count", "RateCodes", "RoomTypes").
+		Updates(&model).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Where("promotion_id=?", model.Id).Delete(&models.PromotionRateCode{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Where("promotion_id=?", model.Id).Delete(&models.PromotionRoomType{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, rateCode := range model.RateCodes {
+		rateCode.Id = 0
+		rateCode.PromotionId = model.Id
+		if err := tx.Create(rateCode).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	for _, roomType := range model.RoomTypes {
+		roomType.Id = 0
+		roomType.PromotionId = model.Id
+		if err := tx.Create(roomType).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, model.Id)
+}
+
+func (r *PromotionRepository) Find(ctx context.Context, id uint64) (*models.Promotion, error) {
+
+	model := models.Promotion{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Preload("RateCodes").Preload("RoomTypes").Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *PromotionRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.Promotion{}, db, input)
+}
+
+// Delete removes promotion and its eligibility, redemptions are kept for reservations which used it.
+func (r *PromotionRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	if err := tx.Where("promotion_id=?", id).Delete(&models.PromotionRateCode{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("promotion_id=?", id).Delete(&models.PromotionRoomType{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("id=?", id).Delete(&models.Promotion{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// findPromotionByCode returns promotion with its eligibility by code, it returns nil if there is no such promotion.
+func findPromotionByCode(db *gorm.DB, code string) (*models.Promotion, error) {
+
+	promotion := models.Promotion{}
+
+	if err := db.Preload("RateCodes").Preload("RoomTypes").
+		Where("code=?", models.NormalizeCode(code)).Find(&promotion).Error; err != nil {
+		return nil, err
+	}
+
+	if promotion.Id == 0 {
+		return nil, nil
+	}
+
+	return &promotion, nil
+}
+
+// redeemPromotion records use of the reservation's promotion in given transaction. The promotion row is locked
+// so concurrent redemptions of a promotion are serialized and its usage limits can not be exceeded.
+// Previous redemption of the reservation is released first, so updating a reservation does not use it twice.
+func redeemPromotion(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if err := releasePromotion(tx, reservation.Id); err != nil {
+		return err
+	}
+
+	if reservation.PromotionId == nil {
+		return nil
+	}
+
+	promotion := models.Promotion{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id=?", *reservation.PromotionId).Find(&promotion).Error; err != nil {
+		return err
+	}
+
+	if promotion.Id == 0 {
+		return models.PromotionNotFoundErr
+	}
+
+	if promotion.MaxUses != 0 && promotion.UsedCount >= promotion.MaxUses {
+		return models.PromotionUsageLimitErr
+	}
+
+	if promotion.MaxUsesPerGuest != 0 {
+
+		var count int64 = 0
+		if err := tx.Model(&models.PromotionRedemption{}).
+			Where("promotion_id=? AND guest_id=?", promotion.Id, reservation.SupervisorId).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if uint64(count) >= promotion.MaxUsesPerGuest {
+			return models.PromotionUsageLimitErr
+		}
+	}
+
+	redemption := models.PromotionRedemption{
+		PromotionId:    promotion.Id,
+		ReservationId:  reservation.Id,
+		GuestId:        reservation.SupervisorId,
+		DiscountAmount: reservation.DiscountAmount,
+	}
+	redemption.CreatedBy = reservation.UpdatedBy
+	redemption.UpdatedBy = reservation.UpdatedBy
+
+	if err := tx.Create(&redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Promotion{}).Where("id=?", promotion.Id).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error
+}
+
+// releasePromotion removes redemption of the reservation, if there is any, and gives its use back to the promotion.
+func releasePromotion(tx *gorm.DB, reservationId uint64) error {
+
+	redemption := models.PromotionRedemption{}
+	if err := tx.Where("reservation_id=?", reservationId).Find(&redemption).Error; err != nil {
+		return err
+	}
+
+	if redemption.Id == 0 {
+		return nil
+	}
+
+	if err := tx.Delete(&redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Promotion{}).Where("id=? AND used_count > 0", redemption.PromotionId).
+		UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+}
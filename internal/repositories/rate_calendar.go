@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
+	"sort"
+	"time"
+)
+
+// planRateCalendar returns details of a room which replace existing details after setting prices of days
+// of the update. existing must contain details of the room which overlap or touch the update range.
+// Every day keeps restrictions of the detail which applies to it, consecutive days with equal restrictions
+// and prices are merged into one detail, so overlapping details are split and adjacent equal ones merged.
+// Existing details which are not changed are neither removed nor created.
+func planRateCalendar(roomId uint64, existing []*models.RateCodeDetail, update *dto.RateCalendarUpdateDto) (removed []*models.RateCodeDetail, created []*models.RateCodeDetail) {
+
+	start := date_utils.TruncateToDay(*update.DateStart)
+	end := date_utils.TruncateToDay(*update.DateEnd)
+
+	details := make([]*models.RateCodeDetail, 0, len(existing))
+	for _, detail := range existing {
+		if detail.DateStart == nil || detail.DateEnd == nil {
+			continue
+		}
+		details = append(details, detail)
+		if day := date_utils.TruncateToDay(*detail.DateStart); day.Before(start) {
+			start = day
+		}
+		if day := date_utils.TruncateToDay(*detail.DateEnd); day.After(end) {
+			end = day
+		}
+	}
+
+	segments := make([]*models.RateCodeDetail, 0)
+	var current *models.RateCodeDetail
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+
+		value := resolveCalendarDay(day, details)
+		if isCalendarTarget(day, update) {
+			value = withCalendarPrices(roomId, value, update)
+		}
+
+		if current != nil && value != nil && equalCalendarValues(current, value) {
+			dayEnd := day
+			current.DateEnd = &dayEnd
+			continue
+		}
+
+		current = nil
+		if value != nil {
+			dayStart, dayEnd := day, day
+			current = copyCalendarValue(value)
+			current.DateStart = &dayStart
+			current.DateEnd = &dayEnd
+			segments = append(segments, current)
+		}
+	}
+
+	removed = make([]*models.RateCodeDetail, 0)
+	created = make([]*models.RateCodeDetail, 0)
+	kept := make(map[*models.RateCodeDetail]bool)
+
+	for _, segment := range segments {
+
+		unchanged := false
+		for _, detail := range details {
+			if !kept[detail] && sameCalendarRange(detail, segment) && equalCalendarValues(detail, segment) {
+				kept[detail] = true
+				unchanged = true
+				break
+			}
+		}
+
+		if !unchanged {
+			created = append(created, segment)
+		}
+	}
+
+	for _, detail := range details {
+		if !kept[detail] {
+			removed = append(removed, detail)
+		}
+	}
+
+	return removed, created
+}
+
+// resolveCalendarDay returns the detail which applies to the day, the latest created one wins like rate resolving.
+func resolveCalendarDay(day time.Time, details []*models.RateCodeDetail) *models.RateCodeDetail {
+
+	var selected *models.RateCodeDetail
+
+	for _, detail := range details {
+
+		if day.Before(date_utils.TruncateToDay(*detail.DateStart)) || day.After(date_utils.TruncateToDay(*detail.DateEnd)) {
+			continue
+		}
+
+		if selected == nil || isDetailCreatedAfter(detail, selected) {
+			selected = detail
+		}
+	}
+
+	return selected
+}
+
+func isDetailCreatedAfter(a, b *models.RateCodeDetail) bool {
+
+	if a.CreatedAt != nil && b.CreatedAt != nil && !a.CreatedAt.Equal(*b.CreatedAt) {
+		return a.CreatedAt.After(*b.CreatedAt)
+	}
+
+	return a.Id > b.Id
+}
+
+// isCalendarTarget reports whether prices of the day are set by the update.
+func isCalendarTarget(day time.Time, update *dto.RateCalendarUpdateDto) bool {
+
+	if day.Before(date_utils.TruncateToDay(*update.DateStart)) || day.After(date_utils.TruncateToDay(*update.DateEnd)) {
+		return false
+	}
+
+	if len(update.Weekdays) == 0 {
+		return true
+	}
+
+	for _, weekday := range update.Weekdays {
+		if day.Weekday() == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withCalendarPrices returns value of a day with prices of the update, restrictions of the day and prices of
+// guest counts which are not in the update are kept.
+func withCalendarPrices(roomId uint64, value *models.RateCodeDetail, update *dto.RateCalendarUpdateDto) *models.RateCodeDetail {
+
+	var result *models.RateCodeDetail
+
+	if value != nil {
+		result = copyCalendarValue(value)
+	} else {
+		result = &models.RateCodeDetail{
+			RoomId:    roomId,
+			MinNights: update.MinNights,
+			MaxNights: update.MaxNights,
+		}
+		if result.MinNights == 0 {
+			result.MinNights = 1
+		}
+	}
+
+	for _, price := range update.Prices {
+
+		merged := false
+		for _, existing := range result.RatePrices {
+			if existing.GuestCount == price.GuestCount {
+				existing.Price = price.Price
+				merged = true
+			}
+		}
+
+		if !merged {
+			result.RatePrices = append(result.RatePrices, &models.RateCodeDetailPrice{GuestCount: price.GuestCount, Price: price.Price})
+		}
+	}
+
+	return result
+}
+
+// copyCalendarValue returns a new detail with room, restrictions and prices of given detail.
+func copyCalendarValue(detail *models.RateCodeDetail) *models.RateCodeDetail {
+
+	result := &models.RateCodeDetail{
+		RateCodeId:        detail.RateCodeId,
+		RoomId:            detail.RoomId,
+		MinNights:         detail.MinNights,
+		MaxNights:         detail.MaxNights,
+		ClosedToArrival:   detail.ClosedToArrival,
+		ClosedToDeparture: detail.ClosedToDeparture,
+		MinAdvanceDays:    detail.MinAdvanceDays,
+		MaxAdvanceDays:    detail.MaxAdvanceDays,
+		ExtraAdultPrice:   detail.ExtraAdultPrice,
+		ExtraBedPrice:     detail.ExtraBedPrice,
+		RatePrices:        make([]*models.RateCodeDetailPrice, 0, len(detail.RatePrices)),
+		ChildPrices:       make([]*models.RateCodeDetailChildPrice, 0, len(detail.ChildPrices)),
+	}
+
+	for _, price := range detail.RatePrices {
+		result.RatePrices = append(result.RatePrices, &models.RateCodeDetailPrice{GuestCount: price.GuestCount, Price: price.Price})
+	}
+
+	for _, price := range detail.ChildPrices {
+		result.ChildPrices = append(result.ChildPrices, &models.RateCodeDetailChildPrice{ChildAgeBandId: price.ChildAgeBandId, Price: price.Price})
+	}
+
+	return result
+}
+
+func sameCalendarRange(a, b *models.RateCodeDetail) bool {
+	return date_utils.TruncateToDay(*a.DateStart).Equal(date_utils.TruncateToDay(*b.DateStart)) &&
+		date_utils.TruncateToDay(*a.DateEnd).Equal(date_utils.TruncateToDay(*b.DateEnd))
+}
+
+// equalCalendarValues reports whether two details have equal restrictions and prices.
+func equalCalendarValues(a, b *models.RateCodeDetail) bool {
+
+	if a.MinNights != b.MinNights || a.MaxNights != b.MaxNights || a.ClosedToArrival != b.ClosedToArrival ||
+		a.ClosedToDeparture != b.ClosedToDeparture || a.MinAdvanceDays != b.MinAdvanceDays ||
+		a.MaxAdvanceDays != b.MaxAdvanceDays || len(a.RatePrices) != len(b.RatePrices) ||
+		a.ExtraAdultPrice != b.ExtraAdultPrice || a.ExtraBedPrice != b.ExtraBedPrice || len(a.ChildPrices) != len(b.ChildPrices) {
+		return false
+	}
+
+	aChildPrices := make(map[uint64]float64)
+	for _, price := range a.ChildPrices {
+		aChildPrices[price.ChildAgeBandId] = price.Price
+	}
+
+	for _, price := range b.ChildPrices {
+		if aPrice, ok := aChildPrices[price.ChildAgeBandId]; !ok || aPrice != price.Price {
+			return false
+		}
+	}
+
+	aPrices, bPrices := sortedPrices(a.RatePrices), sortedPrices(b.RatePrices)
+	for i := range aPrices {
+		if aPrices[i].GuestCount != bPrices[i].GuestCount || aPrices[i].Price != bPrices[i].Price {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedPrices(prices []*models.RateCodeDetailPrice) []*models.RateCodeDetailPrice {
+
+	result := append([]*models.RateCodeDetailPrice{}, prices...)
+	sort.Slice(result, func(i, j int) bool { return result[i].GuestCount < result[j].GuestCount })
+
+	return result
+}
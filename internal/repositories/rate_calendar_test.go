@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"testing"
+	"time"
+)
+
+func calendarDetail(id uint64, start, end *time.Time, price float64) *models.RateCodeDetail {
+
+	detail := &models.RateCodeDetail{RoomId: 1, MinNights: 1, DateStart: start, DateEnd: end,
+		RatePrices: []*models.RateCodeDetailPrice{{GuestCount: 1, Price: price}}}
+	detail.Id = id
+
+	return detail
+}
+
+func calendarUpdate(start, end *time.Time, price float64, weekdays ...time.Weekday) *dto.RateCalendarUpdateDto {
+	return &dto.RateCalendarUpdateDto{DateStart: start, DateEnd: end, Weekdays: weekdays,
+		Prices: []*dto.RateCalendarPriceDto{{GuestCount: 1, Price: price}}}
+}
+
+func calendarRanges(details []*models.RateCodeDetail) []string {
+
+	result := make([]string, 0, len(details))
+	for _, detail := range details {
+		result = append(result, detail.DateStart.Format("01-02")+"/"+detail.DateEnd.Format("01-02"))
+	}
+
+	return result
+}
+
+func TestPlanRateCalendarSplitsOverlappingDetail(t *testing.T) {
+
+	existing := calendarDetail(1, date(1, 1), date(1, 31), 100)
+
+	removed, created := planRateCalendar(1, []*models.RateCodeDetail{existing}, calendarUpdate(date(1, 10), date(1, 12), 120))
+
+	assert.Equal(t, []*models.RateCodeDetail{existing}, removed)
+	assert.Equal(t, []string{"01-01/01-09", "01-10/01-12", "01-13/01-31"}, calendarRanges(created))
+	assert.Equal(t, []float64{100, 120, 100}, []float64{created[0].RatePrices[0].Price, created[1].RatePrices[0].Price, created[2].RatePrices[0].Price})
+	assert.Equal(t, uint64(1), created[1].MinNights)
+}
+
+func TestPlanRateCalendarWeekdays(t *testing.T) {
+
+	// 2023-01-07 and 2023-01-14 are saturdays.
+	removed, created := planRateCalendar(1, nil, calendarUpdate(date(1, 1), date(1, 14), 150, time.Saturday, time.Sunday))
+
+	assert.Equal(t, 0, len(removed))
+	assert.Equal(t, []string{"01-01/01-01", "01-07/01-08", "01-14/01-14"}, calendarRanges(created))
+}
+
+func TestPlanRateCalendarMergesEqualRanges(t *testing.T) {
+
+	first := calendarDetail(1, date(1, 1), date(1, 10), 100)
+	second := calendarDetail(2, date(1, 11), date(1, 20), 120)
+
+	removed, created := planRateCalendar(1, []*models.RateCodeDetail{first, second}, calendarUpdate(date(1, 11), date(1, 20), 100))
+
+	assert.Equal(t, 2, len(removed))
+	assert.Equal(t, []string{"01-01/01-20"}, calendarRanges(created))
+}
+
+func TestPlanRateCalendarWithoutChange(t *testing.T) {
+
+	existing := calendarDetail(1, date(1, 1), date(1, 31), 100)
+
+	removed, created := planRateCalendar(1, []*models.RateCodeDetail{existing}, calendarUpdate(date(1, 5), date(1, 6), 100))
+
+	assert.Equal(t, 0, len(removed))
+	assert.Equal(t, 0, len(created))
+}
+
+func TestPlanRateCalendarKeepsOtherGuestCountPrices(t *testing.T) {
+
+	existing := calendarDetail(1, date(1, 1), date(1, 10), 100)
+	existing.RatePrices = append(existing.RatePrices, &models.RateCodeDetailPrice{GuestCount: 2, Price: 150})
+
+	update := calendarUpdate(date(1, 1), date(1, 10), 200)
+	update.Prices[0].GuestCount = 2
+
+	_, created := planRateCalendar(1, []*models.RateCodeDetail{existing}, update)
+
+	assert.Equal(t, 1, len(created))
+	prices := sortedPrices(created[0].RatePrices)
+	assert.Equal(t, []float64{100, 200}, []float64{prices[0].Price, prices[1].Price})
+}
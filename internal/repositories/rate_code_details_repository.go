@@ -2,10 +2,20 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
+	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"sort"
+	"time"
+)
+
+var (
+	DerivedRateCodePricesErr = errors.New(message_keys.DerivedRateCodePrices)
 )
 
 type RateCodeDetailRepository struct {
@@ -38,6 +48,11 @@ func (r *RateCodeDetailRepository) Update(ctx context.Context, model *models.Rat
 		tx.Rollback()
 		return nil, err
 	}
+
+	if err := tx.Where("rate_code_detail_id=?", model.Id).Delete(&models.RateCodeDetailChildPrice{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 	// update
 	if err := tx.Updates(&model).Error; err != nil {
 		return nil, err
@@ -93,3 +108,148 @@ func (r RateCodeDetailRepository) Delete(ctx context.Context, id uint64) error {
 	}
 	return nil
 }
+
+// ApplyCalendar sets prices of the rate code for days of the update in one transaction and returns removed and
+// created details, see planRateCalendar. Nothing is stored in a dry run. It returns nil if rate code does not exist.
+func (r *RateCodeDetailRepository) ApplyCalendar(ctx context.Context, rateCodeId uint64, update *dto.RateCalendarUpdateDto,
+	username string) (*dto.RateCalendarDiffDto, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	// rate code is locked, so calendar updates of a rate code do not plan on details which are being replaced.
+	rateCode := models.RateCode{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", rateCodeId).Find(&rateCode).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if rateCode.Id == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	if rateCode.IsDerived() {
+		tx.Rollback()
+		return nil, DerivedRateCodePricesErr
+	}
+
+	roomIds, err := calendarRoomIds(tx, update)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	result := &dto.RateCalendarDiffDto{
+		DryRun:  update.DryRun,
+		Removed: make([]*models.RateCodeDetail, 0),
+		Created: make([]*models.RateCodeDetail, 0),
+	}
+
+	// details which touch the range are loaded too, so they can be merged with the updated days.
+	from, to := update.DateStart.AddDate(0, 0, -1), update.DateEnd.AddDate(0, 0, 1)
+
+	for _, roomId := range roomIds {
+
+		existing := make([]*models.RateCodeDetail, 0)
+		if err := tx.Preload("RatePrices", "deleted_at IS NULL").Preload("ChildPrices", "deleted_at IS NULL").
+			Where("rate_code_id=? AND room_id=? AND date_start <= ? AND date_end >= ? AND deleted_at IS NULL", rateCodeId, roomId, to, from).
+			Order("id").Find(&existing).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		removed, created := planRateCalendar(roomId, existing, update)
+
+		for _, detail := range created {
+			detail.RateCodeId = rateCodeId
+			detail.CreatedBy = username
+			detail.UpdatedBy = username
+			for _, price := range detail.RatePrices {
+				price.CreatedBy = username
+				price.UpdatedBy = username
+			}
+			for _, price := range detail.ChildPrices {
+				price.CreatedBy = username
+				price.UpdatedBy = username
+			}
+		}
+
+		result.Removed = append(result.Removed, removed...)
+		result.Created = append(result.Created, created...)
+
+		if update.DryRun {
+			continue
+		}
+
+		// removed details are soft deleted, nights of reservations keep referencing the detail and prices they were priced by.
+		deleted := map[string]interface{}{"deleted_at": time.Now(), "updated_by": username}
+
+		for _, detail := range removed {
+
+			if err := tx.Model(&models.RateCodeDetailPrice{}).Where("rate_code_detail_id=? AND deleted_at IS NULL", detail.Id).
+				Updates(deleted).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			if err := tx.Model(&models.RateCodeDetailChildPrice{}).Where("rate_code_detail_id=? AND deleted_at IS NULL", detail.Id).
+				Updates(deleted).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
+			if err := tx.Model(&models.RateCodeDetail{}).Where("id=?", detail.Id).Updates(deleted).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		for _, detail := range created {
+			if err := tx.Omit("RateCode", "Room").Create(detail).Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if update.DryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// calendarRoomIds returns distinct ids of rooms of the update and rooms of its room types.
+func calendarRoomIds(tx *gorm.DB, update *dto.RateCalendarUpdateDto) ([]uint64, error) {
+
+	roomIds := append([]uint64{}, update.RoomIds...)
+
+	if len(update.RoomTypeIds) > 0 {
+
+		typeRoomIds := make([]uint64, 0)
+		if err := tx.Model(&models.Room{}).Where("room_type_id IN ?", update.RoomTypeIds).Pluck("id", &typeRoomIds).Error; err != nil {
+			return nil, err
+		}
+		roomIds = append(roomIds, typeRoomIds...)
+	}
+
+	result := make([]uint64, 0, len(roomIds))
+	seen := make(map[uint64]bool)
+
+	for _, roomId := range roomIds {
+		if !seen[roomId] {
+			seen[roomId] = true
+			result = append(result, roomId)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result, nil
+}
@@ -2,12 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
+	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
 )
 
+var (
+	InvalidParentRateCodeErr       = errors.New(message_keys.InvalidParentRateCode)
+	RateCodeHasDerivedRateCodesErr = errors.New(message_keys.RateCodeHasDerivedRateCodes)
+)
+
 type RateCodeRepository struct {
 	DbResolver *tenant_database_resolver.TenantDatabaseResolver
 }
@@ -31,10 +38,49 @@ func (r *RateCodeRepository) Create(ctx context.Context, model *models.RateCode)
 func (r *RateCodeRepository) Update(ctx context.Context, model *models.RateCode) (*models.RateCode, error) {
 
 	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
 
-	if tx := db.Updates(&model); tx.Error != nil {
-		return nil, tx.Error
+	if err := tx.Omit("AddOns").Updates(&model).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// derivation can be removed or reset to zero values, so its columns are always updated.
+	if err := tx.Model(&models.RateCode{}).Where("id=?", model.Id).Updates(map[string]interface{}{
+		"parent_rate_code_id": model.ParentRateCodeId,
+		"derivation_type":     model.DerivationType,
+		"derivation_value":    model.DerivationValue,
+		"rounding_type":       model.RoundingType,
+		"rounding_unit":       model.RoundingUnit,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// bundled add-ons are replaced.
+	if err := tx.Where("rate_code_id=?", model.Id).Delete(&models.RateCodeAddOn{}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, bundle := range model.AddOns {
+		bundle.Id = 0
+		bundle.RateCodeId = model.Id
+		bundle.CreatedBy = model.UpdatedBy
+		bundle.UpdatedBy = model.UpdatedBy
+	}
+
+	if len(model.AddOns) > 0 {
+		if err := tx.Create(&model.AddOns).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
 	}
+
 	return model, nil
 }
 
@@ -43,7 +89,7 @@ func (r *RateCodeRepository) Find(ctx context.Context, id uint64) (*models.RateC
 	model := models.RateCode{}
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+	if tx := db.Preload("AddOns").Where("id=?", id).Find(&model); tx.Error != nil {
 		return nil, tx.Error
 	}
 
@@ -59,10 +105,85 @@ func (r *RateCodeRepository) FindAll(ctx context.Context, input *dto.PaginationF
 	return paginatedList(&models.RateCode{}, db, input)
 }
 
+// CheckParent returns InvalidParentRateCodeErr if the rate code can not derive from its parent, the parent must
+// be another rate code of the same currency which is not derived itself and a parent can not become derived.
+func (r *RateCodeRepository) CheckParent(ctx context.Context, model *models.RateCode) error {
+
+	if !model.IsDerived() {
+		return nil
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if model.Id != 0 {
+
+		var count int64 = 0
+		if err := db.Model(&models.RateCode{}).Where("parent_rate_code_id=?", model.Id).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return InvalidParentRateCodeErr
+		}
+	}
+
+	parent := models.RateCode{}
+	if err := db.Where("id=?", *model.ParentRateCodeId).Find(&parent).Error; err != nil {
+		return err
+	}
+
+	if parent.Id == 0 || parent.Id == model.Id || parent.IsDerived() || parent.CurrencyId != model.CurrencyId {
+		return InvalidParentRateCodeErr
+	}
+
+	return nil
+}
+
+// CheckAddOns returns models.AddOnNotFoundErr if an add-on which is bundled by the rate code is not an add-on
+// of the rate code hotel.
+func (r *RateCodeRepository) CheckAddOns(ctx context.Context, model *models.RateCode) error {
+
+	if len(model.AddOns) == 0 {
+		return nil
+	}
+
+	addOnIds := make([]uint64, 0, len(model.AddOns))
+	seen := make(map[uint64]bool)
+	for _, bundle := range model.AddOns {
+		if !seen[bundle.AddOnId] {
+			seen[bundle.AddOnId] = true
+			addOnIds = append(addOnIds, bundle.AddOnId)
+		}
+	}
+
+	var count int64 = 0
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(&models.AddOn{}).Where("id IN ? AND hotel_id=?", addOnIds, model.HotelId).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count != int64(len(addOnIds)) {
+		return models.AddOnNotFoundErr
+	}
+
+	return nil
+}
+
+// Delete removes RateCode, rate codes which other rate codes derive from can not be removed.
 func (r RateCodeRepository) Delete(ctx context.Context, id uint64) error {
 
 	db := r.DbResolver.GetTenantDB(ctx)
 
+	var count int64 = 0
+	if err := db.Model(&models.RateCode{}).Where("parent_rate_code_id=?", id).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return RateCodeHasDerivedRateCodesErr
+	}
+
 	if query := db.Model(&models.RateCode{}).Where("id=?", id).Delete(&models.RateCode{}); query.Error != nil {
 		return query.Error
 	}
@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"errors"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+var (
+	ClosedToArrivalErr           = errors.New(message_keys.ClosedToArrival)
+	ClosedToDepartureErr         = errors.New(message_keys.ClosedToDeparture)
+	MinStayNotMetErr             = errors.New(message_keys.MinStayNotMet)
+	MaxStayExceededErr           = errors.New(message_keys.MaxStayExceeded)
+	MinAdvanceBookingNotMetErr   = errors.New(message_keys.MinAdvanceBookingNotMet)
+	MaxAdvanceBookingExceededErr = errors.New(message_keys.MaxAdvanceBookingExceeded)
+
+	stayRestrictionErrs = []error{ClosedToArrivalErr, ClosedToDepartureErr, MinStayNotMetErr, MaxStayExceededErr,
+		MinAdvanceBookingNotMetErr, MaxAdvanceBookingExceededErr}
+)
+
+// resolveNightPrices picks the applicable rate price of every night from candidate rate code details of a rate code.
+// The second result is false if at least one night has no price.
+func resolveNightPrices(nights []time.Time, candidates []*dto.RateCodePricesDto) ([]*dto.NightPriceDto, bool) {
+
+	result := make([]*dto.NightPriceDto, 0, len(nights))
+	complete := true
+
+	for _, night := range nights {
+
+		selected := resolveDetail(night, candidates)
+		if selected == nil {
+			complete = false
+			continue
+		}
+
+		result = append(result, &dto.NightPriceDto{
+			Date:             night,
+			RateCodeId:       selected.RateCodeId,
+			RateCodeDetailId: selected.DetailId,
+			RatePriceId:      selected.RatePriceId,
+			Price:            selected.Price,
+			BasePrice:        selected.Price,
+		})
+	}
+
+	return result, complete
+}
+
+// resolveDetail returns the candidate which applies to given day, a detail applies to a day if the day is
+// between its DateStart and DateEnd (both inclusive), when more than one detail applies the latest created one wins.
+func resolveDetail(day time.Time, candidates []*dto.RateCodePricesDto) *dto.RateCodePricesDto {
+
+	var selected *dto.RateCodePricesDto
+	day = date_utils.TruncateToDay(day)
+
+	for _, candidate := range candidates {
+
+		if candidate.DateStart == nil || candidate.DateEnd == nil {
+			continue
+		}
+
+		if day.Before(date_utils.TruncateToDay(*candidate.DateStart)) || day.After(date_utils.TruncateToDay(*candidate.DateEnd)) {
+			continue
+		}
+
+		if selected == nil || isCreatedAfter(candidate, selected) {
+			selected = candidate
+		}
+	}
+
+	return selected
+}
+
+// checkStayRestrictions checks stay restrictions of the details which apply to arrival and departure days,
+// now is the booking time which advance purchase restrictions are counted from.
+func checkStayRestrictions(checkin, checkout, now time.Time, candidates []*dto.RateCodePricesDto) error {
+
+	arrival := date_utils.TruncateToDay(checkin)
+	detail := resolveDetail(arrival, candidates)
+
+	if detail != nil {
+
+		nights := uint64(len(date_utils.Nights(checkin, checkout)))
+		advanceDays := int64(arrival.Sub(date_utils.TruncateToDay(now)).Hours() / 24)
+
+		if detail.ClosedToArrival {
+			return ClosedToArrivalErr
+		}
+
+		if nights < detail.MinNights {
+			return MinStayNotMetErr
+		}
+
+		if detail.MaxNights != 0 && nights > detail.MaxNights {
+			return MaxStayExceededErr
+		}
+
+		if advanceDays < int64(detail.MinAdvanceDays) {
+			return MinAdvanceBookingNotMetErr
+		}
+
+		if detail.MaxAdvanceDays != 0 && advanceDays > int64(detail.MaxAdvanceDays) {
+			return MaxAdvanceBookingExceededErr
+		}
+	}
+
+	if detail := resolveDetail(checkout, candidates); detail != nil && detail.ClosedToDeparture {
+		return ClosedToDepartureErr
+	}
+
+	return nil
+}
+
+// IsStayRestrictionErr reports whether err is a stay restriction rejection.
+func IsStayRestrictionErr(err error) bool {
+
+	for _, restrictionErr := range stayRestrictionErrs {
+		if errors.Is(err, restrictionErr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCreatedAfter reports whether rate price a is created after b, ids break ties of equal or missing creation times.
+func isCreatedAfter(a, b *dto.RateCodePricesDto) bool {
+
+	if a.CreatedAt != nil && b.CreatedAt != nil && !a.CreatedAt.Equal(*b.CreatedAt) {
+		return a.CreatedAt.After(*b.CreatedAt)
+	}
+
+	return a.DetailId > b.DetailId
+}
+
+// deriveRatePrices adds rate prices of derived rate codes to candidates, a derived rate code gets every
+// rate price of its parent rate code with the derived price. Derived prices are not stored, so they always
+// follow prices of the parent rate code.
+func deriveRatePrices(candidates []*dto.RateCodePricesDto, derived []*models.RateCode) []*dto.RateCodePricesDto {
+
+	result := candidates
+
+	for _, rateCode := range derived {
+
+		if !rateCode.IsDerived() {
+			continue
+		}
+
+		for _, candidate := range candidates {
+
+			if candidate.RateCodeId != *rateCode.ParentRateCodeId {
+				continue
+			}
+
+			derivedPrice := *candidate
+			derivedPrice.RateCodeId = rateCode.Id
+			derivedPrice.RateCodeName = rateCode.Name
+			derivedPrice.Price = rateCode.DerivePrice(candidate.Price)
+			result = append(result, &derivedPrice)
+		}
+	}
+
+	return result
+}
+
+// nightOccupancy returns occupancy percent of a room type with roomCount rooms in every night by given stays,
+// nights are truncated days.
+func nightOccupancy(nights []time.Time, roomCount int64, stays []*models.Reservation) map[time.Time]float64 {
+
+	result := make(map[time.Time]float64, len(nights))
+
+	for _, night := range nights {
+
+		day := date_utils.TruncateToDay(night)
+		result[day] = 0
+
+		if roomCount == 0 {
+			continue
+		}
+
+		occupied := 0
+		for _, stay := range stays {
+			if stay.CheckinDate != nil && stay.CheckoutDate != nil && date_utils.CoversNight(*stay.CheckinDate, *stay.CheckoutDate, day) {
+				occupied++
+			}
+		}
+
+		result[day] = float64(occupied) * 100 / float64(roomCount)
+	}
+
+	return result
+}
+
+// adjustNightPrices applies the pricing rule which matches occupancy of each night on its base price,
+// rules must be sorted by priority. It returns the adjusted nights.
+func adjustNightPrices(nights []*dto.NightPriceDto, rules []*models.PricingRule, occupancy map[time.Time]float64,
+	daysBeforeArrival int64) []*dto.NightPriceDto {
+
+	adjusted := make([]*dto.NightPriceDto, 0)
+
+	for _, night := range nights {
+
+		night.Occupancy = occupancy[date_utils.TruncateToDay(night.Date)]
+		night.PricingRuleId = nil
+		night.Price = night.BasePrice
+
+		rule := models.SelectPricingRule(rules, night.RateCodeId, night.Occupancy, daysBeforeArrival)
+		if rule == nil {
+			continue
+		}
+
+		ruleId := rule.Id
+		night.PricingRuleId = &ruleId
+		night.Price = rule.Adjust(night.BasePrice)
+		adjusted = append(adjusted, night)
+	}
+
+	return adjusted
+}
+
+// selectGuestPrices picks one rate price of every detail for given adults. The price of the adults wins, otherwise
+// the price of the largest guest count below the adults is used and ExtraAdultPrice is added for each other adult.
+// Details without a price for the adults and without ExtraAdultPrice are dropped.
+func selectGuestPrices(candidates []*dto.RateCodePricesDto, adults uint64) []*dto.RateCodePricesDto {
+
+	selected := make(map[uint64]*dto.RateCodePricesDto)
+	detailIds := make([]uint64, 0)
+
+	for _, candidate := range candidates {
+
+		if candidate.GuestCount > adults || (candidate.GuestCount < adults && candidate.ExtraAdultPrice == 0) {
+			continue
+		}
+
+		current, ok := selected[candidate.DetailId]
+		if !ok {
+			detailIds = append(detailIds, candidate.DetailId)
+		}
+
+		if !ok || candidate.GuestCount > current.GuestCount {
+			selected[candidate.DetailId] = candidate
+		}
+	}
+
+	result := make([]*dto.RateCodePricesDto, 0, len(detailIds))
+	for _, detailId := range detailIds {
+
+		ratePrice := *selected[detailId]
+		ratePrice.Price += float64(adults-ratePrice.GuestCount) * ratePrice.ExtraAdultPrice
+		result = append(result, &ratePrice)
+	}
+
+	return result
+}
+
+// addOccupancyPrices adds prices of children of given age bands and extra beds to the rate price of every detail,
+// childPrices are child prices of the details by detail id and age band id.
+func addOccupancyPrices(candidates []*dto.RateCodePricesDto, childPrices map[uint64]map[uint64]float64,
+	childAgeBandIds []uint64, extraBeds uint64) {
+
+	for _, candidate := range candidates {
+
+		for _, bandId := range childAgeBandIds {
+			candidate.Price += childPrices[candidate.DetailId][bandId]
+		}
+
+		candidate.Price += float64(extraBeds) * candidate.ExtraBedPrice
+	}
+}
+
+// sumNightPrices returns total price of given nights.
+func sumNightPrices(nights []*dto.NightPriceDto) float64 {
+
+	total := 0.0
+	for _, night := range nights {
+		total += night.Price
+	}
+
+	return total
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
+	"testing"
+	"time"
+)
+
+func date(month time.Month, day int) *time.Time {
+	result := time.Date(2023, month, day, 0, 0, 0, 0, time.UTC)
+	return &result
+}
+
+func TestResolveNightPricesAcrossSeasons(t *testing.T) {
+
+	lowSeason := &dto.RateCodePricesDto{DetailId: 1, DateStart: date(1, 1), DateEnd: date(3, 31), Price: 100, CreatedAt: date(1, 1)}
+	highSeason := &dto.RateCodePricesDto{DetailId: 2, DateStart: date(4, 1), DateEnd: date(6, 30), Price: 150, CreatedAt: date(1, 1)}
+
+	nights := date_utils.Nights(*date(3, 30), *date(4, 2))
+	result, complete := resolveNightPrices(nights, []*dto.RateCodePricesDto{highSeason, lowSeason})
+
+	assert.True(t, complete)
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, 100.0, result[0].Price)
+	assert.Equal(t, 100.0, result[1].Price)
+	assert.Equal(t, 150.0, result[2].Price)
+	assert.Equal(t, uint64(2), result[2].RateCodeDetailId)
+	assert.Equal(t, 350.0, sumNightPrices(result))
+}
+
+func TestResolveNightPricesPrefersLatestDetail(t *testing.T) {
+
+	old := &dto.RateCodePricesDto{DetailId: 1, DateStart: date(1, 1), DateEnd: date(12, 31), Price: 100, CreatedAt: date(1, 1)}
+	special := &dto.RateCodePricesDto{DetailId: 2, DateStart: date(5, 2), DateEnd: date(5, 2), Price: 80, CreatedAt: date(2, 1)}
+
+	nights := date_utils.Nights(*date(5, 1), *date(5, 4))
+	result, complete := resolveNightPrices(nights, []*dto.RateCodePricesDto{old, special})
+
+	assert.True(t, complete)
+	assert.Equal(t, []float64{100, 80, 100}, []float64{result[0].Price, result[1].Price, result[2].Price})
+}
+
+func TestResolveNightPricesMissingNight(t *testing.T) {
+
+	season := &dto.RateCodePricesDto{DetailId: 1, DateStart: date(1, 1), DateEnd: date(1, 31), Price: 100}
+
+	nights := date_utils.Nights(*date(1, 30), *date(2, 2))
+	result, complete := resolveNightPrices(nights, []*dto.RateCodePricesDto{season})
+
+	assert.False(t, complete)
+	assert.Equal(t, 2, len(result))
+}
+
+func TestCheckStayRestrictions(t *testing.T) {
+
+	now := *date(1, 1)
+	detail := func(modify func(d *dto.RateCodePricesDto)) []*dto.RateCodePricesDto {
+		d := &dto.RateCodePricesDto{DetailId: 1, DateStart: date(1, 1), DateEnd: date(12, 31), MinNights: 2, MaxNights: 7}
+		modify(d)
+		return []*dto.RateCodePricesDto{d}
+	}
+
+	testCases := []struct {
+		name       string
+		checkin    time.Time
+		checkout   time.Time
+		candidates []*dto.RateCodePricesDto
+		want       error
+	}{
+		{name: "allowed", checkin: *date(2, 1), checkout: *date(2, 3), candidates: detail(func(d *dto.RateCodePricesDto) {}), want: nil},
+		{name: "min_stay", checkin: *date(2, 1), checkout: *date(2, 2), candidates: detail(func(d *dto.RateCodePricesDto) {}), want: MinStayNotMetErr},
+		{name: "max_stay", checkin: *date(2, 1), checkout: *date(2, 10), candidates: detail(func(d *dto.RateCodePricesDto) {}), want: MaxStayExceededErr},
+		{name: "unlimited_max_stay", checkin: *date(2, 1), checkout: *date(2, 10), candidates: detail(func(d *dto.RateCodePricesDto) { d.MaxNights = 0 }), want: nil},
+		{name: "closed_to_arrival", checkin: *date(2, 1), checkout: *date(2, 3), candidates: detail(func(d *dto.RateCodePricesDto) { d.ClosedToArrival = true }), want: ClosedToArrivalErr},
+		{name: "closed_to_departure", checkin: *date(2, 1), checkout: *date(2, 3), candidates: detail(func(d *dto.RateCodePricesDto) { d.ClosedToDeparture = true }), want: ClosedToDepartureErr},
+		{name: "min_advance", checkin: *date(1, 5), checkout: *date(1, 7), candidates: detail(func(d *dto.RateCodePricesDto) { d.MinAdvanceDays = 7 }), want: MinAdvanceBookingNotMetErr},
+		{name: "max_advance", checkin: *date(6, 1), checkout: *date(6, 3), candidates: detail(func(d *dto.RateCodePricesDto) { d.MaxAdvanceDays = 30 }), want: MaxAdvanceBookingExceededErr},
+		{name: "no_detail", checkin: *date(2, 1), checkout: *date(2, 2), candidates: nil, want: nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := checkStayRestrictions(testCase.checkin, testCase.checkout, now, testCase.candidates)
+			assert.Equal(t, testCase.want, err)
+			if err != nil {
+				assert.True(t, IsStayRestrictionErr(err))
+			}
+		})
+	}
+}
+
+func TestDeriveRatePrices(t *testing.T) {
+
+	parentId := uint64(1)
+	bar := &dto.RateCodePricesDto{RateCodeId: parentId, RateCodeName: "BAR", DetailId: 1, DateStart: date(1, 1), DateEnd: date(12, 31), Price: 200, MinNights: 2}
+	other := &dto.RateCodePricesDto{RateCodeId: 5, DetailId: 2, DateStart: date(1, 1), DateEnd: date(12, 31), Price: 90}
+
+	nonRefundable := &models.RateCode{Name: "NRF", ParentRateCodeId: &parentId, DerivationType: models.PercentageDerivation, DerivationValue: -10}
+	nonRefundable.Id = 2
+
+	result := deriveRatePrices([]*dto.RateCodePricesDto{bar, other}, []*models.RateCode{nonRefundable})
+
+	assert.Equal(t, 3, len(result))
+	assert.Equal(t, uint64(2), result[2].RateCodeId)
+	assert.Equal(t, "NRF", result[2].RateCodeName)
+	assert.Equal(t, 180.0, result[2].Price)
+	assert.Equal(t, uint64(2), result[2].MinNights)
+	assert.Equal(t, 200.0, bar.Price)
+}
+
+func TestNightOccupancy(t *testing.T) {
+
+	stays := []*models.Reservation{
+		{CheckinDate: date(5, 1), CheckoutDate: date(5, 3)},
+		{CheckinDate: date(5, 2), CheckoutDate: date(5, 4)},
+	}
+
+	result := nightOccupancy(date_utils.Nights(*date(5, 1), *date(5, 4)), 4, stays)
+
+	assert.Equal(t, 25.0, result[*date(5, 1)])
+	assert.Equal(t, 50.0, result[*date(5, 2)])
+	assert.Equal(t, 25.0, result[*date(5, 3)])
+	assert.Equal(t, 0.0, nightOccupancy(date_utils.Nights(*date(5, 1), *date(5, 2)), 0, stays)[*date(5, 1)])
+}
+
+func TestAdjustNightPrices(t *testing.T) {
+
+	high := &models.PricingRule{Condition: models.OccupancyAbove, Threshold: 80, Value: 12, Active: true}
+	high.Id = 7
+
+	nights := []*dto.NightPriceDto{
+		{Date: *date(5, 1), RateCodeId: 1, Price: 150, BasePrice: 100},
+		{Date: *date(5, 2), RateCodeId: 1, Price: 100, BasePrice: 100},
+	}
+	occupancy := map[time.Time]float64{*date(5, 1): 50, *date(5, 2): 90}
+
+	adjusted := adjustNightPrices(nights, []*models.PricingRule{high}, occupancy, 10)
+
+	assert.Equal(t, 1, len(adjusted))
+	assert.Equal(t, 100.0, nights[0].Price)
+	assert.Nil(t, nights[0].PricingRuleId)
+	assert.Equal(t, 112.0, nights[1].Price)
+	assert.Equal(t, uint64(7), *nights[1].PricingRuleId)
+	assert.Equal(t, 90.0, nights[1].Occupancy)
+}
+
+func TestSelectGuestPrices(t *testing.T) {
+
+	candidates := []*dto.RateCodePricesDto{
+		{DetailId: 1, GuestCount: 1, Price: 100, ExtraAdultPrice: 30},
+		{DetailId: 1, GuestCount: 2, Price: 150, ExtraAdultPrice: 30},
+		{DetailId: 2, GuestCount: 1, Price: 80},
+		{DetailId: 3, GuestCount: 3, Price: 200},
+	}
+
+	result := selectGuestPrices(candidates, 3)
+
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, 180.0, result[0].Price)
+	assert.Equal(t, uint64(3), result[1].DetailId)
+	assert.Equal(t, 150.0, candidates[1].Price)
+
+	exact := selectGuestPrices(candidates, 2)
+	assert.Equal(t, 150.0, exact[0].Price)
+}
+
+func TestAddOccupancyPrices(t *testing.T) {
+
+	candidates := []*dto.RateCodePricesDto{{DetailId: 1, Price: 150, ExtraBedPrice: 25}}
+	childPrices := map[uint64]map[uint64]float64{1: {7: 40}}
+
+	addOccupancyPrices(candidates, childPrices, []uint64{7, 7, 8}, 1)
+
+	assert.Equal(t, 255.0, candidates[0].Price)
+}
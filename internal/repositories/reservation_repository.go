@@ -5,31 +5,69 @@ import (
 	"context"
 	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"math"
 	"math/big"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
+	"reservation-api/internal/utils/date_utils"
 	"reservation-api/internal/utils/hash_utils"
+	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
 	"strings"
 	"time"
 )
 
+const (
+	// exclusionViolationCode is postgres error code of exclusion constraint violation.
+	exclusionViolationCode = "23P01"
+
+	rateCodeDetailRestrictionColumns = `
+       details.min_nights,
+       details.max_nights,
+       details.closed_to_arrival,
+       details.closed_to_departure,
+       details.min_advance_days,
+       details.max_advance_days
+	`
+)
+
+// overlapsStaySQL is the condition of reservations whose stay nights overlap nights between two times.
+var overlapsStaySQL = models.StayRangeSQL("") +
+	" && daterange((?::timestamptz AT TIME ZONE 'UTC')::date, (?::timestamptz AT TIME ZONE 'UTC')::date, '[)')"
+
+var (
+	ReservationConflictErr       = errors.New(message_keys.ReservationConflictError)
+	ReservationNotCancellableErr = errors.New(message_keys.ReservationNotCancellable)
+	RateNotAvailableErr          = errors.New(message_keys.RateNotAvailable)
+	ReservationNotEditableErr    = errors.New(message_keys.ReservationNotEditable)
+)
+
 type ReservationRepository struct {
-	DbResolver         *tenant_database_resolver.TenantDatabaseResolver
-	RateCodeRepository *RateCodeDetailRepository
+	DbResolver             *tenant_database_resolver.TenantDatabaseResolver
+	RateCodeRepository     *RateCodeDetailRepository
+	TaxRuleRepository      *TaxRuleRepository
+	ExchangeRateRepository *ExchangeRateRepository
+	PricingRuleRepository  *PricingRuleRepository
 }
 
 // NewReservationRepository returns new ReservationRepository
-func NewReservationRepository(r *tenant_database_resolver.TenantDatabaseResolver, rateCodeRepository *RateCodeDetailRepository) *ReservationRepository {
+func NewReservationRepository(r *tenant_database_resolver.TenantDatabaseResolver, rateCodeRepository *RateCodeDetailRepository,
+	taxRuleRepository *TaxRuleRepository, exchangeRateRepository *ExchangeRateRepository,
+	pricingRuleRepository *PricingRuleRepository) *ReservationRepository {
 	return &ReservationRepository{
-		DbResolver:         r,
-		RateCodeRepository: rateCodeRepository,
+		DbResolver:             r,
+		RateCodeRepository:     rateCodeRepository,
+		TaxRuleRepository:      taxRuleRepository,
+		ExchangeRateRepository: exchangeRateRepository,
+		PricingRuleRepository:  pricingRuleRepository,
 	}
 }
 
@@ -78,7 +116,11 @@ func (r *ReservationRepository) CreateReservationRequest(ctx context.Context, re
 
 func (r *ReservationRepository) Create(ctx context.Context, reservation *models.Reservation) (*models.Reservation, error) {
 
-	r.setReservationCalcFields(ctx, reservation)
+	// exchange rate is locked at booking time.
+	reservation.ExchangeRate = 0
+	if err := r.setReservationCalcFields(ctx, reservation, time.Now()); err != nil {
+		return nil, err
+	}
 	db := r.DbResolver.GetTenantDB(ctx)
 
 	option := sql.TxOptions{
@@ -88,7 +130,29 @@ func (r *ReservationRepository) Create(ctx context.Context, reservation *models.
 
 	tx := db.Begin(&option)
 
+	reservation.SetInitialStatus()
 	if err := tx.Create(&reservation).Error; err != nil {
+		tx.Rollback()
+		return nil, translateReservationError(err)
+	}
+
+	if err := addStatusHistory(tx, reservation.Id, nil, reservation.CheckStatus, reservation.CreatedBy); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := redeemPromotion(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := logPriceAdjustments(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := postReservationCharges(tx, reservation.Id, reservation.SupervisorId, models.ReservationCharges(reservation),
+		reservation.UpdatedBy); err != nil {
 		tx.Rollback()
 		return nil, err
 	}
@@ -105,18 +169,108 @@ func (r *ReservationRepository) Create(ctx context.Context, reservation *models.
 
 func (r *ReservationRepository) Update(ctx context.Context, id uint64, reservation *models.Reservation) (*models.Reservation, error) {
 
-	r.setReservationCalcFields(ctx, reservation)
-	reservation.Id = id
 	db := r.DbResolver.GetTenantDB(ctx)
 
+	// keep the exchange rate which is locked at booking unless the guest currency is changed.
+	locked := models.Reservation{}
+	if err := db.Select("id", "created_at", "check_status", "currency_id", "exchange_rate").Where("id=?", id).Find(&locked).Error; err != nil {
+		return nil, err
+	}
+
+	// cancelled, no-show and checked out reservations are closed, repricing them would post their charges again.
+	if locked.CheckStatus.IsReleased() {
+		return nil, ReservationNotEditableErr
+	}
+
+	reservation.ExchangeRate = 0
+	if locked.CurrencyId != 0 && (reservation.CurrencyId == 0 || reservation.CurrencyId == locked.CurrencyId) {
+		reservation.CurrencyId = locked.CurrencyId
+		reservation.ExchangeRate = locked.ExchangeRate
+	}
+
+	// promotion is checked against the time which the reservation is booked.
+	bookedAt := time.Now()
+	if locked.CreatedAt != nil {
+		bookedAt = *locked.CreatedAt
+	}
+
+	reservation.Id = id
+	if err := r.setReservationCalcFields(ctx, reservation, bookedAt); err != nil {
+		return nil, err
+	}
+
 	tx := db.Begin()
+
+	// the reservation may be released while it is repriced.
+	current := models.Reservation{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "check_status").Where("id=?", id).
+		Find(&current).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if current.CheckStatus.IsReleased() {
+		tx.Rollback()
+		return nil, ReservationNotEditableErr
+	}
+
 	// remove old sharers and replace with new sharers.
 	if err := tx.Where("reservation_id=?", id).Delete(&models.Sharer{}).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	if err := tx.Where("id=?", id).Updates(&reservation).Error; err != nil {
+	// status is changed only by ChangeStatus and Cancel to keep the lifecycle rules.
+	if err := tx.Where("id=?", id).Omit("check_status", "NightPrices", "Taxes", "ChildGuests", "AddOns").Updates(&reservation).Error; err != nil {
+		tx.Rollback()
+		return nil, translateReservationError(err)
+	}
+
+	// promotion, extra beds and add-ons can be removed from the reservation, so their columns are updated even if they are empty.
+	if err := tx.Model(&models.Reservation{}).Where("id=?", id).Updates(map[string]interface{}{
+		"promo_code":              reservation.PromoCode,
+		"promotion_id":            reservation.PromotionId,
+		"discount_amount":         reservation.DiscountAmount,
+		"extra_beds":              reservation.ExtraBeds,
+		"add_on_amount":           reservation.AddOnAmount,
+		"converted_add_on_amount": reservation.ConvertedAddOnAmount,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := redeemPromotion(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := replaceNightPrices(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := replaceChildGuests(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := replaceAddOns(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := replaceTaxes(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := logPriceAdjustments(tx, reservation); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := postReservationCharges(tx, reservation.Id, reservation.SupervisorId, models.ReservationCharges(reservation),
+		reservation.UpdatedBy); err != nil {
 		tx.Rollback()
 		return nil, err
 	}
@@ -133,251 +287,1131 @@ func (r *ReservationRepository) Update(ctx context.Context, id uint64, reservati
 	return result, err
 }
 
-// ChangeStatus changes the reservation check status.
-func (r ReservationRepository) ChangeStatus(ctx context.Context, id uint64, status models.ReservationCheckStatus) (*models.Reservation, error) {
+// ChangeStatus moves the reservation from given status to the new status and records the transition in history.
+// Status is updated only if it is still equal to from, otherwise InvalidStatusTransitionErr is returned,
+// so concurrent requests can not apply two transitions on the same status.
+func (r *ReservationRepository) ChangeStatus(ctx context.Context, reservation *models.Reservation,
+	status models.ReservationCheckStatus, changeTime time.Time, username string) (*models.Reservation, error) {
 
-	reservation := models.Reservation{}
 	db := r.DbResolver.GetTenantDB(ctx)
+	from := reservation.CheckStatus
 
-	if err := db.Find(&reservation, id).Error; err != nil {
-		return nil, err
+	if !models.CanTransition(from, status) {
+		return nil, models.InvalidStatusTransitionErr
 	}
-	if reservation.Id == 0 {
-		return nil, nil
+
+	values := map[string]interface{}{
+		"check_status": status,
+		"updated_by":   username,
 	}
 
-	if status == models.Checkout && reservation.CheckoutDate.After(time.Now()) {
-		checkoutDate := time.Now()
-		reservation.CheckoutDate = &checkoutDate
+	// early checkout releases rest of the nights.
+	if status == models.CheckedOut && reservation.CheckoutDate != nil && reservation.CheckoutDate.After(changeTime) {
+		values["checkout_date"] = changeTime
 	}
 
-	if err := db.Model(&models.Reservation{}).Where("id=?", id).Update("check_status", status).Error; err != nil {
-		return nil, err
+	tx := db.Begin()
+
+	query := tx.Model(&models.Reservation{}).Where("id=? AND check_status=?", reservation.Id, from).Updates(values)
+	if query.Error != nil {
+		tx.Rollback()
+		return nil, query.Error
 	}
-	return &reservation, nil
-}
 
-func (r *ReservationRepository) GetRecommendedRateCodes(ctx context.Context, priceDto *dto.GetRatePriceDto) ([]*dto.RateCodePricesDto, error) {
+	if query.RowsAffected == 0 {
+		tx.Rollback()
+		return nil, models.InvalidStatusTransitionErr
+	}
 
-	db := r.DbResolver.GetTenantDB(ctx)
-	ratePrices := make([]*dto.RateCodePricesDto, 0)
+	if err := addStatusHistory(tx, reservation.Id, &from, status, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	db.Table("rate_code_details details").Select(`
-	   parent.name as rate_code_name,
-       details.rate_code_id,
-       details.created_at,
-       details.room_id,
-       details.id AS detail_id,
-       details.date_start,
-       details.date_end,
-       prices.price,
-       prices.guest_count,
-       prices.id as rate_price_id
-	`).Joins(`
-       LEFT JOIN rate_code_detail_prices prices
-              ON prices.rate_code_detail_id = details.id
-         LEFT JOIN rate_codes parent 
-              ON details.rate_code_id = parent.id
-	`).Where(`
-		  details.room_id = ?
-		  AND prices.guest_count = ?
-		  AND details.min_nights <= ?
-		  AND details.date_start <= ?
-		  AND details.date_end >= ?
-          AND details.rate_code_id=?
-	`, priceDto.RoomId, priceDto.GuestCount, priceDto.NightCount, priceDto.DateStart,
-		priceDto.DateEnd, priceDto.RateCodeId).Scan(&ratePrices)
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
 
-	return ratePrices, nil
+	return r.Find(ctx, reservation.Id)
 }
 
-func (r *ReservationRepository) HasConflict(ctx context.Context, request *dto.RoomRequestDto, reservation *models.Reservation) (bool, error) {
+// FindNoShowCandidates returns confirmed reservations whose checkin date has passed,
+// rate code and hotel of the room are preloaded to apply no-show cutoff and fee.
+func (r *ReservationRepository) FindNoShowCandidates(ctx context.Context, now time.Time) ([]*models.Reservation, error) {
 
-	var reservationRequestCount int64 = 0
+	result := make([]*models.Reservation, 0)
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	if err := db.Model(&models.ReservationRequest{}).
-		Where("room_id=? AND check_in_date >=? AND check_out_date<=? ",
-			request.RoomId, request.CheckInDate, request.CheckOutDate).Count(&reservationRequestCount).Error; err != nil {
-		return false, err
+	if err := db.Preload("RateCode").Preload("Room.RoomType.Hotel").
+		Where("check_status=? AND checkin_date < ?", models.Confirmed, now).
+		Find(&result).Error; err != nil {
+		return nil, err
 	}
 
-	if reservationRequestCount > 0 {
-		return true, nil
-	}
+	return result, nil
+}
 
-	if request.RequestType == dto.CreateReservation {
-		hasReservationConflict, err := r.HasReservationConflict(ctx, request.CheckInDate, request.CheckOutDate, request.RoomId)
-		if err != nil {
-			return false, err
-		}
-		return hasReservationConflict, nil
-	}
+// MarkNoShow marks a confirmed reservation as no-show and posts the no-show fee as a DEBIT payment to its folio.
+// It returns InvalidStatusTransitionErr if the reservation is not confirmed anymore (e.g. guest checked in meanwhile).
+func (r *ReservationRepository) MarkNoShow(ctx context.Context, reservation *models.Reservation, fee float64,
+	now time.Time, username string) error {
 
-	if request.RequestType == dto.UpdateReservation && reservation != nil {
-		// check if reservation checkin or checkout date changes in update operation
-		// and prevent to conflict with other reservations in update operations.
-		if request.CheckInDate.Before(*reservation.CheckinDate) || request.CheckInDate.After(*request.CheckInDate) ||
-			request.CheckOutDate.Before(*reservation.CheckoutDate) || request.CheckInDate.After(*reservation.CheckoutDate) {
-
-			hasReservationConflict, err := r.HasReservationConflict(ctx, request.CheckInDate, request.CheckOutDate, request.RoomId)
-			if err != nil {
-				return false, err
-			}
-			return hasReservationConflict, nil
+	db := r.DbResolver.GetTenantDB(ctx)
+	from := models.Confirmed
 
-		}
+	tx := db.Begin()
 
+	query := tx.Model(&models.Reservation{}).Where("id=? AND check_status=?", reservation.Id, from).
+		Updates(map[string]interface{}{
+			"check_status": models.NoShow,
+			"updated_by":   username,
+		})
+	if query.Error != nil {
+		tx.Rollback()
+		return query.Error
 	}
 
-	return false, nil
-}
+	if query.RowsAffected == 0 {
+		tx.Rollback()
+		return models.InvalidStatusTransitionErr
+	}
 
-func (r *ReservationRepository) HasReservationConflict(ctx context.Context, checkInDate *time.Time, checkOutDate *time.Time, roomId uint64) (bool, error) {
+	if fee > 0 {
 
-	var count int64 = 0
-	db := r.DbResolver.GetTenantDB(ctx)
+		payment := models.Payment{
+			Amount:        fee,
+			PaymentType:   models.DEBIT,
+			PayerId:       reservation.SupervisorId,
+			PaymentDate:   &now,
+			ReservationId: reservation.Id,
+		}
+		payment.SetAudit(username)
 
-	if err := db.Model(&models.Reservation{}).
-		Where("room_id=? AND checkin_date >=? AND checkout_date<=?",
-			roomId, checkInDate, checkOutDate).Count(&count).Error; err != nil {
-		return false, err
+		if err := createPayment(tx, &payment, "no-show fee"); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	if count > 0 {
-		return true, nil
+	if err := addStatusHistory(tx, reservation.Id, &from, models.NoShow, username); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	return false, nil
+	return tx.Commit().Error
 }
 
-func (r *ReservationRepository) DeleteReservationRequest(ctx context.Context, requestKey string) error {
+// FindStatusHistory returns status transitions of given reservation from oldest to newest.
+func (r *ReservationRepository) FindStatusHistory(ctx context.Context, reservationId uint64) ([]*models.ReservationStatusHistory, error) {
 
-	var count int64 = 0
+	result := make([]*models.ReservationStatusHistory, 0)
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	if err := db.Model(models.ReservationRequest{}).Where("requestKey=?", requestKey).Count(&count).Error; err != nil {
-		return err
-	}
-	if count > 0 {
-		if err := db.Where("requestKey=?", requestKey).Delete(&models.ReservationRequest{}).Error; err != nil {
-			return err
-		}
+	if err := db.Where("reservation_id=?", reservationId).Order("id").Find(&result).Error; err != nil {
+		return nil, err
 	}
-	return nil
+
+	return result, nil
 }
 
-func (r *ReservationRepository) Find(ctx context.Context, id uint64) (*models.Reservation, error) {
+// Cancel cancels the reservation, reverses its charges and records the cancellation penalty of its rate code's policy
+// as a DEBIT payment in the same transaction. Reservation row is locked, so concurrent cancel requests
+// can not charge the penalty twice. It returns nil if the reservation does not exist.
+func (r *ReservationRepository) Cancel(ctx context.Context, id uint64, cancelTime time.Time, username string) (*dto.ReservationCancellationDto, error) {
 
 	reservation := models.Reservation{}
 	db := r.DbResolver.GetTenantDB(ctx)
 
-	query := db.Model(models.Reservation{})
-	query = r.preloadReservationRelations(query)
+	tx := db.Begin()
 
-	if err := query.Where("id=?", id).Find(&reservation).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&reservation).Error; err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
 	if reservation.Id == 0 {
+		tx.Rollback()
 		return nil, nil
 	}
 
-	return &reservation, nil
-}
+	if !models.CanTransition(reservation.CheckStatus, models.Cancelled) {
+		tx.Rollback()
+		return nil, ReservationNotCancellableErr
+	}
 
-func (r *ReservationRepository) FindReservationRequest(ctx context.Context, requestKey string) (*models.ReservationRequest, error) {
+	result := &dto.ReservationCancellationDto{
+		ReservationId: reservation.Id,
+		TenantId:      reservation.TenantId,
+		CancelledAt:   cancelTime,
+		CancelledBy:   username,
+	}
 
-	reservationRequest := models.ReservationRequest{}
-	db := r.DbResolver.GetTenantDB(ctx)
+	rateCode := models.RateCode{}
+	if err := tx.Preload("CancellationPolicy").Where("id=?", reservation.RateCodeId).Find(&rateCode).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	if err := db.Where("request_key=?", requestKey).Find(&reservationRequest).Error; err != nil {
+	// first night penalty charges the stored price of the first night.
+	if err := tx.Where("reservation_id=?", reservation.Id).Order("date").Find(&reservation.NightPrices).Error; err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	if reservationRequest.Id == 0 {
-		return nil, nil
+	if rateCode.CancellationPolicy != nil {
+		result.Penalty = rateCode.CancellationPolicy.CalculatePenalty(&reservation, cancelTime)
 	}
 
-	return &reservationRequest, nil
-}
+	if err := tx.Model(&models.Reservation{}).Where("id=?", id).Updates(map[string]interface{}{
+		"check_status": models.Cancelled,
+		"updated_by":   username,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-func (r *ReservationRepository) RemoveExpiredReservationRequests(ctx context.Context) error {
+	if result.Penalty > 0 {
 
-	db := r.DbResolver.GetTenantDB(ctx)
+		payment := models.Payment{
+			Amount:        result.Penalty,
+			PaymentType:   models.DEBIT,
+			PayerId:       reservation.SupervisorId,
+			PaymentDate:   &cancelTime,
+			ReservationId: reservation.Id,
+		}
+		payment.SetAudit(username)
 
-	err := db.Where("expire_time < ?", time.Now()).Delete(&models.ReservationRequest{}).Error
-	if err != nil {
-		return err
+		if err := createPayment(tx, &payment, "cancellation penalty"); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		result.Payment = &payment
 	}
 
-	return nil
-}
-
-func (r *ReservationRepository) FindAll(ctx context.Context, filter *dto.ReservationFilter) (error, *commons.PaginatedResult) {
+	// cancelled stay is not charged, only its penalty is.
+	if err := postReservationCharges(tx, reservation.Id, reservation.SupervisorId, nil, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	db := r.DbResolver.GetTenantDB(ctx)
-	reservations := make([]*models.Reservation, 0)
+	if err := addStatusHistory(tx, reservation.Id, &reservation.CheckStatus, models.Cancelled, username); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	query := db.Model(&models.Reservation{})
-	query = r.getReservationFilteredQuery(query, filter)
+	// cancelled reservations do not count in usage limits of promotions.
+	if err := releasePromotion(tx, reservation.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	if err := query.Scan(&reservations).Error; err != nil {
-		return err, nil
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
 	}
 
-	return nil, paginateWithFilter(query, reservations, filter, filter.Page, filter.PageSize, filter.IgnorePagination)
+	reservation.CheckStatus = models.Cancelled
+	reservation.UpdatedBy = username
+	result.Reservation = &reservation
 
+	return result, nil
 }
 
-/*================= private functions ===========================================================*/
+// GetRecommendedRateCodes returns price of the stay for every rate code of the room which has a price
+// for all nights of the stay, each quote contains per night breakdown. Quotes which are rejected by
+// stay restrictions contain message key of the rejection in Restriction.
+func (r *ReservationRepository) GetRecommendedRateCodes(ctx context.Context, priceDto *dto.GetRatePriceDto) ([]*dto.RateCodeQuoteDto, error) {
 
-func (r *ReservationRepository) preloadReservationRelations(query *gorm.DB) *gorm.DB {
-	return query.Preload("Room").Preload("Supervisor").Preload("RateCode").
-		Preload("Sharers").Preload("Sharers.Guest")
-}
+	candidates, err := r.findRatePriceCandidates(ctx, priceDto)
+	if err != nil {
+		return nil, err
+	}
 
-func (r *ReservationRepository) calculatePrice(ctx context.Context, reservation *models.Reservation) float64 {
+	quotes := make([]*dto.RateCodeQuoteDto, 0)
+	rateCodes := make(map[uint64][]*dto.RateCodePricesDto)
 
-	priceDto := &dto.GetRatePriceDto{
-		RoomId:     reservation.RoomId,
-		NightCount: reservation.Nights,
-		GuestCount: reservation.GuestCount,
-		DateStart:  reservation.CheckinDate,
-		DateEnd:    reservation.CheckoutDate,
-		RateCodeId: reservation.RateCodeId,
+	for _, candidate := range candidates {
+		if _, ok := rateCodes[candidate.RateCodeId]; !ok {
+			quotes = append(quotes, &dto.RateCodeQuoteDto{
+				RateCodeId:   candidate.RateCodeId,
+				RateCodeName: candidate.RateCodeName,
+				CurrencyId:   candidate.CurrencyId,
+			})
+		}
+		rateCodes[candidate.RateCodeId] = append(rateCodes[candidate.RateCodeId], candidate)
 	}
 
-	prices, err := r.GetRecommendedRateCodes(ctx, priceDto)
-
+	taxRules, err := r.TaxRuleRepository.FindApplicable(ctx, priceDto.RoomId)
 	if err != nil {
-		return 0
+		return nil, err
 	}
 
-	if len(prices) == 0 {
-		return 0
+	now := time.Now()
+	nights := date_utils.Nights(*priceDto.DateStart, *priceDto.DateEnd)
+	result := make([]*dto.RateCodeQuoteDto, 0, len(quotes))
+
+	pricing, err := r.PricingRuleRepository.loadNightPricing(ctx, priceDto.RoomId, *priceDto.DateStart, *priceDto.DateEnd, now, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(prices) == 1 {
-		return prices[0].Price * reservation.Nights
+	for _, quote := range quotes {
+
+		nightPrices, complete := resolveNightPrices(nights, rateCodes[quote.RateCodeId])
+		if !complete {
+			continue
+		}
+
+		// adjustments of quotes are not logged, they are logged when a reservation is booked by the quote.
+		pricing.adjust(nightPrices, "")
+
+		quote.Nights = nightPrices
+		quote.Total = sumNightPrices(nightPrices)
+
+		guests := priceDto.GuestCount + uint64(len(priceDto.ChildAges))
+		for _, tax := range models.CalculateTaxes(taxRules, quote.Total, uint64(len(nightPrices)), guests) {
+			quote.TaxAmount += tax.Amount
+		}
+
+		if err := checkStayRestrictions(*priceDto.DateStart, *priceDto.DateEnd, now, rateCodes[quote.RateCodeId]); err != nil {
+			quote.Restriction = err.Error()
+		}
+
+		if priceDto.CurrencyId != 0 {
+			if err := r.convertQuote(ctx, quote, priceDto.CurrencyId, now); err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, quote)
 	}
 
-	defaultPrice := prices[0]
+	return result, nil
+}
+
+// convertQuote converts quote amounts to given currency by the current exchange rate, if there is no rate
+// for the quote currency the quote can not be booked in that currency and it is marked by Restriction.
+func (r *ReservationRepository) convertQuote(ctx context.Context, quote *dto.RateCodeQuoteDto, currencyId uint64, now time.Time) error {
 
-	for _, price := range prices {
-		// get latest inserted.
-		if price.CreatedAt.After(*defaultPrice.CreatedAt) {
-			defaultPrice = price
+	rate, err := r.ExchangeRateRepository.GetRate(ctx, quote.CurrencyId, currencyId, now)
+	if errors.Is(err, ExchangeRateNotFoundErr) {
+		if quote.Restriction == "" {
+			quote.Restriction = err.Error()
 		}
+		return nil
+	}
+
+	if err != nil {
+		return err
 	}
-	return defaultPrice.Price * reservation.Nights
+
+	quote.QuoteCurrencyId = currencyId
+	quote.ExchangeRate = rate
+	quote.ConvertedTotal = models.ConvertAmount(quote.Total, rate)
+	quote.ConvertedTaxAmount = models.ConvertAmount(quote.TaxAmount, rate)
+
+	return nil
 }
 
-// fill calculation fields
-func (r *ReservationRepository) setReservationCalcFields(ctx context.Context, reservation *models.Reservation) {
-	reservation.Nights = math.Round(reservation.CheckoutDate.Sub(*reservation.CheckinDate).Hours() / 24)
-	reservation.GuestCount = uint64(len(reservation.Sharers))
-	reservation.Price = r.calculatePrice(ctx, reservation)
+// CheckStayRestrictions checks stay restrictions of the rate code for given room and stay which is booked at bookedAt,
+// it returns one of the stay restriction errors if the stay can not be booked.
+func (r *ReservationRepository) CheckStayRestrictions(ctx context.Context, roomId uint64, rateCodeId uint64,
+	checkInDate *time.Time, checkOutDate *time.Time, bookedAt time.Time) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	details := make([]*dto.RateCodePricesDto, 0)
+
+	// derived rate codes follow restrictions of their parent rate code.
+	rateCode := models.RateCode{}
+	if err := db.Select("id", "parent_rate_code_id").Where("id=?", rateCodeId).Find(&rateCode).Error; err != nil {
+		return err
+	}
+
+	if rateCode.IsDerived() {
+		rateCodeId = *rateCode.ParentRateCodeId
+	}
+
+	if err := db.Table("rate_code_details details").Select(`
+       details.rate_code_id,
+       details.created_at,
+       details.room_id,
+       details.id AS detail_id,
+       details.date_start,
+       details.date_end,
+       `+rateCodeDetailRestrictionColumns).Where(`
+		  details.room_id = ?
+		  AND details.rate_code_id = ?
+		  AND details.date_start <= ?
+		  AND details.date_end >= ?
+		  AND details.deleted_at IS NULL
+	`, roomId, rateCodeId, checkOutDate, checkInDate.AddDate(0, 0, -1)).Scan(&details).Error; err != nil {
+		return err
+	}
+
+	return checkStayRestrictions(*checkInDate, *checkOutDate, bookedAt, details)
+}
+
+// findRatePriceCandidates returns rate prices of rate code details which cover at least one day of the stay,
+// prices include extra adults, children and extra beds of the stay.
+func (r *ReservationRepository) findRatePriceCandidates(ctx context.Context, priceDto *dto.GetRatePriceDto) ([]*dto.RateCodePricesDto, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	ratePrices := make([]*dto.RateCodePricesDto, 0)
+
+	// children whose age is not in an age band of the hotel are priced as adults.
+	adults := priceDto.GuestCount
+	childAgeBandIds := make([]uint64, 0)
+
+	if len(priceDto.ChildAges) > 0 {
+
+		bands, err := findChildAgeBands(db, priceDto.RoomId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, age := range priceDto.ChildAges {
+			if band := models.FindAgeBand(bands, age); band != nil {
+				childAgeBandIds = append(childAgeBandIds, band.Id)
+			} else {
+				adults++
+			}
+		}
+	}
+
+	query := db.Table("rate_code_details details").Select(`
+	   parent.name as rate_code_name,
+	   parent.currency_id,
+       details.rate_code_id,
+       details.created_at,
+       details.room_id,
+       details.id AS detail_id,
+       details.date_start,
+       details.date_end,
+       prices.price,
+       prices.guest_count,
+       prices.id as rate_price_id,
+       details.extra_adult_price,
+       details.extra_bed_price,
+       `+rateCodeDetailRestrictionColumns).Joins(`
+       INNER JOIN rate_code_detail_prices prices
+              ON prices.rate_code_detail_id = details.id
+         LEFT JOIN rate_codes parent 
+              ON details.rate_code_id = parent.id
+	`).Where(`
+		  details.room_id = ?
+		  AND prices.guest_count <= ?
+		  AND details.date_start <= ?
+		  AND details.date_end >= ?
+		  AND details.deleted_at IS NULL
+		  AND prices.deleted_at IS NULL
+	`, priceDto.RoomId, adults, priceDto.DateEnd, priceDto.DateStart.AddDate(0, 0, -1))
+
+	// derived rate codes are priced by details of their parent rate code.
+	derived := make([]*models.RateCode, 0)
+	derivedQuery := db.Where("parent_rate_code_id IS NOT NULL")
+	if priceDto.RateCodeId != 0 {
+		derivedQuery = derivedQuery.Where("id = ?", priceDto.RateCodeId)
+	}
+
+	if err := derivedQuery.Find(&derived).Error; err != nil {
+		return nil, err
+	}
+
+	query = query.Where("parent.parent_rate_code_id IS NULL")
+
+	if priceDto.RateCodeId != 0 {
+		rateCodeIds := []uint64{priceDto.RateCodeId}
+		for _, rateCode := range derived {
+			rateCodeIds = append(rateCodeIds, *rateCode.ParentRateCodeId)
+		}
+		query = query.Where("details.rate_code_id IN ?", rateCodeIds)
+	}
+
+	if err := query.Scan(&ratePrices).Error; err != nil {
+		return nil, err
+	}
+
+	ratePrices = selectGuestPrices(ratePrices, adults)
+
+	if len(childAgeBandIds) > 0 || priceDto.ExtraBeds > 0 {
+
+		childPrices, err := findChildPrices(db, ratePrices)
+		if err != nil {
+			return nil, err
+		}
+
+		addOccupancyPrices(ratePrices, childPrices, childAgeBandIds, priceDto.ExtraBeds)
+	}
+
+	// derived prices are calculated on the price of the whole stay occupancy.
+	ratePrices = deriveRatePrices(ratePrices, derived)
+
+	if priceDto.RateCodeId == 0 {
+		return ratePrices, nil
+	}
+
+	result := make([]*dto.RateCodePricesDto, 0, len(ratePrices))
+	for _, ratePrice := range ratePrices {
+		if ratePrice.RateCodeId == priceDto.RateCodeId {
+			result = append(result, ratePrice)
+		}
+	}
+
+	return result, nil
+}
+
+// HasConflict checks if given room request overlaps with a not expired reservation request (hold)
+// or an active reservation of the same room. If reservation is not nil, it is the reservation being edited
+// and it is excluded from conflict detection.
+func (r *ReservationRepository) HasConflict(ctx context.Context, request *dto.RoomRequestDto, reservation *models.Reservation) (bool, error) {
+
+	var reservationRequestCount int64 = 0
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(&models.ReservationRequest{}).
+		Where("room_id=? AND check_in_date < ? AND check_out_date > ? AND expire_time > ?",
+			request.RoomId, request.CheckOutDate, request.CheckInDate, time.Now()).Count(&reservationRequestCount).Error; err != nil {
+		return false, err
+	}
+
+	if reservationRequestCount > 0 {
+		return true, nil
+	}
+
+	var excludeReservationId uint64 = 0
+	if request.RequestType == dto.UpdateReservation && reservation != nil {
+		excludeReservationId = reservation.Id
+	}
+
+	return r.HasReservationConflict(ctx, request.CheckInDate, request.CheckOutDate, request.RoomId, excludeReservationId)
+}
+
+// HasReservationConflict checks if the half-open stay [checkInDate, checkOutDate) overlaps with
+// an active reservation of given room. Checked-out and cancelled reservations are ignored
+// and excludeReservationId (the reservation being edited) is not counted as a conflict.
+func (r *ReservationRepository) HasReservationConflict(ctx context.Context, checkInDate *time.Time, checkOutDate *time.Time,
+	roomId uint64, excludeReservationId uint64) (bool, error) {
+
+	var count int64 = 0
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	query := db.Model(&models.Reservation{}).
+		Where("room_id=? AND "+overlapsStaySQL+" AND id <> ?", roomId, checkInDate, checkOutDate, excludeReservationId)
+
+	if err := activeReservations(query).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *ReservationRepository) DeleteReservationRequest(ctx context.Context, requestKey string) error {
+
+	var count int64 = 0
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(models.ReservationRequest{}).Where("requestKey=?", requestKey).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		if err := db.Where("requestKey=?", requestKey).Delete(&models.ReservationRequest{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReservationRepository) Find(ctx context.Context, id uint64) (*models.Reservation, error) {
+
+	reservation := models.Reservation{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	query := db.Model(models.Reservation{})
+	query = r.preloadReservationRelations(query)
+
+	if err := query.Where("id=?", id).Find(&reservation).Error; err != nil {
+		return nil, err
+	}
+
+	if reservation.Id == 0 {
+		return nil, nil
+	}
+
+	return &reservation, nil
+}
+
+func (r *ReservationRepository) FindReservationRequest(ctx context.Context, requestKey string) (*models.ReservationRequest, error) {
+
+	reservationRequest := models.ReservationRequest{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Where("request_key=?", requestKey).Find(&reservationRequest).Error; err != nil {
+		return nil, err
+	}
+
+	if reservationRequest.Id == 0 {
+		return nil, nil
+	}
+
+	return &reservationRequest, nil
+}
+
+func (r *ReservationRepository) RemoveExpiredReservationRequests(ctx context.Context) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	err := db.Where("expire_time < ?", time.Now()).Delete(&models.ReservationRequest{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) FindAll(ctx context.Context, filter *dto.ReservationFilter) (error, *commons.PaginatedResult) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	reservations := make([]*models.Reservation, 0)
+
+	query := db.Model(&models.Reservation{})
+	query = r.getReservationFilteredQuery(query, filter)
+
+	if err := query.Scan(&reservations).Error; err != nil {
+		return err, nil
+	}
+
+	return nil, paginateWithFilter(query, reservations, filter, filter.Page, filter.PageSize, filter.IgnorePagination)
+
+}
+
+// FindRoomsByType returns rooms of given room type, if roomTypeId is 0 it returns all rooms.
+func (r *ReservationRepository) FindRoomsByType(ctx context.Context, roomTypeId uint64) ([]*models.Room, error) {
+
+	rooms := make([]*models.Room, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	query := db.Model(&models.Room{}).Preload("RoomType")
+	if roomTypeId != 0 {
+		query = query.Where("room_type_id=?", roomTypeId)
+	}
+
+	if err := query.Order("room_type_id, id").Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// FindOverlappingReservations returns reservations of given rooms which occupy at least one night between from and to.
+func (r *ReservationRepository) FindOverlappingReservations(ctx context.Context, roomIds []uint64, from, to *time.Time) ([]*models.Reservation, error) {
+
+	reservations := make([]*models.Reservation, 0)
+	if len(roomIds) == 0 {
+		return reservations, nil
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	query := db.Model(&models.Reservation{}).
+		Where("room_id IN ? AND "+overlapsStaySQL, roomIds, from, to)
+
+	if err := activeReservations(query).Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// FindOverlappingReservationRequests returns not expired reservation requests of given rooms
+// which hold at least one night between from and to.
+func (r *ReservationRepository) FindOverlappingReservationRequests(ctx context.Context, roomIds []uint64, from, to *time.Time) ([]*models.ReservationRequest, error) {
+
+	requests := make([]*models.ReservationRequest, 0)
+	if len(roomIds) == 0 {
+		return requests, nil
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Model(&models.ReservationRequest{}).
+		Where("room_id IN ? AND check_in_date < ? AND check_out_date > ? AND expire_time > ?", roomIds, to, from, time.Now()).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+/*================= private functions ===========================================================*/
+
+// roomHotelId returns a sub query which selects hotel id of given room.
+func roomHotelId(db *gorm.DB, roomId uint64) *gorm.DB {
+	return db.Model(&models.RoomType{}).Select("hotel_id").
+		Where("id = (?)", db.Model(&models.Room{}).Select("room_type_id").Where("id=?", roomId))
+}
+
+// findChildAgeBands returns child age bands of the hotel of given room.
+func findChildAgeBands(db *gorm.DB, roomId uint64) ([]*models.ChildAgeBand, error) {
+
+	bands := make([]*models.ChildAgeBand, 0)
+
+	if err := db.Where("hotel_id = (?)", roomHotelId(db, roomId)).Order("min_age").Find(&bands).Error; err != nil {
+		return nil, err
+	}
+
+	return bands, nil
+}
+
+// findChildPrices returns child prices of details of given rate prices by detail id and age band id.
+func findChildPrices(db *gorm.DB, ratePrices []*dto.RateCodePricesDto) (map[uint64]map[uint64]float64, error) {
+
+	result := make(map[uint64]map[uint64]float64)
+	detailIds := make([]uint64, 0, len(ratePrices))
+
+	for _, ratePrice := range ratePrices {
+		detailIds = append(detailIds, ratePrice.DetailId)
+	}
+
+	if len(detailIds) == 0 {
+		return result, nil
+	}
+
+	childPrices := make([]*models.RateCodeDetailChildPrice, 0)
+	if err := db.Where("rate_code_detail_id IN ?", detailIds).Find(&childPrices).Error; err != nil {
+		return nil, err
+	}
+
+	for _, childPrice := range childPrices {
+		if result[childPrice.RateCodeDetailId] == nil {
+			result[childPrice.RateCodeDetailId] = make(map[uint64]float64)
+		}
+		result[childPrice.RateCodeDetailId][childPrice.ChildAgeBandId] = childPrice.Price
+	}
+
+	return result, nil
+}
+
+// FindStayOverlaps returns pairs of active reservations of a room whose stays overlap, overlaps can only exist if they
+// are booked before the stays are constrained, see tenant_dsn_resolver.GetConstraints.
+func (r *ReservationRepository) FindStayOverlaps(ctx context.Context) ([]*dto.StayOverlapDto, error) {
+
+	result := make([]*dto.StayOverlapDto, 0)
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Table("reservations a").Select(`
+	   a.room_id,
+	   a.id AS reservation_id,
+	   a.checkin_date,
+	   a.checkout_date,
+	   b.id AS overlapping_reservation_id,
+	   b.checkin_date AS overlapping_checkin_date,
+	   b.checkout_date AS overlapping_checkout_date`).Joins(`
+	   INNER JOIN reservations b ON b.room_id = a.room_id AND b.id > a.id AND `+
+		models.StayRangeSQL("a.")+" && "+models.StayRangeSQL("b.")).Where(`
+		  a.deleted_at IS NULL AND b.deleted_at IS NULL
+		  AND a.is_group_master = false AND b.is_group_master = false
+		  AND a.check_status NOT IN ? AND b.check_status NOT IN ?
+	`, models.ReleasedCheckStatuses, models.ReleasedCheckStatuses).Order("a.room_id, a.id, b.id").Scan(&result).Error; err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// activeReservations filters reservations which still occupy their room.
+func activeReservations(query *gorm.DB) *gorm.DB {
+	return query.Where("check_status NOT IN ?", models.ReleasedCheckStatuses)
+}
+
+// addStatusHistory inserts a status transition of the reservation in given transaction.
+func addStatusHistory(tx *gorm.DB, reservationId uint64, from *models.ReservationCheckStatus,
+	to models.ReservationCheckStatus, username string) error {
+
+	changedAt := time.Now()
+	history := models.ReservationStatusHistory{
+		ReservationId: reservationId,
+		FromStatus:    from,
+		ToStatus:      to,
+		ChangedAt:     &changedAt,
+	}
+	history.SetAudit(username)
+
+	return tx.Create(&history).Error
+}
+
+// translateReservationError converts database exclusion constraint violation
+// (two overlapping reservations for the same room) to ReservationConflictErr.
+func translateReservationError(err error) error {
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolationCode {
+		return ReservationConflictErr
+	}
+
+	return err
+}
+
+func (r *ReservationRepository) preloadReservationRelations(query *gorm.DB) *gorm.DB {
+	return query.Preload("Room").Preload("Supervisor").Preload("RateCode").
+		Preload("Sharers").Preload("Sharers.Guest").
+		Preload("NightPrices", func(query *gorm.DB) *gorm.DB { return query.Order("date") }).
+		Preload("Taxes", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).
+		Preload("ChildGuests", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).
+		Preload("AddOns", func(query *gorm.DB) *gorm.DB { return query.Order("id") }).Preload("AddOns.AddOn")
+}
+
+// priceReservation calculates room price of the reservation and the taxes which apply to it,
+// taxes are stored as itemized lines in reservation.Taxes and their sum in reservation.TaxAmount.
+// Promotion of the reservation is applied on the room price before taxes and amounts are converted to the
+// guest currency too, see applyPromotion and convertPrice. bookedAt is the time which the reservation is booked.
+func (r *ReservationRepository) priceReservation(ctx context.Context, reservation *models.Reservation, bookedAt time.Time) error {
+
+	if err := r.priceNights(ctx, reservation, bookedAt); err != nil {
+		return err
+	}
+
+	if err := r.applyPromotion(ctx, reservation, bookedAt); err != nil {
+		return err
+	}
+
+	reservation.Taxes = make([]*models.ReservationTax, 0)
+	reservation.TaxAmount = 0
+
+	rules, err := r.TaxRuleRepository.FindApplicable(ctx, reservation.RoomId)
+	if err != nil {
+		return err
+	}
+
+	reservation.Taxes = models.CalculateTaxes(rules, reservation.Price, uint64(len(reservation.NightPrices)), reservation.GuestCount)
+
+	for _, tax := range reservation.Taxes {
+		tax.CreatedBy = reservation.UpdatedBy
+		tax.UpdatedBy = reservation.UpdatedBy
+		reservation.TaxAmount += tax.Amount
+	}
+
+	if err := r.priceAddOns(ctx, reservation); err != nil {
+		return err
+	}
+
+	return r.convertPrice(ctx, reservation)
+}
+
+// priceAddOns prices selected add-ons of the reservation and adds add-ons which are bundled by its rate code,
+// see models.PriceAddOns. Only active add-ons of the hotel of the reservation room can be sold.
+func (r *ReservationRepository) priceAddOns(ctx context.Context, reservation *models.Reservation) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	bundles := make([]*models.RateCodeAddOn, 0)
+	if err := db.Where("rate_code_id=?", reservation.RateCodeId).Order("id").Find(&bundles).Error; err != nil {
+		return err
+	}
+
+	addOnIds := make([]uint64, 0, len(reservation.AddOns)+len(bundles))
+	for _, item := range reservation.AddOns {
+		addOnIds = append(addOnIds, item.AddOnId)
+	}
+	for _, bundle := range bundles {
+		addOnIds = append(addOnIds, bundle.AddOnId)
+	}
+
+	catalog := make(map[uint64]*models.AddOn)
+
+	if len(addOnIds) > 0 {
+
+		addOns := make([]*models.AddOn, 0)
+		if err := db.Where("id IN ? AND active=?", addOnIds, true).
+			Where("hotel_id = (?)", roomHotelId(db, reservation.RoomId)).Find(&addOns).Error; err != nil {
+			return err
+		}
+
+		for _, addOn := range addOns {
+			catalog[addOn.Id] = addOn
+		}
+	}
+
+	addOns, amount, err := models.PriceAddOns(reservation.AddOns, catalog, bundles, *reservation.CheckinDate,
+		*reservation.CheckoutDate, reservation.GuestCount)
+	if err != nil {
+		return err
+	}
+
+	// catalog add-ons are not saved with the reservation.
+	for _, item := range addOns {
+		item.AddOn = nil
+		item.CreatedBy = reservation.UpdatedBy
+		item.UpdatedBy = reservation.UpdatedBy
+	}
+
+	reservation.AddOns = addOns
+	reservation.AddOnAmount = amount
+
+	return nil
+}
+
+// applyPromotion discounts price of the reservation by promotion of its promo code, it returns PromotionNotFoundErr
+// if there is no promotion with the code and PromotionNotApplicableErr if the promotion is not valid for the stay.
+func (r *ReservationRepository) applyPromotion(ctx context.Context, reservation *models.Reservation, bookedAt time.Time) error {
+
+	reservation.PromoCode = models.NormalizeCode(reservation.PromoCode)
+	reservation.PromotionId = nil
+	reservation.DiscountAmount = 0
+
+	if reservation.PromoCode == "" {
+		return nil
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	promotion, err := findPromotionByCode(db, reservation.PromoCode)
+	if err != nil {
+		return err
+	}
+
+	if promotion == nil {
+		return models.PromotionNotFoundErr
+	}
+
+	room := models.Room{}
+	if err := db.Select("id", "room_type_id").Where("id=?", reservation.RoomId).Find(&room).Error; err != nil {
+		return err
+	}
+
+	if err := promotion.CheckApplicable(bookedAt, *reservation.CheckinDate, *reservation.CheckoutDate,
+		reservation.RateCodeId, room.RoomTypeId); err != nil {
+		return err
+	}
+
+	nightPrices := make([]float64, 0, len(reservation.NightPrices))
+	for _, night := range reservation.NightPrices {
+		nightPrices = append(nightPrices, night.Price)
+	}
+
+	reservation.PromotionId = &promotion.Id
+	reservation.DiscountAmount = promotion.Discount(nightPrices)
+	reservation.Price -= reservation.DiscountAmount
+
+	return nil
+}
+
+// convertPrice converts price, taxes and add-ons of the reservation from its rate code currency to the guest currency,
+// reservation.ExchangeRate is used if it is already locked, otherwise the current rate is locked.
+// Reservations without a guest currency are charged in the rate code currency.
+func (r *ReservationRepository) convertPrice(ctx context.Context, reservation *models.Reservation) error {
+
+	rateCode := models.RateCode{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Select("id", "currency_id").Where("id=?", reservation.RateCodeId).Find(&rateCode).Error; err != nil {
+		return err
+	}
+
+	reservation.BaseCurrencyId = rateCode.CurrencyId
+	if reservation.CurrencyId == 0 {
+		reservation.CurrencyId = rateCode.CurrencyId
+	}
+
+	if reservation.ExchangeRate == 0 {
+		rate, err := r.ExchangeRateRepository.GetRate(ctx, reservation.BaseCurrencyId, reservation.CurrencyId, time.Now())
+		if err != nil {
+			return err
+		}
+		reservation.ExchangeRate = rate
+	}
+
+	reservation.ConvertedPrice = models.ConvertAmount(reservation.Price, reservation.ExchangeRate)
+	reservation.ConvertedTaxAmount = models.ConvertAmount(reservation.TaxAmount, reservation.ExchangeRate)
+	reservation.ConvertedAddOnAmount = models.ConvertAmount(reservation.AddOnAmount, reservation.ExchangeRate)
+
+	return nil
+}
+
+// priceNights resolves price of every night of the reservation from its rate code and fills
+// reservation.NightPrices and reservation.Price, RateNotAvailableErr is returned if a night has no price. Night prices are
+// adjusted by pricing rules and the applied adjustments are kept in reservation.PriceAdjustments to be logged.
+func (r *ReservationRepository) priceNights(ctx context.Context, reservation *models.Reservation, bookedAt time.Time) error {
+
+	reservation.NightPrices = make([]*models.ReservationNight, 0)
+	reservation.PriceAdjustments = make([]*models.PriceAdjustmentLog, 0)
+	reservation.Price = 0
+
+	priceDto := &dto.GetRatePriceDto{
+		RoomId:     reservation.RoomId,
+		NightCount: reservation.Nights,
+		GuestCount: reservation.GuestCount - uint64(len(reservation.ChildGuests)),
+		DateStart:  reservation.CheckinDate,
+		DateEnd:    reservation.CheckoutDate,
+		RateCodeId: reservation.RateCodeId,
+		ChildAges:  make([]uint64, 0, len(reservation.ChildGuests)),
+		ExtraBeds:  reservation.ExtraBeds,
+	}
+
+	if len(reservation.ChildGuests) > 0 {
+
+		bands, err := findChildAgeBands(r.DbResolver.GetTenantDB(ctx), reservation.RoomId)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range reservation.ChildGuests {
+			child.ChildAgeBandId = nil
+			child.CreatedBy = reservation.UpdatedBy
+			child.UpdatedBy = reservation.UpdatedBy
+			if band := models.FindAgeBand(bands, child.Age); band != nil {
+				child.ChildAgeBandId = &band.Id
+			}
+			priceDto.ChildAges = append(priceDto.ChildAges, child.Age)
+		}
+	}
+
+	candidates, err := r.findRatePriceCandidates(ctx, priceDto)
+	if err != nil {
+		return err
+	}
+
+	// every night of the stay must be priced by the rate code, like quotes of rate codes.
+	nightPrices, complete := resolveNightPrices(date_utils.Nights(*reservation.CheckinDate, *reservation.CheckoutDate), candidates)
+	if len(candidates) == 0 || !complete {
+		return RateNotAvailableErr
+	}
+
+	pricing, err := r.PricingRuleRepository.loadNightPricing(ctx, reservation.RoomId, *reservation.CheckinDate,
+		*reservation.CheckoutDate, bookedAt, reservation.Id)
+	if err != nil {
+		return err
+	}
+
+	reservation.PriceAdjustments = pricing.adjust(nightPrices, reservation.UpdatedBy)
+
+	for _, nightPrice := range nightPrices {
+		date := nightPrice.Date
+		night := &models.ReservationNight{
+			Date:             &date,
+			RateCodeId:       nightPrice.RateCodeId,
+			RateCodeDetailId: nightPrice.RateCodeDetailId,
+			RatePriceId:      nightPrice.RatePriceId,
+			Price:            nightPrice.Price,
+			BasePrice:        nightPrice.BasePrice,
+			PricingRuleId:    nightPrice.PricingRuleId,
+		}
+		night.CreatedBy = reservation.UpdatedBy
+		night.UpdatedBy = reservation.UpdatedBy
+		reservation.NightPrices = append(reservation.NightPrices, night)
+	}
+
+	reservation.Price = sumNightPrices(nightPrices)
+
+	return nil
+}
+
+// replaceNightPrices removes old nights of the reservation and stores its current nights in given transaction.
+func replaceNightPrices(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if err := tx.Where("reservation_id=?", reservation.Id).Delete(&models.ReservationNight{}).Error; err != nil {
+		return err
+	}
+
+	for _, night := range reservation.NightPrices {
+		night.Id = 0
+		night.ReservationId = reservation.Id
+	}
+
+	if len(reservation.NightPrices) == 0 {
+		return nil
+	}
+
+	return tx.Create(&reservation.NightPrices).Error
+}
+
+// replaceTaxes removes old tax lines of the reservation and stores its current taxes in given transaction.
+func replaceTaxes(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if err := tx.Where("reservation_id=?", reservation.Id).Delete(&models.ReservationTax{}).Error; err != nil {
+		return err
+	}
+
+	for _, tax := range reservation.Taxes {
+		tax.Id = 0
+		tax.ReservationId = reservation.Id
+	}
+
+	if len(reservation.Taxes) == 0 {
+		return nil
+	}
+
+	return tx.Create(&reservation.Taxes).Error
+}
+
+// fill calculation fields
+func (r *ReservationRepository) setReservationCalcFields(ctx context.Context, reservation *models.Reservation, bookedAt time.Time) error {
+	reservation.Nights = math.Round(reservation.CheckoutDate.Sub(*reservation.CheckinDate).Hours() / 24)
+
+	// clients which do not send adults count every sharer who is not a child as an adult.
+	if reservation.Adults == 0 && len(reservation.Sharers) > len(reservation.ChildGuests) {
+		reservation.Adults = uint64(len(reservation.Sharers) - len(reservation.ChildGuests))
+	}
+	reservation.GuestCount = reservation.Adults + uint64(len(reservation.ChildGuests))
+
+	if err := r.checkOccupancy(ctx, reservation); err != nil {
+		return err
+	}
+
+	return r.priceReservation(ctx, reservation, bookedAt)
+}
+
+// checkOccupancy checks guests and extra beds of the reservation against its room, see models.CheckOccupancy.
+func (r *ReservationRepository) checkOccupancy(ctx context.Context, reservation *models.Reservation) error {
+
+	room := models.Room{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("RoomType").Where("id=?", reservation.RoomId).Find(&room).Error; err != nil {
+		return err
+	}
+
+	return models.CheckOccupancy(&room, reservation.Adults, uint64(len(reservation.ChildGuests)), reservation.ExtraBeds)
+}
+
+// replaceAddOns removes old add-ons of the reservation and stores its current add-ons in given transaction.
+func replaceAddOns(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if err := tx.Where("reservation_id=?", reservation.Id).Delete(&models.ReservationAddOn{}).Error; err != nil {
+		return err
+	}
+
+	for _, item := range reservation.AddOns {
+		item.Id = 0
+		item.ReservationId = reservation.Id
+	}
+
+	if len(reservation.AddOns) == 0 {
+		return nil
+	}
+
+	return tx.Omit("AddOn").Create(&reservation.AddOns).Error
+}
+
+// replaceChildGuests removes old children of the reservation and stores its current children in given transaction.
+func replaceChildGuests(tx *gorm.DB, reservation *models.Reservation) error {
+
+	if err := tx.Where("reservation_id=?", reservation.Id).Delete(&models.ReservationChild{}).Error; err != nil {
+		return err
+	}
+
+	for _, child := range reservation.ChildGuests {
+		child.Id = 0
+		child.ReservationId = reservation.Id
+	}
+
+	if len(reservation.ChildGuests) == 0 {
+		return nil
+	}
+
+	return tx.Create(&reservation.ChildGuests).Error
 }
 
 func (r *ReservationRepository) getReservationFilteredQuery(query *gorm.DB, filter *dto.ReservationFilter) *gorm.DB {
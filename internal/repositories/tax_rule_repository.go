@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+)
+
+type TaxRuleRepository struct {
+	DbResolver *tenant_database_resolver.TenantDatabaseResolver
+}
+
+// NewTaxRuleRepository returns new TaxRuleRepository.
+func NewTaxRuleRepository(r *tenant_database_resolver.TenantDatabaseResolver) *TaxRuleRepository {
+
+	return &TaxRuleRepository{DbResolver: r}
+}
+
+func (r *TaxRuleRepository) Create(ctx context.Context, model *models.TaxRule) (*models.TaxRule, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Create(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *TaxRuleRepository) Update(ctx context.Context, model *models.TaxRule) (*models.TaxRule, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	// active and scope fields can be reset to zero values, so all columns are saved.
+	if tx := db.Select("*").Omit("created_at", "created_by").Updates(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return model, nil
+}
+
+func (r *TaxRuleRepository) Find(ctx context.Context, id uint64) (*models.TaxRule, error) {
+
+	model := models.TaxRule{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if tx := db.Where("id=?", id).Find(&model); tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if model.Id == 0 {
+		return nil, nil
+	}
+
+	return &model, nil
+}
+
+func (r *TaxRuleRepository) FindAll(ctx context.Context, input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	return paginatedList(&models.TaxRule{}, db, input)
+}
+
+// FindApplicable returns active tax rules which apply to the hotel of given room, a rule applies
+// if each of its country, province, city and hotel scopes is empty or equal to the hotel's one.
+func (r *TaxRuleRepository) FindApplicable(ctx context.Context, roomId uint64) ([]*models.TaxRule, error) {
+
+	rules := make([]*models.TaxRule, 0)
+	room := models.Room{}
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if err := db.Preload("RoomType.Hotel.Province").Where("id=?", roomId).Find(&room).Error; err != nil {
+		return nil, err
+	}
+
+	if room.RoomType.Hotel == nil {
+		return rules, nil
+	}
+
+	hotel := room.RoomType.Hotel
+	var countryId uint64 = 0
+	if hotel.Province != nil {
+		countryId = hotel.Province.CountryId
+	}
+
+	if err := db.Where("active=?", true).
+		Where("country_id IS NULL OR country_id=?", countryId).
+		Where("province_id IS NULL OR province_id=?", hotel.ProvinceId).
+		Where("city_id IS NULL OR city_id=?", hotel.CityId).
+		Where("hotel_id IS NULL OR hotel_id=?", hotel.Id).
+		Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Delete removes TaxRule, taxes of existing reservations keep their calculated amount.
+func (r *TaxRuleRepository) Delete(ctx context.Context, id uint64) error {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+
+	if query := db.Model(&models.TaxRule{}).Where("id=?", id).Delete(&models.TaxRule{}); query.Error != nil {
+		return query.Error
+	}
+
+	return nil
+}
@@ -2,11 +2,14 @@ package repositories
 
 import (
 	"context"
-	"errors"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
 	. "reservation-api/internal/models"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"time"
 )
 
 type WalletRepository struct {
@@ -17,66 +20,365 @@ func NewWalletRepository(resolver *tenant_database_resolver.TenantDatabaseResolv
 	return &WalletRepository{resolver}
 }
 
+// CreateWallet creates the wallet with zero balance, its initial balance is posted as a deposit.
 func (r *WalletRepository) CreateWallet(ctx context.Context, wallet *Wallet) (*Wallet, error) {
 	db := r.DbResolver.GetTenantDB(ctx)
-	if err := db.Create(wallet).Error; err != nil {
+	tx := db.Begin()
+
+	initial := wallet.Balance
+	wallet.Balance = decimal.Zero
+	if err := tx.Create(wallet).Error; err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	return wallet, nil
-}
 
-func (r *WalletRepository) UpdateWallet(ctx context.Context, wallet *Wallet) (*Wallet, error) {
-	db := r.DbResolver.GetTenantDB(ctx)
-	if err := db.Model(wallet).Updates(wallet).Error; err != nil {
+	if !initial.IsZero() {
+		if _, err := postWalletTransaction(tx, wallet, WalletDeposit, initial, nil, "initial balance", wallet.CreatedBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 	return wallet, nil
 }
 
+// GetWalletByID returns the wallet, it returns nil if the wallet does not exist.
 func (r *WalletRepository) GetWalletByID(ctx context.Context, walletID uint64) (*Wallet, error) {
 	db := r.DbResolver.GetTenantDB(ctx)
 	var wallet Wallet
-	if err := db.First(&wallet, walletID).Error; err != nil {
+	if err := db.Where("id=?", walletID).Find(&wallet).Error; err != nil {
 		return nil, err
 	}
+	if wallet.Id == 0 {
+		return nil, nil
+	}
 	return &wallet, nil
 }
 
-func (r *WalletRepository) Deposit(ctx context.Context, walletID uint64, amount decimal.Decimal) (*Wallet, error) {
+// FindTransactions returns ledger of the wallet which matches the filter, the latest first.
+func (r *WalletRepository) FindTransactions(ctx context.Context, walletID uint64, filter *dto.WalletTransactionFilter) (*commons.PaginatedResult, error) {
 	db := r.DbResolver.GetTenantDB(ctx)
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		var wallet Wallet
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&wallet, walletID).Error; err != nil {
-			return err
+	transactions := make([]*WalletTransaction, 0)
+
+	query := db.Model(&WalletTransaction{}).Where("wallet_id=?", walletID).Order("id desc")
+
+	if filter.Type != nil {
+		query = query.Where("type=?", *filter.Type)
+	}
+
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", filter.DateFrom)
+	}
+
+	if filter.DateTo != nil {
+		query = query.Where("created_at <= ?", filter.DateTo)
+	}
+
+	if err := query.Scan(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return paginateWithFilter(query, transactions, filter, filter.Page, filter.PageSize, filter.IgnorePagination), nil
+}
+
+// Deposit posts amount to the wallet. It returns nil if the wallet does not exist.
+func (r *WalletRepository) Deposit(ctx context.Context, walletID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+	return r.post(ctx, walletID, WalletDeposit, amount, idempotencyKey, "deposit", username)
+}
+
+// Withdraw takes amount out of the wallet, it returns InsufficientWalletBalanceErr if the balance is not enough.
+// It returns nil if the wallet does not exist.
+func (r *WalletRepository) Withdraw(ctx context.Context, walletID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+	return r.post(ctx, walletID, WalletWithdrawal, amount, idempotencyKey, "withdrawal", username)
+}
+
+// Transfer moves amount from a wallet to another one and returns the outgoing and incoming transactions, both are
+// posted with the idempotency key. It returns WalletIdempotencyKeyErr if the key is used by another transaction of
+// one of the wallets and nil if one of the wallets does not exist.
+func (r *WalletRepository) Transfer(ctx context.Context, fromID, toID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) ([]*WalletTransaction, error) {
+
+	if fromID == toID {
+		return nil, SameWalletTransferErr
+	}
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	// wallets are locked in order of their ids, so opposite transfers between two wallets do not deadlock.
+	first, second := fromID, toID
+	if first > second {
+		first, second = second, first
+	}
+
+	wallets := make(map[uint64]*Wallet)
+	for _, id := range []uint64{first, second} {
+		wallet, err := lockWallet(tx, id)
+		if err != nil || wallet == nil {
+			tx.Rollback()
+			return nil, err
+		}
+		wallets[id] = wallet
+	}
+
+	if idempotencyKey != nil {
+		out, err := findWalletTransaction(tx, fromID, *idempotencyKey)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
 		}
-		wallet.Balance = wallet.Balance.Add(amount)
-		if err := tx.Model(&wallet).Updates(wallet).Error; err != nil {
-			return err
+		if out != nil {
+			in, err := findWalletTransaction(tx, toID, *idempotencyKey)
+			tx.Rollback()
+			if err != nil {
+				return nil, err
+			}
+			if !out.Matches(WalletTransferOut, amount) || in == nil || *out.CounterpartWalletId != toID {
+				return nil, WalletIdempotencyKeyErr
+			}
+			return []*WalletTransaction{out, in}, nil
 		}
-		return nil
-	}); err != nil {
+
+		// keys are unique per wallet, the incoming leg can not be posted if the destination wallet already used the key.
+		in, err := findWalletTransaction(tx, toID, *idempotencyKey)
+		if err != nil || in != nil {
+			tx.Rollback()
+			if err != nil {
+				return nil, err
+			}
+			return nil, WalletIdempotencyKeyErr
+		}
+	}
+
+	out, err := postWalletTransaction(tx, wallets[fromID], WalletTransferOut, amount, idempotencyKey, "transfer", username,
+		func(transaction *WalletTransaction) { transaction.CounterpartWalletId = &toID })
+	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
-	return r.GetWalletByID(ctx, walletID)
+
+	in, err := postWalletTransaction(tx, wallets[toID], WalletTransferIn, out.Amount.Neg(), idempotencyKey, "transfer", username,
+		func(transaction *WalletTransaction) { transaction.CounterpartWalletId = &fromID })
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return []*WalletTransaction{out, in}, nil
 }
 
-func (r *WalletRepository) Withdraw(ctx context.Context, walletID uint64, amount decimal.Decimal) (*Wallet, error) {
+// PayFolio pays amount of balance of the folio from the wallet by a CREDIT payment, the whole balance is paid if amount
+// is zero and the folio is settled when it has no balance anymore. It returns InvalidWalletAmountErr if amount is
+// more than the folio balance and nil if the wallet or the folio does not exist.
+func (r *WalletRepository) PayFolio(ctx context.Context, walletID, folioID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+
 	db := r.DbResolver.GetTenantDB(ctx)
-	if err := db.Transaction(func(tx *gorm.DB) error {
-		var wallet Wallet
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&wallet, walletID).Error; err != nil {
-			return err
+	tx := db.Begin()
+
+	wallet, err := lockWallet(tx, walletID)
+	if err != nil || wallet == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if idempotencyKey != nil {
+		posted, err := findWalletTransaction(tx, walletID, *idempotencyKey)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if posted != nil {
+			tx.Rollback()
+			if !posted.Matches(WalletFolioPayment, amount) || *posted.FolioId != folioID {
+				return nil, WalletIdempotencyKeyErr
+			}
+			return posted, nil
+		}
+	}
+
+	folio, err := lockFolio(tx, folioID)
+	if err != nil || folio == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	balance := decimal.NewFromFloat(folio.Balance).Round(2)
+	if amount.IsZero() {
+		amount = balance
+	}
+
+	if amount.Round(2).GreaterThan(balance) {
+		tx.Rollback()
+		return nil, InvalidWalletAmountErr
+	}
+
+	payment := &Payment{
+		PaymentType:   CREDIT,
+		ReservationId: folio.ReservationId,
+	}
+	payment.SetAudit(username)
+
+	switch {
+	case wallet.GuestId != nil:
+		payment.PayerId = *wallet.GuestId
+	case folio.PayerId != nil:
+		payment.PayerId = *folio.PayerId
+	default:
+		reservation := Reservation{}
+		if err := tx.Select("id", "supervisor_id").Where("id=?", folio.ReservationId).Find(&reservation).Error; err != nil {
+			tx.Rollback()
+			return nil, err
 		}
-		if wallet.Balance.LessThan(amount) {
-			return errors.New("insufficient balance")
+		payment.PayerId = reservation.SupervisorId
+	}
+
+	transaction, err := postWalletTransaction(tx, wallet, WalletFolioPayment, amount, idempotencyKey, "folio payment", username,
+		func(transaction *WalletTransaction) { transaction.FolioId = &folio.Id })
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	now := time.Now()
+	payment.Amount, _ = transaction.Amount.Neg().Float64()
+	payment.PaymentDate = &now
+
+	if err := postPayment(tx, folio, payment, "wallet payment"); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Model(transaction).Update("payment_id", payment.Id).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	transaction.PaymentId = &payment.Id
+
+	if folio.Settle(now) == nil {
+		if err := tx.Model(&Folio{}).Where("id=?", folio.Id).Updates(map[string]interface{}{
+			"status":     folio.Status,
+			"settled_at": folio.SettledAt,
+		}).Error; err != nil {
+			tx.Rollback()
+			return nil, err
 		}
-		wallet.Balance = wallet.Balance.Sub(amount)
-		if err := tx.Model(&wallet).Updates(wallet).Error; err != nil {
-			return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// post posts a transaction of amount to the wallet in a database transaction, retries with the same idempotency key
+// return the posted transaction.
+func (r *WalletRepository) post(ctx context.Context, walletID uint64, transactionType WalletTransactionType, amount decimal.Decimal,
+	idempotencyKey *string, description, username string) (*WalletTransaction, error) {
+
+	db := r.DbResolver.GetTenantDB(ctx)
+	tx := db.Begin()
+
+	wallet, err := lockWallet(tx, walletID)
+	if err != nil || wallet == nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if idempotencyKey != nil {
+		posted, err := findWalletTransaction(tx, walletID, *idempotencyKey)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if posted != nil {
+			tx.Rollback()
+			if !posted.Matches(transactionType, amount) {
+				return nil, WalletIdempotencyKeyErr
+			}
+			return posted, nil
 		}
-		return nil
-	}); err != nil {
+	}
+
+	transaction, err := postWalletTransaction(tx, wallet, transactionType, amount, idempotencyKey, description, username)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
-	return r.GetWalletByID(ctx, walletID)
+	return transaction, nil
+}
+
+// lockWallet returns wallet with given id and locks its row until the transaction ends, so transactions
+// of a wallet are serialized across all instances of the application. It returns nil if there is no wallet.
+func lockWallet(tx *gorm.DB, id uint64) (*Wallet, error) {
+
+	wallet := Wallet{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).Find(&wallet).Error; err != nil {
+		return nil, err
+	}
+
+	if wallet.Id == 0 {
+		return nil, nil
+	}
+
+	return &wallet, nil
+}
+
+// findWalletTransaction returns transaction of the wallet with given idempotency key, it returns nil if there is none.
+func findWalletTransaction(tx *gorm.DB, walletID uint64, idempotencyKey string) (*WalletTransaction, error) {
+
+	transaction := WalletTransaction{}
+	if err := tx.Where("wallet_id=? AND idempotency_key=?", walletID, idempotencyKey).Find(&transaction).Error; err != nil {
+		return nil, err
+	}
+
+	if transaction.Id == 0 {
+		return nil, nil
+	}
+
+	return &transaction, nil
+}
+
+// postWalletTransaction posts amount to the locked wallet, stores the transaction and the new balance of the wallet in
+// given transaction. fill sets references of the transaction before it is stored.
+func postWalletTransaction(tx *gorm.DB, wallet *Wallet, transactionType WalletTransactionType, amount decimal.Decimal,
+	idempotencyKey *string, description, username string, fill ...func(transaction *WalletTransaction)) (*WalletTransaction, error) {
+
+	transaction, err := wallet.Post(transactionType, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.IdempotencyKey = idempotencyKey
+	transaction.Description = description
+	transaction.CreatedBy = username
+	transaction.UpdatedBy = username
+	for _, f := range fill {
+		f(transaction)
+	}
+
+	if err := tx.Create(transaction).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&Wallet{}).Where("id=?", wallet.Id).Updates(map[string]interface{}{
+		"balance":    wallet.Balance,
+		"updated_by": username,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
 }
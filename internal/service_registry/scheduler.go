@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/jasonlvhit/gocron"
 	"reservation-api/internal/global_variables"
+	"reservation-api/internal/models"
 	"reservation-api/internal/services/domain_services"
 	"reservation-api/pkg/applogger"
 	"time"
@@ -41,3 +42,108 @@ func scheduleRemoveExpiredReservationRequests(s *domain_services.ReservationServ
 	}
 
 }
+
+// schedule no-show processing job every hour, each hotel has its own cutoff hour.
+func scheduleProcessNoShows(s *domain_services.ReservationService, logger applogger.Logger,
+	tenantService *domain_services.TenantService) {
+
+	tenants, err := tenantService.GetAll()
+
+	if err != nil {
+		logger.LogError(err)
+
+	} else {
+
+		task := func(ctx context.Context) error {
+			return s.ProcessNoShows(ctx)
+		}
+
+		if err := scheduleForTenants(gocron.Every(1).Hour(), tenants, logger, task); err != nil {
+			logger.LogError(err.Error())
+		}
+	}
+}
+
+// schedule release of not picked up group rooms every hour.
+func scheduleReleaseGroupRooms(s *domain_services.GroupReservationService, logger applogger.Logger,
+	tenantService *domain_services.TenantService) {
+
+	tenants, err := tenantService.GetAll()
+
+	if err != nil {
+		logger.LogError(err)
+
+	} else {
+
+		task := func(ctx context.Context) error {
+			_, err := s.ReleaseUnpickedRooms(ctx)
+			return err
+		}
+
+		if err := scheduleForTenants(gocron.Every(1).Hour(), tenants, logger, task); err != nil {
+			logger.LogError(err.Error())
+		}
+	}
+}
+
+// schedule anonymization of guests who are older than retention period of their tenant every night.
+func scheduleAnonymizeExpiredGuests(s *domain_services.GuestService, logger applogger.Logger,
+	tenantService *domain_services.TenantService) {
+
+	tenants, err := tenantService.GetAll()
+
+	if err != nil {
+		logger.LogError(err)
+
+	} else {
+
+		task := func(ctx context.Context) error {
+			_, err := s.AnonymizeExpiredGuests(ctx, time.Now())
+			return err
+		}
+
+		if err := scheduleForTenants(gocron.Every(1).Day().At("03:00"), tenants, logger, task); err != nil {
+			logger.LogError(err.Error())
+		}
+	}
+}
+
+// schedule re-encryption of guest documents which are not encrypted with the active data key of their tenant every hour.
+func scheduleReencryptGuests(s *domain_services.GuestService, logger applogger.Logger,
+	tenantService *domain_services.TenantService) {
+
+	tenants, err := tenantService.GetAll()
+
+	if err != nil {
+		logger.LogError(err)
+
+	} else {
+
+		task := func(ctx context.Context) error {
+			_, err := s.ReencryptGuests(ctx)
+			return err
+		}
+
+		if err := scheduleForTenants(gocron.Every(1).Hour(), tenants, logger, task); err != nil {
+			logger.LogError(err.Error())
+		}
+	}
+}
+
+// scheduleForTenants registers the task on the job, the task runs in context of each tenant every time the job is due.
+// Jobs run after the scheduler is started.
+func scheduleForTenants(job *gocron.Job, tenants []models.Tenant, logger applogger.Logger,
+	task func(ctx context.Context) error) error {
+
+	return job.Do(func() {
+		for _, tenant := range tenants {
+
+			parentCtx := context.Background()
+			ctx := context.WithValue(parentCtx, global_variables.TenantIDKey, tenant.Id)
+
+			if err := task(ctx); err != nil {
+				logger.LogError(err.Error())
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package service_registry
+
+import (
+	"context"
+	"github.com/jasonlvhit/gocron"
+	"github.com/stretchr/testify/assert"
+	"reservation-api/internal/global_variables"
+	"reservation-api/internal/models"
+	"testing"
+	"time"
+)
+
+func TestScheduleForTenants(t *testing.T) {
+
+	tenants := []models.Tenant{{BaseModel: models.BaseModel{Id: 1}}, {BaseModel: models.BaseModel{Id: 2}}}
+
+	t.Run("registers_due_jobs", func(t *testing.T) {
+
+		scheduler := gocron.NewScheduler()
+		task := func(ctx context.Context) error { return nil }
+
+		assert.Nil(t, scheduleForTenants(scheduler.Every(1).Hour(), tenants, nil, task))
+		assert.Nil(t, scheduleForTenants(scheduler.Every(1).Day().At("03:00"), tenants, nil, task))
+
+		jobs := scheduler.Jobs()
+		assert.Equal(t, 2, scheduler.Len())
+		now := time.Now()
+		assert.WithinDuration(t, now.Add(time.Hour), jobs[0].NextScheduledTime(), time.Minute)
+		assert.True(t, jobs[1].NextScheduledTime().After(now))
+		assert.True(t, jobs[1].NextScheduledTime().Before(now.Add(24*time.Hour)))
+		assert.Equal(t, 3, jobs[1].NextScheduledTime().Hour())
+	})
+
+	t.Run("runs_for_each_tenant_after_start", func(t *testing.T) {
+
+		scheduler := gocron.NewScheduler()
+		ran := make(chan uint64, len(tenants))
+
+		task := func(ctx context.Context) error {
+			ran <- ctx.Value(global_variables.TenantIDKey).(uint64)
+			return nil
+		}
+		assert.Nil(t, scheduleForTenants(scheduler.Every(1).Second(), tenants, nil, task))
+
+		stopped := scheduler.Start()
+		defer func() { stopped <- true }()
+
+		for _, tenant := range tenants {
+			select {
+			case id := <-ran:
+				assert.Equal(t, tenant.Id, id)
+			case <-time.After(5 * time.Second):
+				t.Fatal("job did not run")
+			}
+		}
+	})
+}
@@ -5,6 +5,7 @@ package service_registry
 
 import (
 	"context"
+	"github.com/jasonlvhit/gocron"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -17,8 +18,11 @@ import (
 	"reservation-api/internal/services/common_services"
 	"reservation-api/internal/services/domain_services"
 	"reservation-api/pkg/applogger"
+	"reservation-api/pkg/field_encryption"
 	"reservation-api/pkg/message_broker"
 	"reservation-api/pkg/multi_tenancy_database/tenant_database_resolver"
+	"reservation-api/pkg/payment_gateway"
+	"reservation-api/pkg/secret_manager"
 )
 
 // RegisterServicesAndRoutes register dependencies for services and handlers
@@ -32,6 +36,15 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 
 	addCorsMiddleware(router, appConfig)
 
+	// identity documents of guests are encrypted with data keys of tenants which are stored in vault.
+	if appConfig.FieldEncryption.Enabled {
+		keyring, err := field_encryption.NewKeyring(context.Background(), secret_manager.New())
+		if err != nil {
+			return err
+		}
+		field_encryption.SetDefault(keyring)
+	}
+
 	var (
 		// ================================================================================================================
 		logger = applogger.New(nil)
@@ -55,6 +68,19 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 		paymentHandler     = handlers.PaymentHandler{}
 		tenantHandler      = handlers.TenantHandler{}
 		metricHandler      = handlers.MetricHandler{}
+
+		cancellationPolicyHandler = handlers.CancellationPolicyHandler{}
+		groupReservationHandler   = handlers.GroupReservationHandler{}
+		taxRuleHandler            = handlers.TaxRuleHandler{}
+		exchangeRateHandler       = handlers.ExchangeRateHandler{}
+		promotionHandler          = handlers.PromotionHandler{}
+		pricingRuleHandler        = handlers.PricingRuleHandler{}
+		childAgeBandHandler       = handlers.ChildAgeBandHandler{}
+		addOnHandler              = handlers.AddOnHandler{}
+		folioHandler              = handlers.FolioHandler{}
+		invoiceHandler            = handlers.InvoiceHandler{}
+		paymentWebhookHandler     = handlers.PaymentWebhookHandler{}
+		walletHandler             = handlers.WalletHandler{}
 		// ================================================================================================================
 
 		// ================================== common services =============================================================
@@ -66,9 +92,10 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 		rabbitMqManager = message_broker.New(appConfig.MessageBroker.Url, logger)
 		fileService     = common_services.NewFileTransferService(appConfig.Minio.Endpoint, appConfig.Minio.AccessKeyID,
 			appConfig.Minio.SecretAccessKey, appConfig.Minio.UseSSL, ctx)
+		paymentGateway = payment_gateway.NewStripe(appConfig.PaymentGateway.BaseURL, appConfig.PaymentGateway.SecretKey,
+			appConfig.PaymentGateway.WebhookSecret)
 
 		cacheService       = common_services.NewCacheService(appConfig.Redis.Addr, appConfig.Redis.Password, appConfig.Redis.CacheDB, ctx)
-		eventService       = common_services.NewEventService(rabbitMqManager, emailService)
 		connectionResolver = tenant_database_resolver.NewTenantDatabaseResolver()
 
 		// =============================== domain services ===============================================================
@@ -82,15 +109,30 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 		hotelService          = domain_services.NewHotelService(repositories.NewHotelRepository(connectionResolver), fileService)
 		roomTypeService       = domain_services.NewRoomTypeService(repositories.NewRoomTypeRepository(connectionResolver))
 		roomService           = domain_services.NewRoomService(repositories.NewRoomRepository(connectionResolver))
-		guestService          = domain_services.NewGuestService(repositories.NewGuestRepository(connectionResolver))
+		guestService          = domain_services.NewGuestService(repositories.NewGuestRepository(connectionResolver), fileService)
 		rateGroupService      = domain_services.NewRateGroupService(repositories.NewRateGroupRepository(connectionResolver))
 		rateCodeService       = domain_services.NewRateCodeService(repositories.NewRateCodeRepository(connectionResolver))
 		rateCodeDetailService = domain_services.NewRateCodeDetailService(repositories.NewRateCodeDetailRepository(connectionResolver))
-		reservationRepository = repositories.NewReservationRepository(connectionResolver, rateCodeDetailService.Repository)
-		reservationService    = domain_services.NewReservationService(reservationRepository, rabbitMqManager)
-		paymentService        = domain_services.NewPaymentService(repositories.NewPaymentRepository(connectionResolver))
+		taxRuleService        = domain_services.NewTaxRuleService(repositories.NewTaxRuleRepository(connectionResolver))
+		exchangeRateService   = domain_services.NewExchangeRateService(repositories.NewExchangeRateRepository(connectionResolver))
+		pricingRuleService    = domain_services.NewPricingRuleService(repositories.NewPricingRuleRepository(connectionResolver))
+		reservationRepository = repositories.NewReservationRepository(connectionResolver, rateCodeDetailService.Repository, taxRuleService.Repository, exchangeRateService.Repository, pricingRuleService.Repository)
+		folioService          = domain_services.NewFolioService(repositories.NewFolioRepository(connectionResolver))
+		paymentService        = domain_services.NewPaymentService(repositories.NewPaymentRepository(connectionResolver), folioService, paymentGateway, appConfig.PaymentGateway.Currency)
+		reservationService    = domain_services.NewReservationService(reservationRepository, rabbitMqManager, folioService)
 		authService           = domain_services.NewAuthService(userService, appConfig)
 		tenantService         = domain_services.NewTenantService(repositories.NewTenantDatabaseRepository(connectionResolver))
+
+		promotionService          = domain_services.NewPromotionService(repositories.NewPromotionRepository(connectionResolver))
+		childAgeBandService       = domain_services.NewChildAgeBandService(repositories.NewChildAgeBandRepository(connectionResolver))
+		addOnService              = domain_services.NewAddOnService(repositories.NewAddOnRepository(connectionResolver))
+		invoiceService            = domain_services.NewInvoiceService(repositories.NewInvoiceRepository(connectionResolver), reportService, fileService)
+		walletService             = domain_services.NewWalletService(repositories.NewWalletRepository(connectionResolver))
+		cancellationPolicyService = domain_services.NewCancellationPolicyService(repositories.NewCancellationPolicyRepository(connectionResolver))
+		groupReservationService   = domain_services.NewGroupReservationService(
+			repositories.NewGroupReservationRepository(connectionResolver, reservationRepository), folioService)
+		// sent emails are recorded as data of guests.
+		eventService = common_services.NewEventService(rabbitMqManager, emailService, guestService)
 	)
 	// ======================================================================================================================
 
@@ -107,6 +149,9 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 	// register tenant handler
 	tenantHandler.Register(handlerConf, tenantService)
 
+	// payment gateway webhooks have no tenant header and authentication, they are verified by signature.
+	paymentWebhookHandler.Register(handlerConf, paymentService)
+
 	// authHandler does bot need to authMiddleware.
 	router.Use(middlewares.PanicRecoveryMiddleware(logger), middlewares.LoggerMiddleware(logger), middlewares.TenantMiddleware)
 
@@ -116,6 +161,9 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 	// other handlers needs to this middlewares
 	router.Use(middlewares.MetricsMiddleware, middlewares.JWTAuthMiddleware(authService), middlewares.TenantAccessMiddleware)
 
+	// retries of mutating requests with the same Idempotency-Key get the response of the first request.
+	router.Use(middlewares.IdempotencyMiddleware(cacheService, logger))
+
 	// register all handlers
 	metricHandler.Register(appConfig)
 	countryHandler.Register(handlerConf, countryService)
@@ -133,8 +181,30 @@ func RegisterServicesAndRoutes(router *echo.Group) error {
 	rateCodeHandler.Register(handlerConf, rateCodeService, rateCodeDetailService)
 	reservationHandler.Register(handlerConf, reservationService, reportService)
 	paymentHandler.Register(handlerConf, paymentService)
+	cancellationPolicyHandler.Register(handlerConf, cancellationPolicyService)
+	groupReservationHandler.Register(handlerConf, groupReservationService)
+	taxRuleHandler.Register(handlerConf, taxRuleService)
+	exchangeRateHandler.Register(handlerConf, exchangeRateService)
+	promotionHandler.Register(handlerConf, promotionService)
+	pricingRuleHandler.Register(handlerConf, pricingRuleService)
+	childAgeBandHandler.Register(handlerConf, childAgeBandService)
+	addOnHandler.Register(handlerConf, addOnService)
+	folioHandler.Register(handlerConf, folioService)
+	invoiceHandler.Register(handlerConf, invoiceService)
+	walletHandler.Register(handlerConf, walletService)
 	// schedule to remove expired reservation requests.
 	scheduleRemoveExpiredReservationRequests(reservationService, logger, tenantService)
+	// schedule to mark not checked-in reservations as no-show after hotel's cutoff.
+	scheduleProcessNoShows(reservationService, logger, tenantService)
+	// schedule to release group rooms which are not picked up until release date.
+	scheduleReleaseGroupRooms(groupReservationService, logger, tenantService)
+	// schedule to anonymize guests who have not stayed during retention period of their tenant.
+	scheduleAnonymizeExpiredGuests(guestService, logger, tenantService)
+	// schedule to re-encrypt guest documents after data keys are rotated.
+	scheduleReencryptGuests(guestService, logger, tenantService)
+
+	// start the scheduler after all jobs are registered, it runs due jobs in its own goroutine.
+	gocron.Start()
 
 	// listen to message broker on reservation event and send email in background.
 	go eventService.SendEmailToGuestOnReservation()
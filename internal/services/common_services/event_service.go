@@ -1,25 +1,35 @@
 package common_services
 
 import (
+	"context"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
 	"reservation-api/internal/utils/mapper_utils"
 	"reservation-api/pkg/applogger"
 	"reservation-api/pkg/message_broker"
+	"time"
 )
 
+// EmailRecorder records emails which are sent to guests.
+type EmailRecorder interface {
+	RecordEmail(ctx context.Context, email *models.GuestEmail) error
+}
+
 type EventService struct {
 	MessageBrokerManager message_broker.MessageBrokerManager
 	EmailSender          EmailSender
+	EmailRecorder        EmailRecorder
 	Logger               applogger.AppLogger
 }
 
-func NewEventService(broker message_broker.MessageBrokerManager, emailSender EmailSender) *EventService {
+func NewEventService(broker message_broker.MessageBrokerManager, emailSender EmailSender,
+	emailRecorder EmailRecorder) *EventService {
 
 	return &EventService{
 		MessageBrokerManager: broker,
 		EmailSender:          emailSender,
+		EmailRecorder:        emailRecorder,
 	}
 }
 
@@ -30,11 +40,26 @@ func (e *EventService) SendEmailToGuestOnReservation() {
 		reservation := mapper_utils.ConvertByGeneric(models.Reservation{}, payload)
 
 		if reservation.Supervisor != nil {
-			e.EmailSender.Send(&dto.SendEmailRequest{
+			request := &dto.SendEmailRequest{
 				From:    "reservationapi@test.test",
 				To:      reservation.Supervisor.Email,
 				Subject: "reservation",
 				Body:    "your reservation completed successfully!",
+			}
+			if err := e.EmailSender.Send(request); err != nil {
+				return
+			}
+
+			// sent emails are a part of guest data, reservations are published with id of their tenant.
+			now := time.Now()
+			ctx := context.WithValue(context.Background(), global_variables.TenantIDKey, reservation.TenantId)
+			e.EmailRecorder.RecordEmail(ctx, &models.GuestEmail{
+				GuestId:       reservation.SupervisorId,
+				ReservationId: reservation.Id,
+				Recipient:     request.To,
+				Subject:       request.Subject,
+				Body:          request.Body,
+				SentAt:        &now,
 			})
 		}
 	})
@@ -18,11 +18,12 @@ import (
 )
 
 // FileTransformer interface is related to file management,
-// which includes three upload and delete upload methods
+// which includes upload, get, download and remove methods
 type FileTransformer interface {
 	Upload(bucketName, serverName string, file *os.File, wg *sync.WaitGroup) (*dto.FileTransferResponse, error)
 	Remove(fileName, bucketName, versionID string) error
 	Download(fileName, bucketName string) error
+	Get(bucketName, fileName string) ([]byte, error)
 }
 
 // FileTransferService implements FileTransformer interface
@@ -123,6 +124,18 @@ func (s *FileTransferService) Download(bucketName, fileName string) error {
 	return s.stream(obj)
 }
 
+// Get returns content of the object by given bucketName and fileName.
+func (s *FileTransferService) Get(bucketName, fileName string) ([]byte, error) {
+
+	obj, err := s.Client.GetObject(s.Ctx, bucketName, fileName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	return io.ReadAll(obj)
+}
+
 // stream streams given minio object.
 func (s *FileTransferService) stream(r io.Reader) error {
 	br := bufio.NewReader(r)
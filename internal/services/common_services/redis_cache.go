@@ -8,6 +8,7 @@ import (
 
 type CacheManager interface {
 	Set(key string, value interface{}, expiration *time.Duration) error
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
 	Get(key string) (string, error)
 	Del(key string) (int64, error)
 	Update(key string, value interface{}) error
@@ -41,6 +42,11 @@ func (m *CacheService) Set(key string, value interface{}, expiration *time.Durat
 	return m.Client.Set(m.Ctx, key, value, *expiration).Err()
 }
 
+// SetNX stores given value with key only if the key does not exist, it reports whether the value is stored.
+func (m *CacheService) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return m.Client.SetNX(m.Ctx, key, value, expiration).Result()
+}
+
 // Get returns cache value by given key.
 func (m *CacheService) Get(key string) (string, error) {
 	return m.Client.Get(m.Ctx, key).Result()
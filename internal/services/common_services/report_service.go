@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/xuri/excelize/v2"
+	"math"
 	"reflect"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
 	"reservation-api/internal/utils/mapper_utils"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ReportService struct {
@@ -157,6 +161,148 @@ func (*ReportService) ExportToPDF(data interface{}, headers []string) ([]byte, e
 	return buf.Bytes(), nil
 }
 
+// InvoiceToPDF renders an invoice with the hotel header and logo, the guest and itemized charges,
+// taxes and payments of the reservation.
+func (*ReportService) InvoiceToPDF(invoice *dto.InvoiceDto) ([]byte, error) {
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 10, tr(fmt.Sprintf("%s - Page %d", invoice.Invoice.InvoiceNo, pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+
+	// hotel header, logo on the left and hotel address on the right.
+	if len(invoice.Logo) > 0 {
+		options := gofpdf.ImageOptions{ImageType: invoice.LogoType, ReadDpi: true}
+		pdf.RegisterImageOptionsReader("logo", options, bytes.NewReader(invoice.Logo))
+		if pdf.Ok() {
+			pdf.ImageOptions("logo", 10, 10, 30, 0, false, options, 0, "")
+		} else {
+			// invoice is printed without logo if it is not a supported image.
+			pdf.ClearError()
+		}
+	}
+
+	hotel := invoice.Hotel
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 7, tr(hotel.Name), "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	for _, line := range []string{hotel.Address, invoiceCityLine(hotel), hotel.PhoneNumber1, hotel.EmailAddress, hotel.Website} {
+		if strings.TrimSpace(line) != "" {
+			pdf.CellFormat(0, 5, tr(line), "", 1, "R", false, 0, "")
+		}
+	}
+
+	pdf.SetY(math.Max(pdf.GetY(), 45))
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "INVOICE", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Arial", "", 10)
+	reservation := invoice.Reservation
+	details := [][2]string{
+		{"Invoice No", invoice.Invoice.InvoiceNo},
+		{"Issue Date", invoiceDate(invoice.Invoice.IssuedAt)},
+		{"Reservation", fmt.Sprintf("#%d", reservation.Id)},
+		{"Stay", fmt.Sprintf("%s - %s", invoiceDate(reservation.CheckinDate), invoiceDate(reservation.CheckoutDate))},
+	}
+	for _, detail := range details {
+		pdf.CellFormat(30, 6, detail[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+
+	// guest who the invoice is issued to.
+	if guest := invoice.Guest; guest != nil {
+		pdf.Ln(4)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(0, 6, "Bill To", "", 1, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 10)
+		name := strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", guest.FirstName, guest.MiddleName, guest.LastName)), " ")
+		for _, line := range []string{name, guest.Address, string(guest.NationalId), guest.Email, guest.CellNumber} {
+			if strings.TrimSpace(line) != "" {
+				pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+			}
+		}
+	}
+
+	sections := []struct {
+		title string
+		lines []*dto.InvoiceLineDto
+		total float64
+	}{
+		{"Charges", invoice.Charges, invoice.Invoice.TotalCharges},
+		{"Taxes", invoice.Taxes, invoice.Invoice.TotalTaxes},
+		{"Payments", invoice.Payments, -invoice.Invoice.TotalPayments},
+	}
+
+	for _, section := range sections {
+
+		if len(section.lines) == 0 {
+			continue
+		}
+
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(30, 7, "Date", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(120, 7, section.title, "B", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 7, "Amount", "B", 1, "R", false, 0, "")
+
+		pdf.SetFont("Arial", "", 10)
+		for _, line := range section.lines {
+			pdf.CellFormat(30, 6, invoiceDate(line.Date), "", 0, "L", false, 0, "")
+			pdf.CellFormat(120, 6, tr(line.Description), "", 0, "L", false, 0, "")
+			pdf.CellFormat(40, 6, fmt.Sprintf("%.2f", line.Amount), "", 1, "R", false, 0, "")
+		}
+
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(150, 7, "Total "+strings.ToLower(section.title), "T", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 7, fmt.Sprintf("%.2f", section.total), "T", 1, "R", false, 0, "")
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(150, 8, "Balance Due", "", 0, "R", false, 0, "")
+	pdf.CellFormat(40, 8, fmt.Sprintf("%.2f", invoice.Invoice.Balance), "", 1, "R", false, 0, "")
+
+	buf := new(bytes.Buffer)
+	if err := pdf.Output(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// invoiceCityLine returns city, province and postal code line of the hotel address.
+func invoiceCityLine(hotel *models.Hotel) string {
+
+	parts := make([]string, 0, 3)
+	if hotel.City != nil && hotel.City.Name != "" {
+		parts = append(parts, hotel.City.Name)
+	}
+	if hotel.Province != nil && hotel.Province.Name != "" {
+		parts = append(parts, hotel.Province.Name)
+	}
+	if hotel.PostalCode != "" {
+		parts = append(parts, hotel.PostalCode)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// invoiceDate returns printed date of an invoice.
+func invoiceDate(date *time.Time) string {
+
+	if date == nil {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
+
 // getColName returns excel column name per given column number
 // For example, if input is 1, output will be A
 // or if input is 12, output will be AB
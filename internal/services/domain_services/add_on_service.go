@@ -0,0 +1,48 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type AddOnService struct {
+	Repository *repositories.AddOnRepository
+}
+
+// NewAddOnService returns new AddOnService
+func NewAddOnService(r *repositories.AddOnRepository) *AddOnService {
+	return &AddOnService{Repository: r}
+}
+
+// Create creates new AddOn.
+func (s *AddOnService) Create(ctx context.Context, model *models.AddOn) (*models.AddOn, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates AddOn.
+func (s *AddOnService) Update(ctx context.Context, model *models.AddOn) (*models.AddOn, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns AddOn and if it does not find the AddOn, it returns nil.
+func (s *AddOnService) Find(ctx context.Context, id uint64) (*models.AddOn, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of AddOns.
+func (s *AddOnService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes AddOn by given id.
+func (s *AddOnService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
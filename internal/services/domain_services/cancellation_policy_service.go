@@ -0,0 +1,48 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type CancellationPolicyService struct {
+	Repository *repositories.CancellationPolicyRepository
+}
+
+// NewCancellationPolicyService returns new CancellationPolicyService
+func NewCancellationPolicyService(r *repositories.CancellationPolicyRepository) *CancellationPolicyService {
+	return &CancellationPolicyService{Repository: r}
+}
+
+// Create creates new CancellationPolicy.
+func (s *CancellationPolicyService) Create(ctx context.Context, model *models.CancellationPolicy) (*models.CancellationPolicy, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates CancellationPolicy.
+func (s *CancellationPolicyService) Update(ctx context.Context, model *models.CancellationPolicy) (*models.CancellationPolicy, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns CancellationPolicy and if it does not find the CancellationPolicy, it returns nil.
+func (s *CancellationPolicyService) Find(ctx context.Context, id uint64) (*models.CancellationPolicy, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of CancellationPolicies.
+func (s *CancellationPolicyService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes CancellationPolicy by given id.
+func (s *CancellationPolicyService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
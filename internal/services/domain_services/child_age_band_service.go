@@ -0,0 +1,48 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type ChildAgeBandService struct {
+	Repository *repositories.ChildAgeBandRepository
+}
+
+// NewChildAgeBandService returns new ChildAgeBandService
+func NewChildAgeBandService(r *repositories.ChildAgeBandRepository) *ChildAgeBandService {
+	return &ChildAgeBandService{Repository: r}
+}
+
+// Create creates new ChildAgeBand.
+func (s *ChildAgeBandService) Create(ctx context.Context, model *models.ChildAgeBand) (*models.ChildAgeBand, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates ChildAgeBand.
+func (s *ChildAgeBandService) Update(ctx context.Context, model *models.ChildAgeBand) (*models.ChildAgeBand, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns ChildAgeBand and if it does not find the ChildAgeBand, it returns nil.
+func (s *ChildAgeBandService) Find(ctx context.Context, id uint64) (*models.ChildAgeBand, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of ChildAgeBands.
+func (s *ChildAgeBandService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes ChildAgeBand by given id.
+func (s *ChildAgeBandService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
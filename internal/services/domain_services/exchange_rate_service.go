@@ -0,0 +1,97 @@
+package domain_services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+	"reservation-api/internal_errors/message_keys"
+)
+
+var (
+	UnknownCurrencyCodeErr = errors.New(message_keys.UnknownCurrencyCode)
+)
+
+type ExchangeRateService struct {
+	Repository *repositories.ExchangeRateRepository
+}
+
+// NewExchangeRateService returns new ExchangeRateService
+func NewExchangeRateService(r *repositories.ExchangeRateRepository) *ExchangeRateService {
+	return &ExchangeRateService{Repository: r}
+}
+
+// Create creates new ExchangeRate.
+func (s *ExchangeRateService) Create(ctx context.Context, model *models.ExchangeRate) (*models.ExchangeRate, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates ExchangeRate.
+func (s *ExchangeRateService) Update(ctx context.Context, model *models.ExchangeRate) (*models.ExchangeRate, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns ExchangeRate and if it does not find the ExchangeRate, it returns nil.
+func (s *ExchangeRateService) Find(ctx context.Context, id uint64) (*models.ExchangeRate, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of ExchangeRates.
+func (s *ExchangeRateService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes ExchangeRate by given id.
+func (s *ExchangeRateService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
+
+// Import loads exchange rates from a csv file with from,to,rate,effective_date columns, currencies are
+// ISO codes and dates are in YYYY-MM-DD format. Nothing is imported if one of the rows is invalid.
+func (s *ExchangeRateService) Import(ctx context.Context, reader io.Reader, username string) (*dto.ExchangeRateImportResultDto, error) {
+
+	rows, err := models.ParseExchangeRatesCsv(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies, err := s.Repository.FindCurrencyIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*models.ExchangeRate, 0, len(rows))
+
+	for _, row := range rows {
+
+		fromId, fromOk := currencies[row.FromCurrencyCode]
+		toId, toOk := currencies[row.ToCurrencyCode]
+		if !fromOk || !toOk {
+			return nil, UnknownCurrencyCodeErr
+		}
+
+		effectiveDate := row.EffectiveDate
+		rate := &models.ExchangeRate{
+			FromCurrencyId: fromId,
+			ToCurrencyId:   toId,
+			Rate:           row.Rate,
+			EffectiveDate:  &effectiveDate,
+		}
+		rate.SetAudit(username)
+		rates = append(rates, rate)
+	}
+
+	if err := s.Repository.Import(ctx, rates); err != nil {
+		return nil, err
+	}
+
+	return &dto.ExchangeRateImportResultDto{Imported: len(rates)}, nil
+}
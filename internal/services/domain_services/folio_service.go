@@ -0,0 +1,70 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type FolioService struct {
+	Repository *repositories.FolioRepository
+}
+
+// NewFolioService returns new FolioService
+func NewFolioService(r *repositories.FolioRepository) *FolioService {
+	return &FolioService{Repository: r}
+}
+
+// Create opens a new folio for a reservation.
+func (s *FolioService) Create(ctx context.Context, model *models.Folio) (*models.Folio, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Find returns Folio with its entries and if it does not find the Folio, it returns nil.
+func (s *FolioService) Find(ctx context.Context, id uint64) (*models.Folio, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindByReservation returns folios of the reservation.
+func (s *FolioService) FindByReservation(ctx context.Context, reservationId uint64) ([]*models.Folio, error) {
+
+	return s.Repository.FindByReservation(ctx, reservationId)
+}
+
+// Post posts a payment, refund or adjustment to the folio.
+func (s *FolioService) Post(ctx context.Context, folioId uint64, entry *models.FolioEntry, username string) (*models.FolioEntry, error) {
+
+	return s.Repository.Post(ctx, folioId, entry, username)
+}
+
+// Reverse cancels a folio entry by its reversal entry.
+func (s *FolioService) Reverse(ctx context.Context, entryId uint64, username string) (*models.FolioEntry, error) {
+
+	return s.Repository.Reverse(ctx, entryId, username)
+}
+
+// Transfer moves a folio entry to another folio of the reservation.
+func (s *FolioService) Transfer(ctx context.Context, entryId uint64, folioId uint64, username string) ([]*models.FolioEntry, error) {
+
+	return s.Repository.Transfer(ctx, entryId, folioId, username)
+}
+
+// Settle closes a folio which has no balance.
+func (s *FolioService) Settle(ctx context.Context, id uint64, username string) (*models.Folio, error) {
+
+	return s.Repository.Settle(ctx, id, username)
+}
+
+// GetBalance returns the amount which guest still has to pay for the reservation.
+func (s *FolioService) GetBalance(ctx context.Context, reservationId uint64) (float64, error) {
+
+	return s.Repository.GetBalance(ctx, reservationId)
+}
+
+// GetEntryTypeBalances returns debits minus credits of folio entries of the reservation by entry type.
+func (s *FolioService) GetEntryTypeBalances(ctx context.Context, reservationId uint64) (map[models.FolioEntryType]float64, error) {
+
+	return s.Repository.GetEntryTypeBalances(ctx, reservationId)
+}
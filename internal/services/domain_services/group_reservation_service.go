@@ -0,0 +1,150 @@
+package domain_services
+
+import (
+	"context"
+	"errors"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/global_variables"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+var (
+	InvalidGroupReservationErr = errors.New(message_keys.InvalidGroupReservation)
+)
+
+type GroupReservationService struct {
+	Repository   *repositories.GroupReservationRepository
+	FolioService *FolioService
+}
+
+// NewGroupReservationService returns new GroupReservationService
+func NewGroupReservationService(repository *repositories.GroupReservationRepository, folioService *FolioService) *GroupReservationService {
+	return &GroupReservationService{
+		Repository:   repository,
+		FolioService: folioService,
+	}
+}
+
+// Create creates group master reservation and a tentative child reservation for every requested room.
+func (s *GroupReservationService) Create(ctx context.Context, input *dto.GroupReservationCreateDto, username string) (*models.Reservation, error) {
+
+	if !input.CheckoutDate.After(*input.CheckinDate) || input.ReleaseDate.After(*input.CheckinDate) || len(input.RoomIds) == 0 ||
+		input.GuestsPerRoom == 0 {
+		return nil, InvalidGroupReservationErr
+	}
+
+	master := &models.Reservation{
+		HotelId:      input.HotelId,
+		SupervisorId: input.SupervisorId,
+		CheckinDate:  input.CheckinDate,
+		CheckoutDate: input.CheckoutDate,
+		RateCodeId:   input.RateCodeId,
+		GroupName:    input.GroupName,
+		ReleaseDate:  input.ReleaseDate,
+	}
+	master.SetAudit(username)
+
+	children := make([]*models.Reservation, 0, len(input.RoomIds))
+	rooms := make(map[uint64]bool)
+
+	for _, roomId := range input.RoomIds {
+
+		if rooms[roomId] {
+			return nil, InvalidGroupReservationErr
+		}
+		rooms[roomId] = true
+
+		child := &models.Reservation{
+			HotelId:      input.HotelId,
+			SupervisorId: input.SupervisorId,
+			CheckinDate:  input.CheckinDate,
+			CheckoutDate: input.CheckoutDate,
+			RoomId:       roomId,
+			RateCodeId:   input.RateCodeId,
+			GuestCount:   input.GuestsPerRoom,
+			Adults:       input.GuestsPerRoom,
+			GroupName:    input.GroupName,
+			CheckStatus:  models.Tentative,
+		}
+		child.SetAudit(username)
+		children = append(children, child)
+	}
+
+	return s.Repository.Create(ctx, master, children)
+}
+
+// Find returns group master with its child reservations, it returns nil if the group does not exist.
+func (s *GroupReservationService) Find(ctx context.Context, id uint64) (*models.Reservation, error) {
+	return s.Repository.Find(ctx, id)
+}
+
+// ApplyRoomingList assigns guests to rooms of the group.
+func (s *GroupReservationService) ApplyRoomingList(ctx context.Context, id uint64, items []*dto.RoomingListItemDto, username string) error {
+	return s.Repository.ApplyRoomingList(ctx, id, items, username)
+}
+
+// ReleaseUnpickedRooms releases tentative rooms of groups whose release date has passed.
+func (s *GroupReservationService) ReleaseUnpickedRooms(ctx context.Context) (int, error) {
+	return s.Repository.ReleaseUnpickedRooms(ctx, time.Now(), global_variables.SystemUsername)
+}
+
+// GetFolio returns group level billing of given group master, it contains charges and payments of the master
+// (group account) and every room of the group. Released rooms are not billed unless they have charges.
+func (s *GroupReservationService) GetFolio(ctx context.Context, master *models.Reservation) (*dto.GroupFolioDto, error) {
+
+	result := &dto.GroupFolioDto{
+		MasterId:  master.Id,
+		GroupName: master.GroupName,
+		Lines:     make([]*dto.GroupFolioLineDto, 0, len(master.Children)+1),
+	}
+
+	reservations := append([]*models.Reservation{master}, master.Children...)
+
+	for _, reservation := range reservations {
+
+		line, err := s.getFolioLine(ctx, reservation)
+		if err != nil {
+			return nil, err
+		}
+
+		if line.Price == 0 && line.TaxAmount == 0 && line.AddOnAmount == 0 && line.Debits == 0 && line.Credits == 0 &&
+			!line.IsGroupMaster {
+			continue
+		}
+
+		result.Lines = append(result.Lines, line)
+		result.TotalPrice += line.Price
+		result.TotalTaxes += line.TaxAmount
+		result.TotalAddOns += line.AddOnAmount
+		result.TotalDebits += line.Debits
+		result.TotalCredits += line.Credits
+		result.TotalDue += line.Due
+	}
+
+	return result, nil
+}
+
+func (s *GroupReservationService) getFolioLine(ctx context.Context, reservation *models.Reservation) (*dto.GroupFolioLineDto, error) {
+
+	balances, err := s.FolioService.GetEntryTypeBalances(ctx, reservation.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	line := &dto.GroupFolioLineDto{
+		ReservationId: reservation.Id,
+		RoomId:        reservation.RoomId,
+		IsGroupMaster: reservation.IsGroupMaster,
+		Price:         balances[models.RoomChargeEntry],
+		TaxAmount:     balances[models.TaxEntry],
+		AddOnAmount:   balances[models.AddOnEntry],
+		Debits:        balances[models.AdjustmentEntry] + balances[models.RefundEntry],
+		Credits:       -balances[models.PaymentEntry],
+	}
+	line.Due = line.Price + line.TaxAmount + line.AddOnAmount + line.Debits - line.Credits
+
+	return line, nil
+}
@@ -1,20 +1,38 @@
 package domain_services
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
+	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
 	"reservation-api/internal/repositories"
+	"reservation-api/internal/services/common_services"
+	"reservation-api/internal/tenant_resolver"
+	"reservation-api/pkg/field_encryption"
+	"time"
+)
+
+const (
+	// expiredGuestsBatchSize is the maximum number of guests which are anonymized in one run of retention job.
+	expiredGuestsBatchSize = 500
+	// reencryptBatchSize is the maximum number of rows which are re-encrypted in one run of re-encryption job.
+	reencryptBatchSize = 500
 )
 
 type GuestService struct {
-	Repository *repositories.GuestRepository
+	Repository      *repositories.GuestRepository
+	FileTransformer common_services.FileTransformer
 }
 
 // NewGuestService returns new GuestService
-func NewGuestService(r *repositories.GuestRepository) *GuestService {
-	return &GuestService{Repository: r}
+func NewGuestService(r *repositories.GuestRepository, fs common_services.FileTransformer) *GuestService {
+	return &GuestService{Repository: r, FileTransformer: fs}
 }
 
 // Create creates new Guest.
@@ -46,3 +64,161 @@ func (s *GuestService) ReservationsCount(ctx context.Context, guestId uint64) (e
 
 	return s.Repository.ReservationsCount(ctx, guestId)
 }
+
+// FindDuplicates returns a page of pairs of guests which are probably the same person, the most probable first.
+func (s *GuestService) FindDuplicates(ctx context.Context, filter *dto.GuestDuplicateFilter,
+	input *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	minScore := filter.MinScore
+	if minScore <= 0 {
+		minScore = dto.DefaultDuplicateScore
+	}
+
+	return s.Repository.FindDuplicates(ctx, minScore, filter.GuestId, input)
+}
+
+// Merge merges a duplicate guest into the surviving guest and returns the audit entry of the merge,
+// it returns nil if one of the guests does not exist.
+func (s *GuestService) Merge(ctx context.Context, input *dto.GuestMergeDto, username string) (*models.GuestMergeLog, error) {
+
+	return s.Repository.Merge(ctx, input.SurvivorId, input.MergedId, username)
+}
+
+// Export returns a zip archive of everything which is held about the guest, guest.json has the guest profile,
+// reservations, sharers, payments, emails and invoices and documents of invoices are attached in invoices directory.
+// It returns nil if the guest does not exist.
+func (s *GuestService) Export(ctx context.Context, id uint64) ([]byte, error) {
+
+	data, err := s.Repository.Export(ctx, id)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeZipFile(archive, "guest.json", content); err != nil {
+		return nil, err
+	}
+
+	for _, invoice := range data.Invoices {
+
+		if invoice.FileName == "" {
+			continue
+		}
+
+		document, err := s.FileTransformer.Get(invoice.BucketName, invoice.FileName)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("invoices/%s%s", invoice.InvoiceNo, filepath.Ext(invoice.FileName))
+		if err := writeZipFile(archive, name, document); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Anonymize irreversibly erases personal data of the guest, it returns nil if the guest does not exist.
+func (s *GuestService) Anonymize(ctx context.Context, id uint64, username string) (*models.Guest, error) {
+
+	return s.Repository.Anonymize(ctx, id, username)
+}
+
+// RetentionPolicy returns the retention policy of the tenant, guests are not anonymized automatically if it is not set.
+func (s *GuestService) RetentionPolicy(ctx context.Context) (*models.RetentionPolicy, error) {
+
+	policy, err := s.Repository.FindRetentionPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == nil {
+		return &models.RetentionPolicy{}, nil
+	}
+
+	return policy, nil
+}
+
+// SetRetentionPolicy sets the retention policy of the tenant.
+func (s *GuestService) SetRetentionPolicy(ctx context.Context, policy *models.RetentionPolicy) (*models.RetentionPolicy, error) {
+
+	return s.Repository.SaveRetentionPolicy(ctx, policy)
+}
+
+// AnonymizeExpiredGuests anonymizes guests who have not stayed during retention period of the tenant and returns
+// number of anonymized guests.
+func (s *GuestService) AnonymizeExpiredGuests(ctx context.Context, now time.Time) (int, error) {
+
+	policy, err := s.Repository.FindRetentionPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff, ok := policy.GuestCutoff(now)
+	if !ok {
+		return 0, nil
+	}
+
+	ids, err := s.Repository.FindExpired(ctx, cutoff, expiredGuestsBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		if _, err := s.Repository.Anonymize(ctx, id, global_variables.SystemUsername); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// RecordEmail records an email which is sent to a guest.
+func (s *GuestService) RecordEmail(ctx context.Context, email *models.GuestEmail) error {
+
+	return s.Repository.CreateEmail(ctx, email)
+}
+
+// RotateEncryptionKey adds a new data key to the tenant which encrypts guest documents from now on, it returns version
+// of the new key. Documents which are encrypted with old keys are re-encrypted by ReencryptGuests.
+func (s *GuestService) RotateEncryptionKey(ctx context.Context) (uint32, error) {
+
+	keyring := field_encryption.Default()
+	if keyring == nil {
+		return 0, models.FieldEncryptionDisabledErr
+	}
+
+	return keyring.Rotate(ctx, tenant_resolver.GetCurrentTenant(ctx))
+}
+
+// ReencryptGuests re-encrypts a batch of guest documents which are not encrypted with the active data key of the tenant
+// and returns number of re-encrypted rows.
+func (s *GuestService) ReencryptGuests(ctx context.Context) (int, error) {
+
+	return s.Repository.Reencrypt(ctx, reencryptBatchSize)
+}
+
+func writeZipFile(archive *zip.Writer, name string, content []byte) error {
+
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	return err
+}
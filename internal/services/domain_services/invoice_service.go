@@ -0,0 +1,189 @@
+package domain_services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/global_variables"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+	"reservation-api/internal/services/common_services"
+	"strings"
+	"sync"
+)
+
+type InvoiceService struct {
+	Repository      *repositories.InvoiceRepository
+	ReportService   *common_services.ReportService
+	FileTransformer common_services.FileTransformer
+}
+
+// NewInvoiceService returns new InvoiceService
+func NewInvoiceService(r *repositories.InvoiceRepository, reportService *common_services.ReportService,
+	fs common_services.FileTransformer) *InvoiceService {
+
+	return &InvoiceService{Repository: r, ReportService: reportService, FileTransformer: fs}
+}
+
+// Latest returns the last issued invoice of the reservation and its PDF document, it returns nil if the reservation
+// has no invoice.
+func (s *InvoiceService) Latest(ctx context.Context, reservationId uint64) (*models.Invoice, []byte, error) {
+
+	latest, err := s.Repository.FindLatest(ctx, reservationId)
+	if err != nil || latest == nil {
+		return nil, nil, err
+	}
+
+	content, err := s.FileTransformer.Get(latest.BucketName, latest.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return latest, content, nil
+}
+
+// Issue returns invoice of the reservation and its PDF document. The last invoice is reissued exactly as it is
+// stored unless entries are posted to folios of the reservation after it, then a new invoice is issued.
+// It returns nil if the reservation or its hotel does not exist.
+func (s *InvoiceService) Issue(ctx context.Context, reservationId uint64, username string) (*models.Invoice, []byte, error) {
+
+	reservation, entries, err := s.Repository.FindReservation(ctx, reservationId)
+	if err != nil || reservation == nil {
+		return nil, nil, err
+	}
+
+	hotel := reservation.Hotel
+	if reservation.Room != nil && reservation.Room.RoomType.Hotel != nil {
+		hotel = reservation.Room.RoomType.Hotel
+	}
+
+	if hotel == nil || hotel.Id == 0 {
+		return nil, nil, nil
+	}
+
+	var lastEntryId uint64 = 0
+	for _, entry := range entries {
+		if entry.Id > lastEntryId {
+			lastEntryId = entry.Id
+		}
+	}
+
+	latest, err := s.Repository.FindLatest(ctx, reservationId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if latest != nil && latest.LastEntryId >= lastEntryId {
+		content, err := s.FileTransformer.Get(latest.BucketName, latest.FileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return latest, content, nil
+	}
+
+	invoice := &models.Invoice{
+		HotelId:       hotel.Id,
+		ReservationId: reservation.Id,
+		GuestId:       reservation.SupervisorId,
+		LastEntryId:   lastEntryId,
+	}
+	invoice.SetAudit(username)
+	invoice.SetTotals(entries)
+
+	document := &dto.InvoiceDto{
+		Invoice:     invoice,
+		Hotel:       hotel,
+		Guest:       reservation.Supervisor,
+		Reservation: reservation,
+	}
+	setInvoiceLines(document, entries)
+
+	// invoice is printed without logo if the logo can not be read.
+	if hotel.Logo != "" {
+		if logo, err := s.FileTransformer.Get(global_variables.HotelsBucketName, hotel.Logo); err == nil {
+			document.Logo = logo
+			document.LogoType = strings.TrimPrefix(strings.ToLower(filepath.Ext(hotel.Logo)), ".")
+		}
+	}
+
+	var content []byte
+	result, err := s.Repository.Issue(ctx, invoice, func(invoice *models.Invoice) error {
+
+		if content, err = s.ReportService.InvoiceToPDF(document); err != nil {
+			return err
+		}
+
+		return s.store(invoice, content)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the invoice is issued by a concurrent request, its document is reissued.
+	if content == nil {
+		if content, err = s.FileTransformer.Get(result.BucketName, result.FileName); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return result, content, nil
+}
+
+// store uploads the invoice document to invoices bucket.
+func (s *InvoiceService) store(invoice *models.Invoice, content []byte) error {
+
+	file, err := os.CreateTemp("", fmt.Sprintf("%s-*.pdf", invoice.InvoiceNo))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		file.Close()
+		return err
+	}
+
+	// Upload closes the file and marks the wait group as done.
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	uploadResult, err := s.FileTransformer.Upload(global_variables.InvoicesBucketName, "", file, &wg)
+	if err != nil {
+		return err
+	}
+
+	invoice.BucketName = uploadResult.BucketName
+	invoice.FileName = uploadResult.FileName
+	invoice.VersionID = uploadResult.VersionID
+
+	return nil
+}
+
+// setInvoiceLines itemizes folio entries which are not reversed as charges, taxes and payments of the invoice.
+func setInvoiceLines(document *dto.InvoiceDto, entries []*models.FolioEntry) {
+
+	document.Charges = make([]*dto.InvoiceLineDto, 0)
+	document.Taxes = make([]*dto.InvoiceLineDto, 0)
+	document.Payments = make([]*dto.InvoiceLineDto, 0)
+
+	for _, entry := range models.NetFolioEntries(entries) {
+
+		line := &dto.InvoiceLineDto{Date: entry.Date, Description: entry.Description, Amount: entry.Debit - entry.Credit}
+
+		switch entry.EntryType {
+		case models.TaxEntry:
+			document.Taxes = append(document.Taxes, line)
+		case models.PaymentEntry, models.RefundEntry:
+			document.Payments = append(document.Payments, line)
+		default:
+			document.Charges = append(document.Charges, line)
+		}
+	}
+}
@@ -2,16 +2,28 @@ package domain_services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
 	"reservation-api/internal/repositories"
+	"reservation-api/pkg/payment_gateway"
+	"strconv"
 )
 
 type PaymentService struct {
-	Repository *repositories.PaymentRepository
+	Repository   *repositories.PaymentRepository
+	FolioService *FolioService
+	Gateway      payment_gateway.PaymentGateway
+	// Currency is the currency of charges which do not have one.
+	Currency string
 }
 
-func NewPaymentService(repository *repositories.PaymentRepository) *PaymentService {
-	return &PaymentService{Repository: repository}
+func NewPaymentService(repository *repositories.PaymentRepository, folioService *FolioService,
+	gateway payment_gateway.PaymentGateway, currency string) *PaymentService {
+	return &PaymentService{Repository: repository, FolioService: folioService, Gateway: gateway, Currency: currency}
 }
 
 func (s *PaymentService) Create(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
@@ -24,9 +36,40 @@ func (s *PaymentService) Find(ctx context.Context, id uint64) (*models.Payment,
 	return s.Repository.Find(ctx, id)
 }
 
-func (s *PaymentService) Delete(ctx context.Context, id uint64) error {
+// Refund refunds amount of the payment for reason, the remaining amount of the payment is refunded if amount is zero.
+// Card payments are refunded through the payment gateway too. It returns nil if the payment does not exist,
+// RefundExceedsPaymentErr if amount is more than the amount which is not refunded and InvalidRefundErr for refunds and DEBIT payments.
+func (s *PaymentService) Refund(ctx context.Context, id uint64, amount float64, reason models.RefundReason, username string) (*models.Payment, error) {
 
-	return s.Repository.Delete(ctx, id)
+	tenantId := fmt.Sprintf("%v", ctx.Value(global_variables.TenantIDKey))
+
+	return s.Repository.Refund(ctx, id, amount, reason, username, func(payment *models.Payment, refund *models.Payment) error {
+
+		if payment.GatewayReference == "" {
+			return nil
+		}
+
+		currency, amount := s.Currency, -refund.Amount
+		charge, err := s.Repository.FindChargeByPayment(ctx, payment.Id)
+		if err != nil {
+			return err
+		}
+		// the payment is in currency of the folio and the card is refunded in currency of its charge.
+		if charge != nil {
+			currency, amount = charge.Currency, charge.ToChargeAmount(amount)
+		}
+
+		// refunded amount including the refund identifies it, so retries of a refund which is not stored
+		// are not refunded again by the gateway.
+		result, err := s.Gateway.Refund(ctx, payment.GatewayReference, amount, currency,
+			fmt.Sprintf("refund-%s-%d-%.2f", tenantId, payment.Id, payment.RefundedAmount))
+		if err != nil {
+			return err
+		}
+
+		refund.GatewayReference = result.Reference
+		return nil
+	})
 }
 
 func (s *PaymentService) GetListByReservationID(ctx context.Context, reservationID uint64, paymentType *models.PaymentType) ([]*models.Payment, error) {
@@ -34,7 +77,185 @@ func (s *PaymentService) GetListByReservationID(ctx context.Context, reservation
 	return s.Repository.GetListByReservationID(ctx, reservationID, paymentType)
 }
 
-func (s *PaymentService) GetBalance(ctx context.Context, reservationID uint64, paymentType *models.PaymentType) (float64, error) {
+// Charge charges the card for balance of the reservation through the payment gateway in currency of the reservation.
+// It returns InvalidChargeAmountErr if the amount is not positive or more than the balance which is not charged yet
+// and ChargeDeclinedErr if the card is declined.
+// A charge which the gateway does not answer stays pending until its webhook arrives.
+func (s *PaymentService) Charge(ctx context.Context, input *dto.ChargeDto, username string) (*models.Charge, error) {
+
+	if input.Amount < 0 {
+		return nil, models.InvalidChargeAmountErr
+	}
+
+	charge := &models.Charge{
+		ReservationId: input.ReservationId,
+		PayerId:       input.PayerId,
+		FolioAmount:   math.Round(input.Amount*100) / 100,
+		ReceiptEmail:  input.ReceiptEmail,
+		Status:        models.ChargePending,
+	}
+	charge.SetAudit(username)
+
+	if _, err := s.Repository.CreateCharge(ctx, charge, s.Currency); err != nil {
+		return nil, err
+	}
+
+	tenantId := fmt.Sprintf("%v", ctx.Value(global_variables.TenantIDKey))
+	result, err := s.Gateway.Authorize(ctx, &payment_gateway.ChargeRequest{
+		Amount:         charge.Amount,
+		Currency:       charge.Currency,
+		PaymentMethod:  input.PaymentMethod,
+		Description:    fmt.Sprintf("reservation #%d", input.ReservationId),
+		ReceiptEmail:   input.ReceiptEmail,
+		Capture:        input.Capture,
+		IdempotencyKey: fmt.Sprintf("charge-%s-%d", tenantId, charge.Id),
+		Metadata: map[string]string{
+			"tenant_id":      tenantId,
+			"charge_id":      strconv.FormatUint(charge.Id, 10),
+			"reservation_id": strconv.FormatUint(input.ReservationId, 10),
+		},
+	})
+
+	if err != nil {
+
+		declined := &payment_gateway.DeclinedError{}
+		if !errors.As(err, &declined) {
+			return nil, err
+		}
+
+		update := &models.Charge{Status: models.ChargeFailed, FailureMessage: declined.Message}
+		if declined.Result != nil {
+			update.GatewayReference = declined.Result.Reference
+		}
+
+		if _, err := s.Repository.UpdateCharge(ctx, charge.Id, update, username); err != nil {
+			return nil, err
+		}
+
+		return nil, models.ChargeDeclinedErr
+	}
+
+	return s.Repository.UpdateCharge(ctx, charge.Id, chargeUpdate(result), username)
+}
+
+func (s *PaymentService) FindCharge(ctx context.Context, id uint64) (*models.Charge, error) {
+
+	return s.Repository.FindCharge(ctx, id)
+}
+
+// CaptureCharge captures amount of an authorized charge, whole authorized amount is captured if amount is zero.
+// It returns nil if the charge does not exist and InvalidChargeStatusErr if it is not authorized.
+func (s *PaymentService) CaptureCharge(ctx context.Context, id uint64, amount float64, username string) (*models.Charge, error) {
+
+	charge, err := s.authorizedCharge(ctx, id)
+	if err != nil || charge == nil {
+		return nil, err
+	}
+
+	if amount < 0 || amount > charge.Amount {
+		return nil, models.InvalidChargeAmountErr
+	}
+
+	result, err := s.Gateway.Capture(ctx, charge.GatewayReference, amount, charge.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repository.UpdateCharge(ctx, id, chargeUpdate(result), username)
+}
+
+// VoidCharge releases an authorized charge. It returns nil if the charge does not exist and
+// InvalidChargeStatusErr if it is not authorized.
+func (s *PaymentService) VoidCharge(ctx context.Context, id uint64, username string) (*models.Charge, error) {
+
+	charge, err := s.authorizedCharge(ctx, id)
+	if err != nil || charge == nil {
+		return nil, err
+	}
+
+	result, err := s.Gateway.Void(ctx, charge.GatewayReference)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repository.UpdateCharge(ctx, id, chargeUpdate(result), username)
+}
+
+// HandleWebhook verifies a webhook of the payment gateway and applies its charge to the stored charge.
+// Webhooks are not sent with a tenant, so the tenant is read from metadata of the charge which is set by Charge.
+// Events of other objects and charges which are not created by Charge are ignored.
+func (s *PaymentService) HandleWebhook(payload []byte, signature string) error {
+
+	event, err := s.Gateway.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	if event.Charge == nil {
+		return nil
+	}
+
+	tenantId, err := strconv.ParseUint(event.Charge.Metadata["tenant_id"], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	chargeId, err := strconv.ParseUint(event.Charge.Metadata["charge_id"], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	ctx := context.WithValue(context.Background(), global_variables.TenantIDKey, tenantId)
+
+	charge, err := s.Repository.FindCharge(ctx, chargeId)
+	if err != nil || charge == nil {
+		return err
+	}
+
+	if charge.GatewayReference != "" && charge.GatewayReference != event.Charge.Reference {
+		return nil
+	}
+
+	_, err = s.Repository.UpdateCharge(ctx, chargeId, chargeUpdate(event.Charge), global_variables.SystemUsername)
+	return err
+}
+
+// authorizedCharge returns the charge, it returns InvalidChargeStatusErr if the charge is not authorized.
+func (s *PaymentService) authorizedCharge(ctx context.Context, id uint64) (*models.Charge, error) {
+
+	charge, err := s.Repository.FindCharge(ctx, id)
+	if err != nil || charge == nil {
+		return nil, err
+	}
+
+	if charge.Status != models.ChargeAuthorized {
+		return nil, models.InvalidChargeStatusErr
+	}
+
+	return charge, nil
+}
+
+// chargeUpdate converts state of a charge in the gateway to the stored charge fields.
+func chargeUpdate(result *payment_gateway.ChargeResult) *models.Charge {
+
+	update := &models.Charge{
+		GatewayReference: result.Reference,
+		AmountCaptured:   result.AmountCaptured,
+		FailureMessage:   result.FailureMessage,
+	}
+
+	switch result.Status {
+	case payment_gateway.ChargeAuthorized:
+		update.Status = models.ChargeAuthorized
+	case payment_gateway.ChargeCaptured:
+		update.Status = models.ChargeCaptured
+	case payment_gateway.ChargeVoided:
+		update.Status = models.ChargeVoided
+	case payment_gateway.ChargeFailed:
+		update.Status = models.ChargeFailed
+	default:
+		update.Status = models.ChargePending
+	}
 
-	return s.Repository.GetBalance(ctx, reservationID, paymentType)
+	return update
 }
@@ -0,0 +1,54 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type PricingRuleService struct {
+	Repository *repositories.PricingRuleRepository
+}
+
+// NewPricingRuleService returns new PricingRuleService
+func NewPricingRuleService(r *repositories.PricingRuleRepository) *PricingRuleService {
+	return &PricingRuleService{Repository: r}
+}
+
+// Create creates new PricingRule.
+func (s *PricingRuleService) Create(ctx context.Context, model *models.PricingRule) (*models.PricingRule, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates PricingRule.
+func (s *PricingRuleService) Update(ctx context.Context, model *models.PricingRule) (*models.PricingRule, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns PricingRule and if it does not find the PricingRule, it returns nil.
+func (s *PricingRuleService) Find(ctx context.Context, id uint64) (*models.PricingRule, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of PricingRules.
+func (s *PricingRuleService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes PricingRule by given id.
+func (s *PricingRuleService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
+
+// FindAdjustments returns paginated list of logged pricing rule adjustments.
+func (s *PricingRuleService) FindAdjustments(ctx context.Context, filter *dto.PriceAdjustmentFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAdjustments(ctx, filter)
+}
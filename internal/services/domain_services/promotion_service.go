@@ -0,0 +1,48 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type PromotionService struct {
+	Repository *repositories.PromotionRepository
+}
+
+// NewPromotionService returns new PromotionService
+func NewPromotionService(r *repositories.PromotionRepository) *PromotionService {
+	return &PromotionService{Repository: r}
+}
+
+// Create creates new Promotion.
+func (s *PromotionService) Create(ctx context.Context, model *models.Promotion) (*models.Promotion, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates Promotion.
+func (s *PromotionService) Update(ctx context.Context, model *models.Promotion) (*models.Promotion, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns Promotion and if it does not find the Promotion, it returns nil.
+func (s *PromotionService) Find(ctx context.Context, id uint64) (*models.Promotion, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of Promotions.
+func (s *PromotionService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes Promotion by given id.
+func (s *PromotionService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
@@ -2,10 +2,20 @@ package domain_services
 
 import (
 	"context"
+	"errors"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/models"
 	"reservation-api/internal/repositories"
+	"reservation-api/internal_errors/message_keys"
+	"time"
+)
+
+// maxRateCalendarDays is the longest date range which prices can be set for by one calendar update.
+const maxRateCalendarDays = 731
+
+var (
+	InvalidRateCalendarErr = errors.New(message_keys.InvalidRateCalendar)
 )
 
 type RateCodeDetailService struct {
@@ -52,3 +62,43 @@ func (s *RateCodeDetailService) FindPrice(ctx context.Context, id uint64) (*mode
 
 	return s.Repository.FindPrice(ctx, id)
 }
+
+// UpdateCalendar sets prices of the rate code for a date range of rooms and returns changed details,
+// it returns nil if rate code does not exist.
+func (s *RateCodeDetailService) UpdateCalendar(ctx context.Context, rateCodeId uint64, update *dto.RateCalendarUpdateDto,
+	username string) (*dto.RateCalendarDiffDto, error) {
+
+	if err := validateRateCalendar(update); err != nil {
+		return nil, err
+	}
+
+	return s.Repository.ApplyCalendar(ctx, rateCodeId, update, username)
+}
+
+func validateRateCalendar(update *dto.RateCalendarUpdateDto) error {
+
+	if update.DateStart == nil || update.DateEnd == nil || update.DateEnd.Before(*update.DateStart) ||
+		update.DateEnd.Sub(*update.DateStart).Hours()/24 > maxRateCalendarDays {
+		return InvalidRateCalendarErr
+	}
+
+	if len(update.Prices) == 0 || len(update.RoomIds)+len(update.RoomTypeIds) == 0 {
+		return InvalidRateCalendarErr
+	}
+
+	guestCounts := make(map[uint64]bool)
+	for _, price := range update.Prices {
+		if price.GuestCount == 0 || price.Price < 0 || guestCounts[price.GuestCount] {
+			return InvalidRateCalendarErr
+		}
+		guestCounts[price.GuestCount] = true
+	}
+
+	for _, weekday := range update.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return InvalidRateCalendarErr
+		}
+	}
+
+	return nil
+}
@@ -20,12 +20,28 @@ func NewRateCodeService(r *repositories.RateCodeRepository) *RateCodeService {
 // Create creates new RateCode.
 func (s *RateCodeService) Create(ctx context.Context, model *models.RateCode) (*models.RateCode, error) {
 
+	if err := s.Repository.CheckParent(ctx, model); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repository.CheckAddOns(ctx, model); err != nil {
+		return nil, err
+	}
+
 	return s.Repository.Create(ctx, model)
 }
 
 // Update updates RateCode.
 func (s *RateCodeService) Update(ctx context.Context, model *models.RateCode) (*models.RateCode, error) {
 
+	if err := s.Repository.CheckParent(ctx, model); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repository.CheckAddOns(ctx, model); err != nil {
+		return nil, err
+	}
+
 	return s.Repository.Update(ctx, model)
 }
 
@@ -2,27 +2,42 @@ package domain_services
 
 import (
 	"context"
+	"errors"
 	"reservation-api/internal/commons"
 	"reservation-api/internal/dto"
 	"reservation-api/internal/global_variables"
 	"reservation-api/internal/models"
 	"reservation-api/internal/repositories"
+	"reservation-api/internal/tenant_resolver"
 	"reservation-api/internal/utils"
+	"reservation-api/internal/utils/date_utils"
+	"reservation-api/internal_errors/message_keys"
 	"reservation-api/pkg/message_broker"
 	"time"
 )
 
+const (
+	// dueTolerance ignores rounding remainders of payments.
+	dueTolerance = 0.005
+)
+
+var (
+	UnpaidBalanceErr = errors.New(message_keys.UnpaidBalance)
+)
+
 type ReservationService struct {
 	Repository           *repositories.ReservationRepository
 	MessageBrokerManager message_broker.MessageBrokerManager
+	FolioService         *FolioService
 }
 
 // NewReservationService returns new ReservationService
 func NewReservationService(repository *repositories.ReservationRepository,
-	messageBroker message_broker.MessageBrokerManager) *ReservationService {
+	messageBroker message_broker.MessageBrokerManager, folioService *FolioService) *ReservationService {
 	return &ReservationService{
 		Repository:           repository,
 		MessageBrokerManager: messageBroker,
+		FolioService:         folioService,
 	}
 }
 
@@ -31,15 +46,112 @@ func (s *ReservationService) Create(ctx context.Context, model *models.Reservati
 
 	result, err := s.Repository.Create(ctx, model)
 	if err != nil {
-		s.MessageBrokerManager.PublishMessage(global_variables.ReservationQueueName, utils.ToJson(model))
+		return nil, err
 	}
+
+	// created reservations are published, so their emails are sent and recorded as data of the guest.
+	// consumers of the event need the tenant to work with its database.
+	result.TenantId = tenant_resolver.GetCurrentTenant(ctx)
+	s.MessageBrokerManager.PublishMessage(global_variables.ReservationQueueName, utils.ToJson(result))
 	return result, nil
 }
 
-// ChangeStatus changes the reservation check status.
-func (s *ReservationService) ChangeStatus(ctx context.Context, id uint64, status models.ReservationCheckStatus) (*models.Reservation, error) {
+// ChangeStatus moves the reservation to given status if the lifecycle allows it.
+// Check-in is not allowed before the arrival day and checkout is not allowed while reservation has unpaid balance.
+// Cancellation must be done by Cancel to charge the cancellation penalty.
+// It returns nil if the reservation does not exist.
+func (s *ReservationService) ChangeStatus(ctx context.Context, id uint64, status models.ReservationCheckStatus,
+	username string) (*models.Reservation, error) {
+
+	reservation, err := s.Repository.Find(ctx, id)
+	if err != nil || reservation == nil {
+		return nil, err
+	}
+
+	if status == models.Cancelled || !models.CanTransition(reservation.CheckStatus, status) {
+		return nil, models.InvalidStatusTransitionErr
+	}
+
+	now := time.Now()
+
+	if status == models.CheckedIn && !reservation.CanCheckIn(now) {
+		return nil, models.EarlyCheckInErr
+	}
 
-	return s.Repository.ChangeStatus(ctx, id, status)
+	if status == models.CheckedOut {
+
+		due, err := s.GetDueAmount(ctx, reservation)
+		if err != nil {
+			return nil, err
+		}
+
+		if due > dueTolerance {
+			return nil, UnpaidBalanceErr
+		}
+	}
+
+	return s.Repository.ChangeStatus(ctx, reservation, status, now, username)
+}
+
+// GetDueAmount returns amount which guest still has to pay for the reservation, it is sum of balances of its folios.
+func (s *ReservationService) GetDueAmount(ctx context.Context, reservation *models.Reservation) (float64, error) {
+
+	return s.FolioService.GetBalance(ctx, reservation.Id)
+}
+
+// ProcessNoShows marks confirmed reservations which are not checked in until their hotel's no-show cutoff
+// as NoShow and posts the no-show fee of their rate code, so they do not block the room anymore.
+func (s *ReservationService) ProcessNoShows(ctx context.Context) error {
+
+	now := time.Now()
+
+	reservations, err := s.Repository.FindNoShowCandidates(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+
+		if reservation.CheckinDate == nil || reservation.Room == nil {
+			continue
+		}
+
+		hotel := reservation.Room.RoomType.Hotel
+		if hotel == nil || now.Before(hotel.NoShowCutoff(*reservation.CheckinDate)) {
+			continue
+		}
+
+		fee := 0.0
+		if reservation.RateCode != nil {
+			fee = reservation.RateCode.NoShowFee
+		}
+
+		err := s.Repository.MarkNoShow(ctx, reservation, fee, now, global_variables.SystemUsername)
+		if err != nil && !errors.Is(err, models.InvalidStatusTransitionErr) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindStatusHistory returns status transitions of given reservation from oldest to newest.
+func (s *ReservationService) FindStatusHistory(ctx context.Context, reservationId uint64) ([]*models.ReservationStatusHistory, error) {
+	return s.Repository.FindStatusHistory(ctx, reservationId)
+}
+
+// Cancel cancels the reservation, records penalty of its cancellation policy as a DEBIT payment
+// and publishes cancellation event. It returns nil if the reservation does not exist.
+func (s *ReservationService) Cancel(ctx context.Context, id uint64, username string) (*dto.ReservationCancellationDto, error) {
+
+	result, err := s.Repository.Cancel(ctx, id, time.Now(), username)
+	if err != nil || result == nil {
+		return result, err
+	}
+
+	s.MessageBrokerManager.PublishMessage(global_variables.ReservationCancelQueueName, utils.ToJson(result))
+
+	return result, nil
 }
 
 // Update updates Reservation.
@@ -54,13 +166,43 @@ func (s *ReservationService) CreateReservationRequest(ctx context.Context, reque
 	return s.Repository.CreateReservationRequest(ctx, requestDto)
 }
 
+// HasConflict checks if given room request overlaps with other reservation requests or reservations.
 func (s *ReservationService) HasConflict(ctx context.Context, request *dto.RoomRequestDto, reservation *models.Reservation) (bool, error) {
 	return s.Repository.HasConflict(ctx, request, reservation)
 }
 
-func (s *ReservationService) HasReservationConflict(ctx context.Context, checkInDate *time.Time, checkOutDate *time.Time, roomId uint64) (bool, error) {
+// HasReservationConflict checks if given stay overlaps with other active reservations of the room,
+// excludeReservationId is id of the reservation being edited and 0 for new reservations.
+func (s *ReservationService) HasReservationConflict(ctx context.Context, checkInDate *time.Time, checkOutDate *time.Time,
+	roomId uint64, excludeReservationId uint64) (bool, error) {
+
+	return s.Repository.HasReservationConflict(ctx, checkInDate, checkOutDate, roomId, excludeReservationId)
+}
+
+// CheckStayRestrictions checks closed to arrival/departure, min/max nights and advance booking restrictions
+// of the rate code for given room and stay.
+func (s *ReservationService) CheckStayRestrictions(ctx context.Context, roomId uint64, rateCodeId uint64,
+	checkInDate *time.Time, checkOutDate *time.Time) error {
+
+	return s.Repository.CheckStayRestrictions(ctx, roomId, rateCodeId, checkInDate, checkOutDate, time.Now())
+}
+
+// CheckUpdateStayRestrictions checks stay restrictions of the updated reservation if its dates or rate code are changed,
+// advance booking is measured from the time which the reservation is booked.
+func (s *ReservationService) CheckUpdateStayRestrictions(ctx context.Context, current *models.Reservation,
+	updated *models.Reservation) error {
+
+	if !current.ChangesStay(updated) {
+		return nil
+	}
+
+	bookedAt := time.Now()
+	if current.CreatedAt != nil {
+		bookedAt = *current.CreatedAt
+	}
 
-	return s.Repository.HasReservationConflict(ctx, checkInDate, checkOutDate, roomId)
+	return s.Repository.CheckStayRestrictions(ctx, updated.RoomId, updated.RateCodeId, updated.CheckinDate,
+		updated.CheckoutDate, bookedAt)
 }
 
 // RemoveReservationRequest this function remove reservation request bt given requestKey param.
@@ -68,8 +210,8 @@ func (s *ReservationService) RemoveReservationRequest(ctx context.Context, reque
 	return s.Repository.DeleteReservationRequest(ctx, requestKey)
 }
 
-// GetRecommendedRateCodes returns list of recommended rateCodeDetails price per reservation condition.
-func (s *ReservationService) GetRecommendedRateCodes(ctx context.Context, priceDto *dto.GetRatePriceDto) ([]*dto.RateCodePricesDto, error) {
+// GetRecommendedRateCodes returns price of the stay per rate code with per night breakdown.
+func (s *ReservationService) GetRecommendedRateCodes(ctx context.Context, priceDto *dto.GetRatePriceDto) ([]*dto.RateCodeQuoteDto, error) {
 	return s.Repository.GetRecommendedRateCodes(ctx, priceDto)
 }
 
@@ -93,3 +235,129 @@ func (s *ReservationService) RemoveExpiredReservationRequests(ctx context.Contex
 func (s *ReservationService) FindAll(ctx context.Context, filter *dto.ReservationFilter) (error, *commons.PaginatedResult) {
 	return s.Repository.FindAll(ctx, filter)
 }
+
+// FindStayOverlaps returns pairs of active reservations of a room whose stays overlap.
+func (s *ReservationService) FindStayOverlaps(ctx context.Context) ([]*dto.StayOverlapDto, error) {
+
+	return s.Repository.FindStayOverlaps(ctx)
+}
+
+// GetAvailability returns per night and per room status of rooms between filter.From and filter.To
+// and aggregates room counts per room type to show sellable inventory.
+func (s *ReservationService) GetAvailability(ctx context.Context, filter *dto.AvailabilityFilter) (*dto.AvailabilityDto, error) {
+
+	rooms, err := s.Repository.FindRoomsByType(ctx, filter.RoomTypeId)
+	if err != nil {
+		return nil, err
+	}
+
+	roomIds := make([]uint64, 0, len(rooms))
+	for _, room := range rooms {
+		roomIds = append(roomIds, room.Id)
+	}
+
+	reservations, err := s.Repository.FindOverlappingReservations(ctx, roomIds, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := s.Repository.FindOverlappingReservationRequests(ctx, roomIds, filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	nights := date_utils.Nights(*filter.From, *filter.To)
+	result := &dto.AvailabilityDto{
+		From:      date_utils.TruncateToDay(*filter.From),
+		To:        date_utils.TruncateToDay(*filter.To),
+		Rooms:     make([]*dto.RoomAvailabilityDto, 0, len(rooms)),
+		RoomTypes: make([]*dto.RoomTypeAvailabilityDto, 0),
+	}
+
+	roomTypes := make(map[uint64]*dto.RoomTypeAvailabilityDto)
+
+	for _, room := range rooms {
+
+		roomResult := &dto.RoomAvailabilityDto{
+			RoomId:     room.Id,
+			RoomName:   room.Name,
+			RoomTypeId: room.RoomTypeId,
+			Nights:     make([]*dto.RoomNightDto, 0, len(nights)),
+		}
+
+		roomType, ok := roomTypes[room.RoomTypeId]
+		if !ok {
+			roomType = &dto.RoomTypeAvailabilityDto{
+				RoomTypeId:   room.RoomTypeId,
+				RoomTypeName: room.RoomType.Name,
+				Nights:       make([]*dto.RoomTypeNightDto, 0, len(nights)),
+			}
+			for _, night := range nights {
+				roomType.Nights = append(roomType.Nights, &dto.RoomTypeNightDto{Date: night})
+			}
+			roomTypes[room.RoomTypeId] = roomType
+			result.RoomTypes = append(result.RoomTypes, roomType)
+		}
+
+		for i, night := range nights {
+
+			roomNight := getRoomNightStatus(room.Id, night, reservations, requests)
+			roomResult.Nights = append(roomResult.Nights, roomNight)
+
+			counter := roomType.Nights[i]
+			counter.Total++
+
+			switch roomNight.Status {
+			case dto.RoomNightBooked:
+				counter.Booked++
+			case dto.RoomNightBlocked:
+				counter.Blocked++
+			case dto.RoomNightHeld:
+				counter.Held++
+			default:
+				counter.Free++
+				counter.Sellable++
+			}
+		}
+
+		result.Rooms = append(result.Rooms, roomResult)
+	}
+
+	return result, nil
+}
+
+// getRoomNightStatus returns status of given room in given night.
+// reservations have priority over reservation requests (holds).
+func getRoomNightStatus(roomId uint64, night time.Time, reservations []*models.Reservation,
+	requests []*models.ReservationRequest) *dto.RoomNightDto {
+
+	for _, reservation := range reservations {
+		if reservation.RoomId != roomId || reservation.CheckinDate == nil || reservation.CheckoutDate == nil {
+			continue
+		}
+		if date_utils.CoversNight(*reservation.CheckinDate, *reservation.CheckoutDate, night) {
+
+			status := dto.RoomNightBooked
+			if reservation.CheckStatus == models.Block {
+				status = dto.RoomNightBlocked
+			}
+
+			return &dto.RoomNightDto{
+				Date:          night,
+				Status:        status,
+				ReservationId: reservation.Id,
+			}
+		}
+	}
+
+	for _, request := range requests {
+		if request.RoomId != roomId || request.CheckInDate == nil || request.CheckOutDate == nil {
+			continue
+		}
+		if date_utils.CoversNight(*request.CheckInDate, *request.CheckOutDate, night) {
+			return &dto.RoomNightDto{Date: night, Status: dto.RoomNightHeld}
+		}
+	}
+
+	return &dto.RoomNightDto{Date: night, Status: dto.RoomNightFree}
+}
@@ -0,0 +1,48 @@
+package domain_services
+
+import (
+	"context"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
+	"reservation-api/internal/models"
+	"reservation-api/internal/repositories"
+)
+
+type TaxRuleService struct {
+	Repository *repositories.TaxRuleRepository
+}
+
+// NewTaxRuleService returns new TaxRuleService
+func NewTaxRuleService(r *repositories.TaxRuleRepository) *TaxRuleService {
+	return &TaxRuleService{Repository: r}
+}
+
+// Create creates new TaxRule.
+func (s *TaxRuleService) Create(ctx context.Context, model *models.TaxRule) (*models.TaxRule, error) {
+
+	return s.Repository.Create(ctx, model)
+}
+
+// Update updates TaxRule.
+func (s *TaxRuleService) Update(ctx context.Context, model *models.TaxRule) (*models.TaxRule, error) {
+
+	return s.Repository.Update(ctx, model)
+}
+
+// Find returns TaxRule and if it does not find the TaxRule, it returns nil.
+func (s *TaxRuleService) Find(ctx context.Context, id uint64) (*models.TaxRule, error) {
+
+	return s.Repository.Find(ctx, id)
+}
+
+// FindAll returns paginates list of TaxRules.
+func (s *TaxRuleService) FindAll(ctx context.Context, filter *dto.PaginationFilter) (*commons.PaginatedResult, error) {
+
+	return s.Repository.FindAll(ctx, filter)
+}
+
+// Delete removes TaxRule by given id.
+func (s *TaxRuleService) Delete(ctx context.Context, id uint64) error {
+
+	return s.Repository.Delete(ctx, id)
+}
@@ -3,14 +3,16 @@ package domain_services
 import (
 	"context"
 	"github.com/shopspring/decimal"
+	"reservation-api/internal/commons"
+	"reservation-api/internal/dto"
 	. "reservation-api/internal/models"
 	. "reservation-api/internal/repositories"
-	"sync"
 )
 
+// WalletService posts wallet transactions, concurrent transactions of a wallet are serialized
+// by locking the wallet row in the database.
 type WalletService struct {
 	repo *WalletRepository
-	mu   sync.Mutex
 }
 
 func NewWalletService(repo *WalletRepository) *WalletService {
@@ -21,20 +23,31 @@ func (s *WalletService) Create(ctx context.Context, wallet *Wallet) (*Wallet, er
 	return s.repo.CreateWallet(ctx, wallet)
 }
 
-func (s *WalletService) Deposit(ctx context.Context, walletID uint64, amount decimal.Decimal) (*Wallet, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.repo.Deposit(ctx, walletID, amount)
+func (s *WalletService) Deposit(ctx context.Context, walletID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+	return s.repo.Deposit(ctx, walletID, amount, idempotencyKey, username)
 }
 
-func (s *WalletService) Withdraw(ctx context.Context, walletID uint64, amount decimal.Decimal) (*Wallet, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.repo.Withdraw(ctx, walletID, amount)
+func (s *WalletService) Withdraw(ctx context.Context, walletID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+	return s.repo.Withdraw(ctx, walletID, amount, idempotencyKey, username)
+}
+
+func (s *WalletService) Transfer(ctx context.Context, fromID, toID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) ([]*WalletTransaction, error) {
+	return s.repo.Transfer(ctx, fromID, toID, amount, idempotencyKey, username)
+}
+
+// PayFolio pays the folio from the wallet, the whole folio balance is paid if amount is zero.
+func (s *WalletService) PayFolio(ctx context.Context, walletID, folioID uint64, amount decimal.Decimal, idempotencyKey *string,
+	username string) (*WalletTransaction, error) {
+	return s.repo.PayFolio(ctx, walletID, folioID, amount, idempotencyKey, username)
 }
 
 func (s *WalletService) GetWalletByID(ctx context.Context, walletID uint64) (*Wallet, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
 	return s.repo.GetWalletByID(ctx, walletID)
 }
+
+func (s *WalletService) FindTransactions(ctx context.Context, walletID uint64, filter *dto.WalletTransactionFilter) (*commons.PaginatedResult, error) {
+	return s.repo.FindTransactions(ctx, walletID, filter)
+}
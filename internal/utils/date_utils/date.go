@@ -0,0 +1,37 @@
+package date_utils
+
+import "time"
+
+const (
+	// DateLayout is the layout used to read dates from query params.
+	DateLayout = "2006-01-02"
+)
+
+// TruncateToDay returns midnight of given time's calendar day in UTC
+// so that nights of different locations can be compared as map keys.
+func TruncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Nights returns every night (as a truncated day) between start and end.
+// The start day is included and the end day is excluded, so a stay
+// from 1st to 3rd has two nights: 1st and 2nd.
+func Nights(start, end time.Time) []time.Time {
+
+	result := make([]time.Time, 0)
+	day := TruncateToDay(start)
+	last := TruncateToDay(end)
+
+	for day.Before(last) {
+		result = append(result, day)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return result
+}
+
+// CoversNight reports whether the stay [checkIn, checkOut) occupies the given night.
+func CoversNight(checkIn, checkOut, night time.Time) bool {
+	day := TruncateToDay(night)
+	return !TruncateToDay(checkIn).After(day) && TruncateToDay(checkOut).After(day)
+}
@@ -0,0 +1,30 @@
+package date_utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2023, 1, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestNights(t *testing.T) {
+
+	nights := Nights(day(1), day(4))
+
+	assert.Equal(t, 3, len(nights))
+	assert.Equal(t, TruncateToDay(day(1)), nights[0])
+	assert.Equal(t, TruncateToDay(day(3)), nights[2])
+	assert.Equal(t, 0, len(Nights(day(4), day(4))))
+	assert.Equal(t, 0, len(Nights(day(5), day(4))))
+}
+
+func TestCoversNight(t *testing.T) {
+
+	assert.True(t, CoversNight(day(1), day(3), day(1)))
+	assert.True(t, CoversNight(day(1), day(3), day(2)))
+	assert.False(t, CoversNight(day(1), day(3), day(3)))
+	assert.False(t, CoversNight(day(2), day(3), day(1)))
+}
@@ -9,6 +9,7 @@ const (
 	key = "example key 1234"
 )
 
+// Deprecated: Encrypt uses a constant key, encrypt sensitive columns with pkg/field_encryption.
 func Encrypt(text []byte) []byte {
 	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
@@ -30,6 +31,7 @@ func Encrypt(text []byte) []byte {
 	return ciphertext // return encrypted data
 }
 
+// Deprecated: Decrypt uses a constant key, use pkg/field_encryption.
 func Decrypt(text []byte) []byte {
 
 	block, err := aes.NewCipher([]byte(key)) // create new AES cipher using key
@@ -9,6 +9,11 @@ const (
 	hotels       = "Hotels."
 	rooms        = "Rooms."
 	reservation  = "Reservation."
+	rateCodes    = "RateCodes."
+	currencies   = "Currencies."
+	billing      = "Billing."
+	wallets      = "Wallets."
+	guests       = "Guests."
 	/************************************************************/
 	Created = crudMessages + "Created"
 	Updated = crudMessages + "Updated"
@@ -28,6 +33,8 @@ const (
 	GenderInvalid   = "GenderInvalid"
 	/************************************************************/
 	HotelRepeatPostalCode = hotels + "RepeatPostalCode"
+	InvalidChildAgeBand   = hotels + "InvalidChildAgeBand"
+	ChildAgeBandOverlap   = hotels + "ChildAgeBandOverlap"
 	/************************************************************/
 	InvalidRoomCleanStatus    = rooms + "InvalidCleanStatus"
 	RoomTypeHasRoomErr        = rooms + "RoomTypeHasRoomErr"
@@ -39,4 +46,58 @@ const (
 	ImpossibleReservationLatDateError = reservation + "ImpossibleReservationLatDateError"
 	CheckOutDateEmptyError            = reservation + "CheckOutDateEmptyError"
 	CheckInDateEmptyError             = reservation + "CheckInDateEmptyError"
+	InvalidAvailabilityRange          = reservation + "InvalidAvailabilityRange"
+	ReservationNotCancellable         = reservation + "ReservationNotCancellable"
+	InvalidStatusTransition           = reservation + "InvalidStatusTransition"
+	EarlyCheckIn                      = reservation + "EarlyCheckIn"
+	UnpaidBalance                     = reservation + "UnpaidBalance"
+	InvalidGroupReservation           = reservation + "InvalidGroupReservation"
+	GroupRoomNotFound                 = reservation + "GroupRoomNotFound"
+	EmptyRoomingListItem              = reservation + "EmptyRoomingListItem"
+	ClosedToArrival                   = reservation + "ClosedToArrival"
+	ClosedToDeparture                 = reservation + "ClosedToDeparture"
+	MinStayNotMet                     = reservation + "MinStayNotMet"
+	MaxStayExceeded                   = reservation + "MaxStayExceeded"
+	MinAdvanceBookingNotMet           = reservation + "MinAdvanceBookingNotMet"
+	MaxAdvanceBookingExceeded         = reservation + "MaxAdvanceBookingExceeded"
+	PromotionNotFound                 = reservation + "PromotionNotFound"
+	PromotionNotApplicable            = reservation + "PromotionNotApplicable"
+	PromotionUsageLimit               = reservation + "PromotionUsageLimit"
+	NoAdultGuest                      = reservation + "NoAdultGuest"
+	MaxGuestCountExceeded             = reservation + "MaxGuestCountExceeded"
+	MaxBedsExceeded                   = reservation + "MaxBedsExceeded"
+	AddOnNotFound                     = reservation + "AddOnNotFound"
+	InvalidAddOnDates                 = reservation + "InvalidAddOnDates"
+	RateNotAvailable                  = reservation + "RateNotAvailable"
+	ReservationNotEditable            = reservation + "ReservationNotEditable"
+	/************************************************************/
+	CancellationPolicyHasRateCodeErr = rateCodes + "CancellationPolicyHasRateCodeErr"
+	InvalidParentRateCode            = rateCodes + "InvalidParentRateCode"
+	RateCodeHasDerivedRateCodes      = rateCodes + "RateCodeHasDerivedRateCodes"
+	DerivedRateCodePrices            = rateCodes + "DerivedRateCodePrices"
+	InvalidRateCalendar              = rateCodes + "InvalidRateCalendar"
+	/************************************************************/
+	ExchangeRateNotFound     = currencies + "ExchangeRateNotFound"
+	InvalidExchangeRatesFile = currencies + "InvalidExchangeRatesFile"
+	UnknownCurrencyCode      = currencies + "UnknownCurrencyCode"
+	DuplicateExchangeRate    = currencies + "DuplicateExchangeRate"
+	/************************************************************/
+	FolioSettled         = billing + "FolioSettled"
+	FolioNotBalanced     = billing + "FolioNotBalanced"
+	InvalidFolioEntry    = billing + "InvalidFolioEntry"
+	FolioEntryReversed   = billing + "FolioEntryReversed"
+	InvalidChargeAmount  = billing + "InvalidChargeAmount"
+	InvalidChargeStatus  = billing + "InvalidChargeStatus"
+	ChargeDeclined       = billing + "ChargeDeclined"
+	ChargeCurrency       = billing + "ChargeCurrency"
+	RefundExceedsPayment = billing + "RefundExceedsPayment"
+	InvalidRefund        = billing + "InvalidRefund"
+	/************************************************************/
+	InvalidWalletAmount        = wallets + "InvalidWalletAmount"
+	InsufficientWalletBalance  = wallets + "InsufficientWalletBalance"
+	WalletIdempotencyKeyReused = wallets + "WalletIdempotencyKeyReused"
+	SameWalletTransfer         = wallets + "SameWalletTransfer"
+	/************************************************************/
+	SameGuestMerge          = guests + "SameGuestMerge"
+	FieldEncryptionDisabled = guests + "FieldEncryptionDisabled"
 )
@@ -0,0 +1,397 @@
+// Package field_encryption
+// encrypts sensitive columns with data keys of tenants. Data keys are wrapped by the master key and stored in the
+// secret manager (envelope encryption), they are rotated by adding a new active version and old versions are kept
+// to decrypt values which are not re-encrypted yet.
+package field_encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ciphertextPrefix starts encrypted values, values are enc:v1:<tenant>:<key version>:<base64 nonce and sealed value>.
+	ciphertextPrefix = "enc:v1:"
+	masterKeyPath    = "field-encryption/master"
+	keySetPath       = "field-encryption/tenant-%d"
+	secretKey        = "key"
+	// keyRefreshInterval reloads key sets, so a key which is rotated by another instance becomes active here too.
+	keyRefreshInterval = 10 * time.Minute
+)
+
+var (
+	UnknownKeyErr        = errors.New("field_encryption: unknown data key")
+	InvalidCiphertextErr = errors.New("field_encryption: invalid ciphertext")
+)
+
+var (
+	defaultKeyring *Keyring
+	defaultMutex   sync.RWMutex
+)
+
+// SetDefault sets the keyring which encrypted columns use, columns are stored as they are if it is nil.
+func SetDefault(keyring *Keyring) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultKeyring = keyring
+}
+
+// Default returns the keyring which encrypted columns use, it returns nil if field encryption is not configured.
+func Default() *Keyring {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultKeyring
+}
+
+// SecretStore stores secrets with check and set, secret_manager.SecretManager is a SecretStore.
+type SecretStore interface {
+	// GetAt returns value of key in the secret at path and version of the secret, version is zero if it does not exist.
+	GetAt(ctx context.Context, path, key string) (string, int, error)
+	// PutAt writes key of the secret at path if the secret is still at version.
+	PutAt(ctx context.Context, path, key, val string, version int) error
+}
+
+// storedKeySet is the key set of a tenant in the secret store, keys are wrapped by the master key.
+type storedKeySet struct {
+	Active   uint32            `json:"active"`
+	Keys     map[uint32]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+// keySet is the unwrapped key set of a tenant.
+type keySet struct {
+	active   uint32
+	keys     map[uint32]cipher.AEAD
+	indexKey []byte
+	loadedAt time.Time
+}
+
+// Keyring encrypts values with AES-256-GCM data keys of tenants and computes blind indexes of values with HMAC-SHA256
+// index keys of tenants. Blind index keys are not rotated, indexes of values would change with them.
+type Keyring struct {
+	store   SecretStore
+	master  cipher.AEAD
+	mutex   sync.Mutex
+	tenants map[uint64]*keySet
+	now     func() time.Time
+}
+
+// NewKeyring returns a keyring of key sets in the store, the master key is created in the store if it does not exist.
+func NewKeyring(ctx context.Context, store SecretStore) (*Keyring, error) {
+
+	value, version, err := store.GetAt(ctx, masterKeyPath, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == 0 {
+		value = base64.StdEncoding.EncodeToString(randomBytes(32))
+		if err := store.PutAt(ctx, masterKeyPath, secretKey, value, 0); err != nil {
+			// another instance created the master key first.
+			if value, _, err = store.GetAt(ctx, masterKeyPath, secretKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyring{store: store, master: master, tenants: make(map[uint64]*keySet), now: time.Now}, nil
+}
+
+// IsEncrypted reports whether the value is encrypted by a keyring.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// Encrypt encrypts the value with the active data key of the tenant, the result is different every time.
+func (k *Keyring) Encrypt(tenantId uint64, value string) (string, error) {
+
+	keys, err := k.keySet(tenantId, false)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := randomBytes(12)
+	sealed := keys.keys[keys.active].Seal(nonce, nonce, []byte(value), additionalData(tenantId, keys.active))
+
+	return fmt.Sprintf("%s%d:%d:%s", ciphertextPrefix, tenantId, keys.active,
+		base64.RawStdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt decrypts a value which is encrypted by Encrypt, values which are not encrypted are returned as they are.
+func (k *Keyring) Decrypt(value string) (string, error) {
+
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, ciphertextPrefix), ":", 3)
+	if len(parts) != 3 {
+		return "", InvalidCiphertextErr
+	}
+
+	tenantId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return "", InvalidCiphertextErr
+	}
+
+	version, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return "", InvalidCiphertextErr
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < 12 {
+		return "", InvalidCiphertextErr
+	}
+
+	keys, err := k.keySet(tenantId, false)
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := keys.keys[uint32(version)]
+	if !ok {
+		// the key may be rotated by another instance after the key set is loaded.
+		if keys, err = k.keySet(tenantId, true); err != nil {
+			return "", err
+		}
+		if key, ok = keys.keys[uint32(version)]; !ok {
+			return "", UnknownKeyErr
+		}
+	}
+
+	plain, err := key.Open(nil, sealed[:12], sealed[12:], additionalData(tenantId, uint32(version)))
+	if err != nil {
+		return "", InvalidCiphertextErr
+	}
+
+	return string(plain), nil
+}
+
+// BlindIndex returns a keyed hash of the value, equal values of a tenant have equal indexes so they can be searched
+// without decrypting them.
+func (k *Keyring) BlindIndex(tenantId uint64, value string) (string, error) {
+
+	keys, err := k.keySet(tenantId, false)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, keys.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// ActivePrefix returns the prefix of values which are encrypted with the active data key of the tenant, values
+// without it need re-encryption.
+func (k *Keyring) ActivePrefix(tenantId uint64) (string, error) {
+
+	keys, err := k.keySet(tenantId, false)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d:%d:", ciphertextPrefix, tenantId, keys.active), nil
+}
+
+// Rotate adds a new data key to the tenant and makes it active, it returns version of the new key.
+// Values which are encrypted with old keys can still be decrypted until they are re-encrypted.
+func (k *Keyring) Rotate(ctx context.Context, tenantId uint64) (uint32, error) {
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	stored, version, err := k.load(ctx, tenantId)
+	if err != nil {
+		return 0, err
+	}
+
+	if version == 0 {
+		stored = k.newKeySet()
+	}
+
+	for id := range stored.Keys {
+		if id > stored.Active {
+			stored.Active = id
+		}
+	}
+	stored.Active++
+	stored.Keys[stored.Active] = k.wrap(randomBytes(32))
+
+	keys, err := k.save(ctx, tenantId, stored, version)
+	if err != nil {
+		return 0, err
+	}
+
+	return keys.active, nil
+}
+
+// keySet returns the key set of the tenant, it is created if the tenant does not have one.
+func (k *Keyring) keySet(tenantId uint64, reload bool) (*keySet, error) {
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if keys, ok := k.tenants[tenantId]; ok && !reload && k.now().Sub(keys.loadedAt) < keyRefreshInterval {
+		return keys, nil
+	}
+
+	ctx := context.Background()
+	stored, version, err := k.load(ctx, tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 {
+		return k.unwrap(tenantId, stored)
+	}
+
+	keys, saveErr := k.save(ctx, tenantId, k.newKeySet(), 0)
+	if saveErr != nil {
+		// another instance may have created the key set first.
+		if stored, version, err = k.load(ctx, tenantId); err != nil {
+			return nil, err
+		}
+		if version == 0 {
+			return nil, saveErr
+		}
+		return k.unwrap(tenantId, stored)
+	}
+
+	return keys, nil
+}
+
+func (k *Keyring) load(ctx context.Context, tenantId uint64) (*storedKeySet, int, error) {
+
+	value, version, err := k.store.GetAt(ctx, fmt.Sprintf(keySetPath, tenantId), secretKey)
+	if err != nil || version == 0 {
+		return nil, 0, err
+	}
+
+	stored := &storedKeySet{}
+	if err := json.Unmarshal([]byte(value), stored); err != nil {
+		return nil, 0, err
+	}
+
+	return stored, version, nil
+}
+
+func (k *Keyring) save(ctx context.Context, tenantId uint64, stored *storedKeySet, version int) (*keySet, error) {
+
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k.store.PutAt(ctx, fmt.Sprintf(keySetPath, tenantId), secretKey, string(value), version); err != nil {
+		return nil, err
+	}
+
+	return k.unwrap(tenantId, stored)
+}
+
+func (k *Keyring) newKeySet() *storedKeySet {
+
+	return &storedKeySet{
+		Active:   1,
+		Keys:     map[uint32]string{1: k.wrap(randomBytes(32))},
+		IndexKey: k.wrap(randomBytes(32)),
+	}
+}
+
+// unwrap decrypts keys of the stored key set with the master key and caches them.
+func (k *Keyring) unwrap(tenantId uint64, stored *storedKeySet) (*keySet, error) {
+
+	keys := &keySet{
+		active:   stored.Active,
+		keys:     make(map[uint32]cipher.AEAD),
+		loadedAt: k.now(),
+	}
+
+	for id, wrapped := range stored.Keys {
+		key, err := k.unwrapKey(wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if keys.keys[id], err = newAEAD(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := keys.keys[keys.active]; !ok {
+		return nil, UnknownKeyErr
+	}
+
+	indexKey, err := k.unwrapKey(stored.IndexKey)
+	if err != nil {
+		return nil, err
+	}
+	keys.indexKey = indexKey
+
+	k.tenants[tenantId] = keys
+	return keys, nil
+}
+
+func (k *Keyring) wrap(key []byte) string {
+
+	nonce := randomBytes(12)
+	return base64.StdEncoding.EncodeToString(k.master.Seal(nonce, nonce, key, nil))
+}
+
+func (k *Keyring) unwrapKey(wrapped string) ([]byte, error) {
+
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil || len(sealed) < 12 {
+		return nil, InvalidCiphertextErr
+	}
+
+	return k.master.Open(nil, sealed[:12], sealed[12:], nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds ciphertexts to their tenant and key, so a value can not be moved to another tenant.
+func additionalData(tenantId uint64, version uint32) []byte {
+	return []byte(fmt.Sprintf("%d:%d", tenantId, version))
+}
+
+func randomBytes(n int) []byte {
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return b
+}
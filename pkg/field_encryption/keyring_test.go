@@ -0,0 +1,119 @@
+package field_encryption
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryStore is an in memory SecretStore.
+type memoryStore struct {
+	mutex    sync.Mutex
+	values   map[string]string
+	versions map[string]int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{values: make(map[string]string), versions: make(map[string]int)}
+}
+
+func (s *memoryStore) GetAt(ctx context.Context, path, key string) (string, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.values[path+"/"+key], s.versions[path], nil
+}
+
+func (s *memoryStore) PutAt(ctx context.Context, path, key, val string, version int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.versions[path] != version {
+		return errors.New("check-and-set parameter did not match the current version")
+	}
+	s.values[path+"/"+key] = val
+	s.versions[path]++
+	return nil
+}
+
+func TestKeyring(t *testing.T) {
+
+	store := newMemoryStore()
+	keyring, err := NewKeyring(context.Background(), store)
+	assert.Nil(t, err)
+
+	t.Run("encrypts_and_decrypts", func(t *testing.T) {
+
+		first, err := keyring.Encrypt(1, "0012345678")
+		assert.Nil(t, err)
+		second, _ := keyring.Encrypt(1, "0012345678")
+
+		assert.True(t, strings.HasPrefix(first, "enc:v1:1:1:"))
+		assert.NotEqual(t, first, second)
+		assert.NotContains(t, first, "0012345678")
+
+		plain, err := keyring.Decrypt(first)
+		assert.Nil(t, err)
+		assert.Equal(t, "0012345678", plain)
+	})
+
+	t.Run("returns_plaintext_as_it_is", func(t *testing.T) {
+
+		plain, err := keyring.Decrypt("0012345678")
+		assert.Nil(t, err)
+		assert.Equal(t, "0012345678", plain)
+	})
+
+	t.Run("rejects_tampered_and_moved_values", func(t *testing.T) {
+
+		value, _ := keyring.Encrypt(1, "P123")
+		_, err := keyring.Decrypt(value[:len(value)-2] + "AA")
+		assert.Equal(t, InvalidCiphertextErr, err)
+
+		_, err = keyring.Decrypt(strings.Replace(value, "enc:v1:1:", "enc:v1:2:", 1))
+		assert.Equal(t, InvalidCiphertextErr, err)
+	})
+
+	t.Run("blind_indexes_are_per_tenant", func(t *testing.T) {
+
+		first, _ := keyring.BlindIndex(1, "0012345678")
+		second, _ := keyring.BlindIndex(1, "0012345678")
+		other, _ := keyring.BlindIndex(2, "0012345678")
+
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, other)
+	})
+
+	t.Run("rotates_keys", func(t *testing.T) {
+
+		old, _ := keyring.Encrypt(1, "P123")
+		index, _ := keyring.BlindIndex(1, "P123")
+
+		// another instance loads the key set before rotation.
+		other, err := NewKeyring(context.Background(), store)
+		assert.Nil(t, err)
+		_, err = other.Decrypt(old)
+		assert.Nil(t, err)
+
+		version, err := keyring.Rotate(context.Background(), 1)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(2), version)
+
+		rotated, _ := keyring.Encrypt(1, "P123")
+		prefix, _ := keyring.ActivePrefix(1)
+		assert.Equal(t, "enc:v1:1:2:", prefix)
+		assert.True(t, strings.HasPrefix(rotated, prefix))
+
+		plain, err := keyring.Decrypt(old)
+		assert.Nil(t, err)
+		assert.Equal(t, "P123", plain)
+
+		sameIndex, _ := keyring.BlindIndex(1, "P123")
+		assert.Equal(t, index, sameIndex)
+
+		plain, err = other.Decrypt(rotated)
+		assert.Nil(t, err)
+		assert.Equal(t, "P123", plain)
+	})
+}
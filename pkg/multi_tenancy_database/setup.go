@@ -72,6 +72,12 @@ func Migrate() error {
 					}
 				}
 
+				for _, constraint := range tenant_dsn_resolver.GetConstraints() {
+					if err := tenantDB.Exec(constraint).Error; err != nil {
+						panic(err.Error())
+					}
+				}
+
 				wg.Done()
 			}()
 
@@ -52,7 +52,11 @@ func (c *TenantDatabaseResolver) GetTenantDB(ctx context.Context) *gorm.DB {
 		}
 
 		c.cache[tenantID] = cn
-		return cn
+	}
+
+	// statements get the context, so encrypted columns know their tenant.
+	if ctx != nil {
+		return c.cache[tenantID].WithContext(ctx)
 	}
 
 	return c.cache[tenantID]
@@ -91,4 +95,10 @@ func (c *TenantDatabaseResolver) Migrate(db *gorm.DB, tenantId uint64) {
 			panic(err.Error())
 		}
 	}
+
+	for _, constraint := range tenant_dsn_resolver.GetConstraints() {
+		if err := db.Exec(constraint).Error; err != nil {
+			panic(err.Error())
+		}
+	}
 }
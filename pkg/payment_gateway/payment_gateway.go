@@ -0,0 +1,90 @@
+package payment_gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// InvalidSignatureErr is returned when a webhook payload is not signed by the gateway or its signature is expired.
+	InvalidSignatureErr = errors.New("invalid webhook signature")
+)
+
+type ChargeStatus string
+
+const (
+	// ChargePending charges need an action of the card holder, e.g. 3D secure authentication.
+	ChargePending    ChargeStatus = "pending"
+	ChargeAuthorized ChargeStatus = "authorized"
+	ChargeCaptured   ChargeStatus = "captured"
+	ChargeVoided     ChargeStatus = "voided"
+	ChargeFailed     ChargeStatus = "failed"
+)
+
+// PaymentGateway charges cards through a payment service provider. Charges are authorized first and the
+// authorized amount is captured or voided later, a charge which is authorized with Capture is captured at once.
+// Amounts are in major units of the currency, e.g. 12.50 USD.
+type PaymentGateway interface {
+	// Authorize authorizes the amount of the request on its payment method.
+	// It returns DeclinedError if the payment method is declined.
+	Authorize(ctx context.Context, request *ChargeRequest) (*ChargeResult, error)
+	// Capture captures amount of an authorized charge, the whole authorized amount is captured if amount is zero.
+	Capture(ctx context.Context, reference string, amount float64, currency string) (*ChargeResult, error)
+	// Void releases an authorized charge which is not captured.
+	Void(ctx context.Context, reference string) (*ChargeResult, error)
+	// Refund refunds amount of a captured charge, the idempotency key prevents refunding twice on retries.
+	Refund(ctx context.Context, reference string, amount float64, currency string, idempotencyKey string) (*RefundResult, error)
+	// VerifyWebhook checks signature of a webhook payload and returns its event.
+	// It returns InvalidSignatureErr if the signature does not match.
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// ChargeRequest is a charge of a payment method, PaymentMethod is a token of the card which is created by the client.
+// IdempotencyKey makes retries of the request return the same charge instead of charging twice.
+type ChargeRequest struct {
+	Amount         float64
+	Currency       string
+	PaymentMethod  string
+	Description    string
+	ReceiptEmail   string
+	Capture        bool
+	IdempotencyKey string
+	Metadata       map[string]string
+}
+
+// ChargeResult is state of a charge in the gateway, Reference is id of the charge in the gateway.
+type ChargeResult struct {
+	Reference        string
+	Status           ChargeStatus
+	AmountAuthorized float64
+	AmountCaptured   float64
+	FailureMessage   string
+	Metadata         map[string]string
+}
+
+type RefundResult struct {
+	Reference string
+	Status    string
+	Amount    float64
+}
+
+// WebhookEvent is a notification of the gateway, Charge is set for events of charges.
+type WebhookEvent struct {
+	Id      string
+	Type    string
+	Created time.Time
+	Charge  *ChargeResult
+}
+
+// DeclinedError is returned when the gateway declines a charge, Result is the failed charge if the gateway created one.
+type DeclinedError struct {
+	Code    string
+	Message string
+	Result  *ChargeResult
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("payment declined: %s (%s)", e.Message, e.Code)
+}
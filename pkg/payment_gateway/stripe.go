@@ -0,0 +1,318 @@
+package payment_gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// StripeURL is base url of the Stripe API.
+	StripeURL = "https://api.stripe.com"
+	// webhookTolerance is the maximum age of a signed webhook, older webhooks are rejected to prevent replays.
+	webhookTolerance = 5 * time.Minute
+)
+
+// zeroDecimalCurrencies are currencies which Stripe amounts are not multiplied by 100.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true, "krw": true, "mga": true,
+	"pyg": true, "rwf": true, "ugx": true, "vnd": true, "vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// StripeGateway implements PaymentGateway by Stripe payment intents. BaseURL can be pointed to a fake server in tests.
+type StripeGateway struct {
+	BaseURL       string
+	SecretKey     string
+	WebhookSecret string
+	Client        *http.Client
+	now           func() time.Time
+}
+
+// NewStripe returns new StripeGateway, Stripe API url is used if baseURL is empty.
+func NewStripe(baseURL, secretKey, webhookSecret string) *StripeGateway {
+
+	if baseURL == "" {
+		baseURL = StripeURL
+	}
+
+	return &StripeGateway{
+		BaseURL:       strings.TrimSuffix(baseURL, "/"),
+		SecretKey:     secretKey,
+		WebhookSecret: webhookSecret,
+		Client:        &http.Client{Timeout: 30 * time.Second},
+		now:           time.Now,
+	}
+}
+
+type stripePaymentIntent struct {
+	Id               string            `json:"id"`
+	Status           string            `json:"status"`
+	Amount           int64             `json:"amount"`
+	AmountCapturable int64             `json:"amount_capturable"`
+	AmountReceived   int64             `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	Metadata         map[string]string `json:"metadata"`
+	LastPaymentError *stripeError      `json:"last_payment_error"`
+}
+
+type stripeError struct {
+	Type          string               `json:"type"`
+	Code          string               `json:"code"`
+	DeclineCode   string               `json:"decline_code"`
+	Message       string               `json:"message"`
+	PaymentIntent *stripePaymentIntent `json:"payment_intent"`
+}
+
+type stripeRefund struct {
+	Id       string `json:"id"`
+	Status   string `json:"status"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type stripeEvent struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// Authorize creates and confirms a payment intent, it is captured manually unless the request is captured.
+func (g *StripeGateway) Authorize(ctx context.Context, request *ChargeRequest) (*ChargeResult, error) {
+
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(toMinorUnits(request.Amount, request.Currency), 10))
+	form.Set("currency", strings.ToLower(request.Currency))
+	form.Set("payment_method", request.PaymentMethod)
+	form.Set("payment_method_types[]", "card")
+	form.Set("confirm", "true")
+	form.Set("capture_method", "manual")
+	if request.Capture {
+		form.Set("capture_method", "automatic")
+	}
+	if request.Description != "" {
+		form.Set("description", request.Description)
+	}
+	if request.ReceiptEmail != "" {
+		form.Set("receipt_email", request.ReceiptEmail)
+	}
+	for key, value := range request.Metadata {
+		form.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+
+	intent := stripePaymentIntent{}
+	if err := g.post(ctx, "/v1/payment_intents", form, request.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+
+	return intent.result(), nil
+}
+
+func (g *StripeGateway) Capture(ctx context.Context, reference string, amount float64, currency string) (*ChargeResult, error) {
+
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(toMinorUnits(amount, currency), 10))
+	}
+
+	intent := stripePaymentIntent{}
+	if err := g.post(ctx, fmt.Sprintf("/v1/payment_intents/%s/capture", url.PathEscape(reference)), form, "", &intent); err != nil {
+		return nil, err
+	}
+
+	return intent.result(), nil
+}
+
+func (g *StripeGateway) Void(ctx context.Context, reference string) (*ChargeResult, error) {
+
+	intent := stripePaymentIntent{}
+	if err := g.post(ctx, fmt.Sprintf("/v1/payment_intents/%s/cancel", url.PathEscape(reference)), url.Values{}, "", &intent); err != nil {
+		return nil, err
+	}
+
+	return intent.result(), nil
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, reference string, amount float64, currency string, idempotencyKey string) (*RefundResult, error) {
+
+	form := url.Values{}
+	form.Set("payment_intent", reference)
+	if amount > 0 {
+		form.Set("amount", strconv.FormatInt(toMinorUnits(amount, currency), 10))
+	}
+
+	refund := stripeRefund{}
+	if err := g.post(ctx, "/v1/refunds", form, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+
+	return &RefundResult{
+		Reference: refund.Id,
+		Status:    refund.Status,
+		Amount:    fromMinorUnits(refund.Amount, refund.Currency),
+	}, nil
+}
+
+// VerifyWebhook checks the Stripe-Signature header of a webhook, it has the signing time and HMAC-SHA256
+// signatures of "time.payload" like t=1492774577,v1=5257a869...
+func (g *StripeGateway) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+
+	var timestamp int64
+	signatures := make([]string, 0)
+
+	for _, part := range strings.Split(signature, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return nil, InvalidSignatureErr
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.WebhookSecret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, s := range signatures {
+		if actual, err := hex.DecodeString(s); err == nil && hmac.Equal(actual, expected) {
+			valid = true
+			break
+		}
+	}
+
+	signedAt := time.Unix(timestamp, 0)
+	if !valid || g.now().Sub(signedAt) > webhookTolerance || signedAt.Sub(g.now()) > webhookTolerance {
+		return nil, InvalidSignatureErr
+	}
+
+	event := stripeEvent{}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	result := &WebhookEvent{Id: event.Id, Type: event.Type, Created: time.Unix(event.Created, 0)}
+
+	if strings.HasPrefix(event.Type, "payment_intent.") {
+		intent := stripePaymentIntent{}
+		if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+			return nil, err
+		}
+		result.Charge = intent.result()
+	}
+
+	return result, nil
+}
+
+// post sends a form encoded request to the Stripe API and decodes its response to out.
+// Card errors are returned as DeclinedError.
+func (g *StripeGateway) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, g.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+g.SecretKey)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		request.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	response, err := g.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+
+		body := struct {
+			Error stripeError `json:"error"`
+		}{}
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			return fmt.Errorf("stripe: unexpected response status %d", response.StatusCode)
+		}
+
+		if body.Error.Type == "card_error" {
+			declined := &DeclinedError{Code: body.Error.Code, Message: body.Error.Message}
+			if body.Error.DeclineCode != "" {
+				declined.Code = body.Error.DeclineCode
+			}
+			if body.Error.PaymentIntent != nil {
+				declined.Result = body.Error.PaymentIntent.result()
+			}
+			return declined
+		}
+
+		return fmt.Errorf("stripe: %s (%d)", body.Error.Message, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// result converts the payment intent to ChargeResult.
+func (i *stripePaymentIntent) result() *ChargeResult {
+
+	result := &ChargeResult{
+		Reference:        i.Id,
+		AmountAuthorized: fromMinorUnits(i.AmountCapturable, i.Currency),
+		AmountCaptured:   fromMinorUnits(i.AmountReceived, i.Currency),
+		Metadata:         i.Metadata,
+	}
+
+	switch i.Status {
+	case "requires_capture":
+		result.Status = ChargeAuthorized
+	case "succeeded":
+		result.Status = ChargeCaptured
+	case "canceled":
+		result.Status = ChargeVoided
+	case "requires_payment_method":
+		result.Status = ChargeFailed
+	default:
+		result.Status = ChargePending
+	}
+
+	if i.LastPaymentError != nil {
+		result.FailureMessage = i.LastPaymentError.Message
+	}
+
+	return result
+}
+
+func toMinorUnits(amount float64, currency string) int64 {
+
+	if zeroDecimalCurrencies[strings.ToLower(currency)] {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(amount int64, currency string) float64 {
+
+	if zeroDecimalCurrencies[strings.ToLower(currency)] {
+		return float64(amount)
+	}
+	return float64(amount) / 100
+}
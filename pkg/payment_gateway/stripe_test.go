@@ -0,0 +1,194 @@
+package payment_gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testSecretKey     = "sk_test_123"
+	testWebhookSecret = "whsec_test_123"
+)
+
+// fakeStripe serves payment intent and refund endpoints of the Stripe API and records the last request form.
+func fakeStripe(t *testing.T) (*httptest.Server, *http.Request) {
+
+	last := &http.Request{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		assert.Equal(t, "Bearer "+testSecretKey, r.Header.Get("Authorization"))
+		assert.Nil(t, r.ParseForm())
+		*last = *r
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/payment_intents":
+			if r.PostForm.Get("payment_method") == "pm_card_chargeDeclined" {
+				w.WriteHeader(http.StatusPaymentRequired)
+				fmt.Fprint(w, `{"error":{"type":"card_error","code":"card_declined","decline_code":"generic_decline",
+					"message":"Your card was declined.","payment_intent":{"id":"pi_2","status":"requires_payment_method",
+					"amount":1250,"currency":"usd"}}}`)
+				return
+			}
+			status := "requires_capture"
+			capturable, received := r.PostForm.Get("amount"), "0"
+			if r.PostForm.Get("capture_method") == "automatic" {
+				status, capturable, received = "succeeded", "0", r.PostForm.Get("amount")
+			}
+			fmt.Fprintf(w, `{"id":"pi_1","status":"%s","amount":%s,"amount_capturable":%s,"amount_received":%s,
+				"currency":"%s","metadata":{"charge_id":"7"}}`, status, r.PostForm.Get("amount"), capturable, received,
+				r.PostForm.Get("currency"))
+		case "/v1/payment_intents/pi_1/capture":
+			fmt.Fprintf(w, `{"id":"pi_1","status":"succeeded","amount":1250,"amount_received":%s,"currency":"usd"}`,
+				r.PostForm.Get("amount_to_capture"))
+		case "/v1/payment_intents/pi_1/cancel":
+			fmt.Fprint(w, `{"id":"pi_1","status":"canceled","amount":1250,"currency":"usd"}`)
+		case "/v1/refunds":
+			fmt.Fprintf(w, `{"id":"re_1","status":"succeeded","amount":%s,"currency":"usd"}`, r.PostForm.Get("amount"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"type":"invalid_request_error","message":"No such payment_intent"}}`)
+		}
+	}))
+
+	return server, last
+}
+
+func TestStripeGateway(t *testing.T) {
+
+	server, last := fakeStripe(t)
+	defer server.Close()
+
+	gateway := NewStripe(server.URL, testSecretKey, testWebhookSecret)
+	ctx := context.Background()
+
+	t.Run("authorizes_amount_in_minor_units", func(t *testing.T) {
+
+		result, err := gateway.Authorize(ctx, &ChargeRequest{Amount: 12.5, Currency: "USD", PaymentMethod: "pm_card_visa",
+			IdempotencyKey: "charge-1", Metadata: map[string]string{"charge_id": "7"}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1250", last.PostForm.Get("amount"))
+		assert.Equal(t, "manual", last.PostForm.Get("capture_method"))
+		assert.Equal(t, "7", last.PostForm.Get("metadata[charge_id]"))
+		assert.Equal(t, "charge-1", last.Header.Get("Idempotency-Key"))
+		assert.Equal(t, "pi_1", result.Reference)
+		assert.Equal(t, ChargeAuthorized, result.Status)
+		assert.Equal(t, 12.5, result.AmountAuthorized)
+	})
+
+	t.Run("captures_at_once_when_requested", func(t *testing.T) {
+
+		result, err := gateway.Authorize(ctx, &ChargeRequest{Amount: 5000, Currency: "jpy", PaymentMethod: "pm_card_visa",
+			Capture: true})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "5000", last.PostForm.Get("amount"))
+		assert.Equal(t, ChargeCaptured, result.Status)
+		assert.Equal(t, 5000.0, result.AmountCaptured)
+	})
+
+	t.Run("returns_declined_error", func(t *testing.T) {
+
+		_, err := gateway.Authorize(ctx, &ChargeRequest{Amount: 12.5, Currency: "usd", PaymentMethod: "pm_card_chargeDeclined"})
+
+		declined := &DeclinedError{}
+		assert.True(t, errors.As(err, &declined))
+		assert.Equal(t, "generic_decline", declined.Code)
+		assert.Equal(t, "pi_2", declined.Result.Reference)
+		assert.Equal(t, ChargeFailed, declined.Result.Status)
+	})
+
+	t.Run("captures_partial_amount", func(t *testing.T) {
+
+		result, err := gateway.Capture(ctx, "pi_1", 10, "usd")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "1000", last.PostForm.Get("amount_to_capture"))
+		assert.Equal(t, ChargeCaptured, result.Status)
+		assert.Equal(t, 10.0, result.AmountCaptured)
+	})
+
+	t.Run("voids_authorization", func(t *testing.T) {
+
+		result, err := gateway.Void(ctx, "pi_1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, ChargeVoided, result.Status)
+	})
+
+	t.Run("refunds_amount", func(t *testing.T) {
+
+		result, err := gateway.Refund(ctx, "pi_1", 2.25, "usd", "refund-1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "pi_1", last.PostForm.Get("payment_intent"))
+		assert.Equal(t, "refund-1", last.Header.Get("Idempotency-Key"))
+		assert.Equal(t, "re_1", result.Reference)
+		assert.Equal(t, 2.25, result.Amount)
+	})
+
+	t.Run("returns_api_error", func(t *testing.T) {
+
+		_, err := gateway.Void(ctx, "pi_unknown")
+
+		assert.NotNil(t, err)
+		assert.False(t, errors.As(err, new(*DeclinedError)))
+	})
+}
+
+func TestStripeVerifyWebhook(t *testing.T) {
+
+	now := time.Unix(1700000000, 0)
+	gateway := NewStripe("", testSecretKey, testWebhookSecret)
+	gateway.now = func() time.Time { return now }
+
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","created":1700000000,
+		"data":{"object":{"id":"pi_1","status":"succeeded","amount_received":1250,"currency":"usd",
+		"metadata":{"tenant_id":"1","charge_id":"7"}}}}`)
+
+	sign := func(timestamp int64, secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, payload)))
+		return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	t.Run("accepts_signed_payload", func(t *testing.T) {
+
+		event, err := gateway.VerifyWebhook(payload, sign(now.Unix(), testWebhookSecret))
+
+		assert.Nil(t, err)
+		assert.Equal(t, "evt_1", event.Id)
+		assert.Equal(t, ChargeCaptured, event.Charge.Status)
+		assert.Equal(t, 12.5, event.Charge.AmountCaptured)
+		assert.Equal(t, "7", event.Charge.Metadata["charge_id"])
+	})
+
+	t.Run("rejects_wrong_secret", func(t *testing.T) {
+
+		_, err := gateway.VerifyWebhook(payload, sign(now.Unix(), "whsec_other"))
+		assert.Equal(t, InvalidSignatureErr, err)
+	})
+
+	t.Run("rejects_expired_signature", func(t *testing.T) {
+
+		_, err := gateway.VerifyWebhook(payload, sign(now.Add(-10*time.Minute).Unix(), testWebhookSecret))
+		assert.Equal(t, InvalidSignatureErr, err)
+	})
+
+	t.Run("rejects_malformed_header", func(t *testing.T) {
+
+		_, err := gateway.VerifyWebhook(payload, "v1=abc")
+		assert.Equal(t, InvalidSignatureErr, err)
+	})
+}
@@ -2,6 +2,7 @@ package secret_manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	vault "github.com/hashicorp/vault/api"
 	"reservation-api/pkg/env"
@@ -60,3 +61,35 @@ func (s *SecretManager) Get(ctx context.Context, key string) (interface{}, error
 	return value, nil
 
 }
+
+// GetAt returns value of key in the secret at path under secrets of the application and version of the secret,
+// version is zero if the secret does not exist.
+func (s *SecretManager) GetAt(ctx context.Context, path, key string) (string, int, error) {
+
+	secret, err := s.client.KVv2(mountPath).Get(ctx, secretPath+"/"+path)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	value, _ := secret.Data[key].(string)
+	if secret.VersionMetadata == nil {
+		return value, 0, nil
+	}
+
+	return value, secret.VersionMetadata.Version, nil
+}
+
+// PutAt writes key of the secret at path under secrets of the application if the secret is still at version,
+// zero version creates the secret. It fails if the secret is changed after it is read, so concurrent writers do not
+// overwrite each other.
+func (s *SecretManager) PutAt(ctx context.Context, path, key, val string, version int) error {
+
+	secretData := make(map[string]interface{})
+	secretData[key] = val
+
+	_, err := s.client.KVv2(mountPath).Put(ctx, secretPath+"/"+path, secretData, vault.WithCheckAndSet(version))
+	return err
+}
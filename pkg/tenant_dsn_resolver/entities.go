@@ -1,6 +1,10 @@
 package tenant_dsn_resolver
 
-import "reservation-api/internal/models"
+import (
+	"fmt"
+	"reservation-api/internal/models"
+	"strings"
+)
 
 func GetEntities() []interface{} {
 	return []interface{}{
@@ -24,5 +28,78 @@ func GetEntities() []interface{} {
 		models.RateCodeDetailPrice{},
 		models.Sharer{},
 		models.Thumbnail{},
+		models.CancellationPolicy{},
+		models.Payment{},
+		models.ReservationStatusHistory{},
+		models.ReservationNight{},
+		models.TaxRule{},
+		models.ReservationTax{},
+		models.ExchangeRate{},
+		models.Promotion{},
+		models.PromotionRateCode{},
+		models.PromotionRoomType{},
+		models.PromotionRedemption{},
+		models.PricingRule{},
+		models.PriceAdjustmentLog{},
+		models.ChildAgeBand{},
+		models.ReservationChild{},
+		models.RateCodeDetailChildPrice{},
+		models.AddOn{},
+		models.ReservationAddOn{},
+		models.RateCodeAddOn{},
+		models.Folio{},
+		models.FolioEntry{},
+		models.Invoice{},
+		models.InvoiceSequence{},
+		models.Charge{},
+		models.Wallet{},
+		models.WalletTransaction{},
+		models.GuestMergeLog{},
+		models.GuestEmail{},
+		models.RetentionPolicy{},
+	}
+}
+
+// GetConstraints returns database constraints which can not be declared with gorm tags,
+// these statements run after entities migration and must be safe to run many times.
+func GetConstraints() []string {
+
+	releasedStatuses := make([]string, 0)
+	for _, status := range models.ReleasedCheckStatuses {
+		releasedStatuses = append(releasedStatuses, fmt.Sprintf("%d", status))
+	}
+
+	active := func(alias string) string {
+		return fmt.Sprintf("%[1]sdeleted_at IS NULL AND %[1]sis_group_master = false AND %[1]scheck_status NOT IN (%[2]s)",
+			alias, strings.Join(releasedStatuses, ","))
+	}
+
+	return []string{
+		// btree_gist lets exclusion constraints compare room_id with "=" beside range overlapping.
+		"CREATE EXTENSION IF NOT EXISTS btree_gist",
+		// prevent two active reservations of the same room to overlap, see models.StayRangeSQL. group masters do not
+		// occupy rooms. The constraint is created once, a constraint over timestamps is replaced by it. Stays which
+		// already overlap would fail the migration, so the constraint is not created until they are resolved, they
+		// are listed by GET /reservation/overlaps.
+		fmt.Sprintf(`DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'reservations_room_stay_excl'
+				AND pg_get_constraintdef(oid) LIKE '%%daterange%%') THEN
+				RETURN;
+			END IF;
+
+			IF EXISTS (SELECT 1 FROM reservations a INNER JOIN reservations b
+				ON b.room_id = a.room_id AND b.id > a.id AND %[1]s && %[2]s WHERE %[3]s AND %[4]s) THEN
+				RAISE WARNING 'reservations_room_stay_excl is not created, reservations of a room overlap';
+				RETURN;
+			END IF;
+
+			ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservations_room_stay_excl;
+			ALTER TABLE reservations ADD CONSTRAINT reservations_room_stay_excl EXCLUDE USING gist (
+				room_id WITH =,
+				%[5]s WITH &&
+			) WHERE (%[6]s);
+		END $$`, models.StayRangeSQL("a."), models.StayRangeSQL("b."), active("a."), active("b."),
+			models.StayRangeSQL(""), active("")),
 	}
 }